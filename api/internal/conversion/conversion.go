@@ -0,0 +1,267 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conversion provides the lossless conversion of objects between the
+// versions of the HelmRelease API.
+//
+// Objects are converted through their JSON representation, as the versions
+// of the API share their field names and types. Fields which can not be
+// represented in the destination version are stored in the DataAnnotation
+// of the destination object, and restored from it when the object is
+// converted back.
+package conversion
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DataAnnotation is the annotation used to store the fields of an object
+// which could not be represented in the version it was converted to.
+const DataAnnotation = "helm.toolkit.fluxcd.io/conversion-data"
+
+// sections are the top-level fields of an object which are subject to
+// conversion. The metadata is equal across all versions.
+var sections = []string{"spec", "status"}
+
+// entry holds the data required to restore a field of an object.
+type entry struct {
+	// Operations turn the field of the converted object into the field of
+	// the source object.
+	Operations []operation `json:"ops"`
+	// Digest is the digest of the field of the converted object. The field
+	// is only restored when it is unchanged, so changes made to the
+	// converted object take precedence over the stored data.
+	Digest string `json:"digest"`
+}
+
+// operation sets or removes the value at a path relative to a field.
+type operation struct {
+	Path   []string    `json:"path,omitempty"`
+	Value  interface{} `json:"value,omitempty"`
+	Remove bool        `json:"remove,omitempty"`
+}
+
+// Convert converts src into dst. The given translate func is called with
+// the JSON representation of src (after restoring any data stored by an
+// earlier conversion), to rewrite fields which are represented differently
+// in the version of dst. It may be nil.
+func Convert(src, dst runtime.Object, translate func(obj map[string]interface{}) error) error {
+	obj, err := toMap(src)
+	if err != nil {
+		return err
+	}
+	if err = restore(obj); err != nil {
+		return err
+	}
+
+	orig := runtime.DeepCopyJSON(obj)
+	if translate != nil {
+		if err = translate(obj); err != nil {
+			return fmt.Errorf("failed to translate %T: %w", src, err)
+		}
+	}
+
+	gvk := dst.GetObjectKind().GroupVersionKind()
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(dst).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err = json.Unmarshal(b, dst); err != nil {
+		return fmt.Errorf("failed to convert %T to %T: %w", src, dst, err)
+	}
+	dst.GetObjectKind().SetGroupVersionKind(gvk)
+
+	converted, err := toMap(dst)
+	if err != nil {
+		return err
+	}
+	entries := make(map[string]entry)
+	for _, s := range sections {
+		o, _ := orig[s].(map[string]interface{})
+		c, _ := converted[s].(map[string]interface{})
+		for _, k := range keys(o, c) {
+			ov, oOK := o[k]
+			cv, cOK := c[k]
+			if ops := diff(nil, ov, oOK, cv, cOK); len(ops) > 0 {
+				entries[s+"."+k] = entry{Operations: ops, Digest: digest(cv)}
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	accessor, err := apimeta.Accessor(dst)
+	if err != nil {
+		return err
+	}
+	annotations := accessor.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[DataAnnotation] = string(data)
+	accessor.SetAnnotations(annotations)
+	return nil
+}
+
+// restore removes the DataAnnotation from the given object, and restores the
+// fields stored in it which are unchanged since the conversion.
+func restore(obj map[string]interface{}) error {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	data, ok := annotations[DataAnnotation].(string)
+	if !ok {
+		return nil
+	}
+	delete(annotations, DataAnnotation)
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+
+	var entries map[string]entry
+	if err := unmarshal([]byte(data), &entries); err != nil {
+		return fmt.Errorf("failed to parse %s annotation: %w", DataAnnotation, err)
+	}
+	for path, e := range entries {
+		s, k, ok := strings.Cut(path, ".")
+		if !ok {
+			continue
+		}
+		section, _ := obj[s].(map[string]interface{})
+		if digest(section[k]) != e.Digest {
+			continue
+		}
+		if section == nil {
+			section = make(map[string]interface{})
+			obj[s] = section
+		}
+		for _, op := range e.Operations {
+			apply(section, append([]string{k}, op.Path...), op)
+		}
+	}
+	return nil
+}
+
+// diff returns the operations which turn the converted value c into the
+// original value o at the given path. The ok values indicate if the values
+// are present.
+func diff(path []string, o interface{}, oOK bool, c interface{}, cOK bool) []operation {
+	switch {
+	case oOK == cOK && reflect.DeepEqual(o, c):
+		return nil
+	case !oOK:
+		return []operation{{Path: path, Remove: true}}
+	}
+	om, ok := o.(map[string]interface{})
+	if !ok || !cOK {
+		return []operation{{Path: path, Value: o}}
+	}
+	cm, ok := c.(map[string]interface{})
+	if !ok {
+		return []operation{{Path: path, Value: o}}
+	}
+	var ops []operation
+	for _, k := range keys(om, cm) {
+		ov, oOK := om[k]
+		cv, cOK := cm[k]
+		p := append(append([]string{}, path...), k)
+		ops = append(ops, diff(p, ov, oOK, cv, cOK)...)
+	}
+	return ops
+}
+
+// apply applies the given operation to the value at the given path in m.
+func apply(m map[string]interface{}, path []string, op operation) {
+	for _, k := range path[:len(path)-1] {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			if op.Remove {
+				return
+			}
+			next = make(map[string]interface{})
+			m[k] = next
+		}
+		m = next
+	}
+	k := path[len(path)-1]
+	if op.Remove {
+		delete(m, k)
+		return
+	}
+	m[k] = op.Value
+}
+
+// keys returns the sorted union of the keys of the given maps, so the
+// operations stored in the DataAnnotation are in a stable order.
+func keys(maps ...map[string]interface{}) []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// digest returns the SHA-256 digest of the JSON representation of v.
+func digest(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+}
+
+// toMap returns the JSON representation of the given object as a map,
+// without its type information.
+func toMap(obj runtime.Object) (map[string]interface{}, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err = unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "apiVersion")
+	delete(m, "kind")
+	return m, nil
+}
+
+// unmarshal decodes the given JSON into v, while preserving the precision of
+// numbers.
+func unmarshal(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(v)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_diff(t *testing.T) {
+	tests := []struct {
+		name      string
+		orig      map[string]interface{}
+		converted map[string]interface{}
+	}{
+		{
+			name:      "equal",
+			orig:      map[string]interface{}{"a": "b"},
+			converted: map[string]interface{}{"a": "b"},
+		},
+		{
+			name:      "removed field",
+			orig:      map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": nil}},
+			converted: map[string]interface{}{},
+		},
+		{
+			name:      "added field",
+			orig:      map[string]interface{}{"a": map[string]interface{}{"b": "c"}},
+			converted: map[string]interface{}{"a": map[string]interface{}{"b": "c", "d": []interface{}{"e"}}},
+		},
+		{
+			name:      "changed list",
+			orig:      map[string]interface{}{"a": []interface{}{"b", nil}},
+			converted: map[string]interface{}{"a": []interface{}{"c"}},
+		},
+		{
+			name:      "null value",
+			orig:      map[string]interface{}{"a": map[string]interface{}{"b": nil}},
+			converted: map[string]interface{}{"a": map[string]interface{}{"b": "c"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := diff(nil, tt.orig, true, tt.converted, true)
+			if reflect.DeepEqual(tt.orig, tt.converted) != (len(ops) == 0) {
+				t.Fatalf("diff() = %v, want no operations only for equal values", ops)
+			}
+			for _, op := range ops {
+				apply(tt.converted, op.Path, op)
+			}
+			if !reflect.DeepEqual(tt.converted, tt.orig) {
+				t.Errorf("apply(diff()) = %v, want %v", tt.converted, tt.orig)
+			}
+		})
+	}
+}
+
+func Test_keys(t *testing.T) {
+	got := keys(
+		map[string]interface{}{"d": nil, "b": nil, "a": nil},
+		map[string]interface{}{"c": nil, "a": nil},
+	)
+	want := []string{"a", "b", "c", "d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keys() = %v, want %v", got, want)
+	}
+}
+
+func TestConvertDeprecatedFields(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"chart": map[string]interface{}{
+				"spec": map[string]interface{}{"valuesFile": ""},
+			},
+			"postRenderers": []interface{}{
+				map[string]interface{}{},
+				map[string]interface{}{"kustomize": map[string]interface{}{"patchesStrategicMerge": []interface{}{}}},
+			},
+		},
+	}
+	want := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"chart": map[string]interface{}{
+				"spec": map[string]interface{}{},
+			},
+			"postRenderers": []interface{}{
+				map[string]interface{}{},
+				map[string]interface{}{"kustomize": map[string]interface{}{}},
+			},
+		},
+	}
+
+	if err := ConvertDeprecatedFields(obj); err != nil {
+		t.Fatalf("ConvertDeprecatedFields() error = %v", err)
+	}
+	if !reflect.DeepEqual(obj, want) {
+		t.Errorf("ConvertDeprecatedFields() = %v, want %v", obj, want)
+	}
+}
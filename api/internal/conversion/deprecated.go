@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import "encoding/json"
+
+// ConvertDeprecatedFields rewrites the deprecated fields of the given
+// v2beta1 or v2beta2 HelmRelease JSON representation into their v2
+// equivalent, so that the converted object behaves the same:
+//
+//   - spec.chart.spec.valuesFile is prepended to spec.chart.spec.valuesFiles.
+//   - spec.postRenderers[].kustomize.patchesStrategicMerge entries are
+//     prepended to spec.postRenderers[].kustomize.patches, without a target.
+//   - spec.postRenderers[].kustomize.patchesJson6902 entries are appended to
+//     spec.postRenderers[].kustomize.patches, with the operations encoded as
+//     a JSON patch.
+//
+// The original fields are restored from the DataAnnotation when the object
+// is converted back, as long as the rewritten fields are unchanged.
+func ConvertDeprecatedFields(obj map[string]interface{}) error {
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if chart, ok := nestedMap(spec, "chart", "spec"); ok {
+		if f, ok := chart["valuesFile"].(string); ok {
+			delete(chart, "valuesFile")
+			if f != "" {
+				files, _ := chart["valuesFiles"].([]interface{})
+				chart["valuesFiles"] = append([]interface{}{f}, files...)
+			}
+		}
+	}
+
+	renderers, _ := spec["postRenderers"].([]interface{})
+	for _, r := range renderers {
+		kustomize, ok := nestedMap(r, "kustomize")
+		if !ok {
+			continue
+		}
+
+		var patches []interface{}
+		psm, _ := kustomize["patchesStrategicMerge"].([]interface{})
+		for _, p := range psm {
+			b, err := json.Marshal(p)
+			if err != nil {
+				return err
+			}
+			patches = append(patches, map[string]interface{}{"patch": string(b)})
+		}
+		existing, _ := kustomize["patches"].([]interface{})
+		patches = append(patches, existing...)
+		json6902, _ := kustomize["patchesJson6902"].([]interface{})
+		for _, p := range json6902 {
+			pm, _ := p.(map[string]interface{})
+			b, err := json.Marshal(pm["patch"])
+			if err != nil {
+				return err
+			}
+			patch := map[string]interface{}{"patch": string(b)}
+			if target, ok := pm["target"]; ok {
+				patch["target"] = target
+			}
+			patches = append(patches, patch)
+		}
+
+		delete(kustomize, "patchesStrategicMerge")
+		delete(kustomize, "patchesJson6902")
+		if len(patches) > 0 {
+			kustomize["patches"] = patches
+		}
+	}
+	return nil
+}
+
+// nestedMap returns the map at the given path of fields in v, and true if it
+// exists.
+func nestedMap(v interface{}, fields ...string) (map[string]interface{}, bool) {
+	m, ok := v.(map[string]interface{})
+	for _, f := range fields {
+		if !ok {
+			return nil, false
+		}
+		m, ok = m[f].(map[string]interface{})
+	}
+	return m, ok
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fuzzer provides a fuzzer for the HelmRelease API types, which
+// generates objects that can be represented in JSON.
+package fuzzer
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	fuzz "github.com/google/gofuzz"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// New returns a new fuzzer seeded with the given seed.
+func New(seed int64) *fuzz.Fuzzer {
+	return fuzz.NewWithSeed(seed).NilChance(.2).NumElements(0, 3).Funcs(
+		func(t *metav1.TypeMeta, _ fuzz.Continue) {
+			// The type information is set by the caller.
+			*t = metav1.TypeMeta{}
+		},
+		func(m *metav1.ObjectMeta, c fuzz.Continue) {
+			*m = metav1.ObjectMeta{}
+			c.Fuzz(&m.Name)
+			c.Fuzz(&m.Namespace)
+			c.Fuzz(&m.Generation)
+			c.Fuzz(&m.Labels)
+			c.Fuzz(&m.Annotations)
+		},
+		func(d *metav1.Duration, c fuzz.Continue) {
+			d.Duration = time.Duration(c.Int63n(int64(24 * time.Hour)))
+		},
+		func(j *apiextensionsv1.JSON, c fuzz.Continue) {
+			j.Raw, _ = json.Marshal(map[string]string{"key": c.RandString()})
+		},
+	)
+}
+
+// Fuzz fills obj with random values using the given fuzzer. The object is
+// normalized through a JSON round trip, so that values without a distinct
+// JSON representation (e.g. a pointer to a nil slice) equal their decoded
+// form.
+func Fuzz(f *fuzz.Fuzzer, obj interface{}) {
+	f.Fuzz(obj)
+	b, err := json.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err = json.Unmarshal(b, obj); err != nil {
+		panic(err)
+	}
+}
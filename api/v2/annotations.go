@@ -30,6 +30,25 @@ const (
 	// The value is interpreted as a token, and must equal the value of
 	// meta.ReconcileRequestAnnotation in order to reset the failure counts.
 	ResetRequestAnnotation string = "reconcile.fluxcd.io/resetAt"
+
+	// UpgradeModeAnnotation is the annotation used for overriding the
+	// upgrade mode of a HelmRelease, without changing its spec. For example,
+	// to plan the next upgrade before it is performed.
+	// The value must be a valid UpgradeMode, any other value is ignored.
+	UpgradeModeAnnotation string = "helm.toolkit.fluxcd.io/upgradeMode"
+
+	// ApproveRequestAnnotation is the annotation used for approving a pending
+	// Helm upgrade of a HelmRelease which requires approval.
+	// The value is interpreted as a token, and must equal the value of
+	// meta.ReconcileRequestAnnotation in order to approve the upgrade
+	// planned in HelmReleaseStatus.UpgradePlan.
+	ApproveRequestAnnotation string = "reconcile.fluxcd.io/approve"
+
+	// UpgradeRequestAnnotation is the annotation used for releasing a Helm
+	// upgrade which is held by the upgrade policy of a HelmRelease.
+	// The value is interpreted as a token, and must equal the value of
+	// meta.ReconcileRequestAnnotation in order to release the upgrade.
+	UpgradeRequestAnnotation string = "reconcile.fluxcd.io/upgradeAt"
 )
 
 // ShouldHandleResetRequest returns true if the HelmRelease has a reset request
@@ -56,6 +75,45 @@ func ShouldHandleForceRequest(obj *HelmRelease) bool {
 	return handleRequest(obj, ForceRequestAnnotation, &obj.Status.LastHandledForceAt)
 }
 
+// ShouldHandleUpgradeRequest returns true if the HelmRelease has an upgrade
+// request annotation, and the value of the annotation matches the value of
+// the meta.ReconcileRequestAnnotation annotation.
+//
+// To ensure that the upgrade request is handled only once, the value of
+// HelmReleaseStatus.LastHandledUpgradeAt is updated to match the value of the
+// upgrade request annotation (even if the upgrade request is not handled
+// because the value of the meta.ReconcileRequestAnnotation annotation does
+// not match).
+func ShouldHandleUpgradeRequest(obj *HelmRelease) bool {
+	return handleRequest(obj, UpgradeRequestAnnotation, &obj.Status.LastHandledUpgradeAt)
+}
+
+// ShouldHandleApproveRequest returns true if the Helm upgrade with the given
+// digest has been approved. This is the case if the HelmRelease has an
+// approve request annotation, the value of the annotation matches the value
+// of the meta.ReconcileRequestAnnotation annotation, and the given digest
+// matches the digest of the HelmReleaseStatus.UpgradePlan. Or, if the
+// upgrade with the given digest was approved by an earlier request.
+//
+// To ensure that the approve request is handled only once, the value of
+// HelmReleaseStatus.LastHandledApproveAt is updated to match the value of the
+// approve request annotation (even if the approve request is not handled
+// because the value of the meta.ReconcileRequestAnnotation annotation does
+// not match). The digest of the approved upgrade is recorded in
+// HelmReleaseStatus.ApprovedUpgradeDigest, so that the approval remains
+// valid for any retry of the same upgrade.
+func ShouldHandleApproveRequest(obj *HelmRelease, digest string) bool {
+	if digest == "" {
+		return false
+	}
+	if handleRequest(obj, ApproveRequestAnnotation, &obj.Status.LastHandledApproveAt) {
+		if plan := obj.Status.UpgradePlan; plan != nil && plan.Digest == digest {
+			obj.Status.ApprovedUpgradeDigest = digest
+		}
+	}
+	return obj.Status.ApprovedUpgradeDigest == digest
+}
+
 // handleRequest returns true if the HelmRelease has a request annotation, and
 // the value of the annotation matches the value of the meta.ReconcileRequestAnnotation
 // annotation.
@@ -78,6 +78,137 @@ func TestShouldHandleForceRequest(t *testing.T) {
 	})
 }
 
+func TestShouldHandleUpgradeRequest(t *testing.T) {
+	t.Run("should handle upgrade request", func(t *testing.T) {
+		obj := &HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					meta.ReconcileRequestAnnotation: "b",
+					UpgradeRequestAnnotation:        "b",
+				},
+			},
+			Status: HelmReleaseStatus{
+				LastHandledUpgradeAt: "a",
+				ReconcileRequestStatus: meta.ReconcileRequestStatus{
+					LastHandledReconcileAt: "a",
+				},
+			},
+		}
+
+		if !ShouldHandleUpgradeRequest(obj) {
+			t.Error("ShouldHandleUpgradeRequest() = false")
+		}
+
+		if obj.Status.LastHandledUpgradeAt != "b" {
+			t.Error("ShouldHandleUpgradeRequest did not update LastHandledUpgradeAt")
+		}
+	})
+}
+
+func TestShouldHandleApproveRequest(t *testing.T) {
+	t.Run("should handle approve request for planned digest", func(t *testing.T) {
+		obj := &HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					meta.ReconcileRequestAnnotation: "b",
+					ApproveRequestAnnotation:        "b",
+				},
+			},
+			Status: HelmReleaseStatus{
+				LastHandledApproveAt: "a",
+				ReconcileRequestStatus: meta.ReconcileRequestStatus{
+					LastHandledReconcileAt: "a",
+				},
+				UpgradePlan: &UpgradePlan{
+					Digest: "sha256:b",
+				},
+			},
+		}
+
+		if !ShouldHandleApproveRequest(obj, "sha256:b") {
+			t.Error("ShouldHandleApproveRequest() = false")
+		}
+
+		if obj.Status.LastHandledApproveAt != "b" {
+			t.Error("ShouldHandleApproveRequest did not update LastHandledApproveAt")
+		}
+
+		if obj.Status.ApprovedUpgradeDigest != "sha256:b" {
+			t.Error("ShouldHandleApproveRequest did not update ApprovedUpgradeDigest")
+		}
+
+		// The approval remains valid for a retry of the same upgrade.
+		if !ShouldHandleApproveRequest(obj, "sha256:b") {
+			t.Error("ShouldHandleApproveRequest() = false for retry")
+		}
+
+		// But never for an upgrade with a different digest.
+		if ShouldHandleApproveRequest(obj, "sha256:c") {
+			t.Error("ShouldHandleApproveRequest() = true for other digest")
+		}
+	})
+
+	t.Run("should not handle approve request for other planned digest", func(t *testing.T) {
+		obj := &HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					meta.ReconcileRequestAnnotation: "b",
+					ApproveRequestAnnotation:        "b",
+				},
+			},
+			Status: HelmReleaseStatus{
+				LastHandledApproveAt: "a",
+				ReconcileRequestStatus: meta.ReconcileRequestStatus{
+					LastHandledReconcileAt: "a",
+				},
+				UpgradePlan: &UpgradePlan{
+					Digest: "sha256:a",
+				},
+			},
+		}
+
+		if ShouldHandleApproveRequest(obj, "sha256:b") {
+			t.Error("ShouldHandleApproveRequest() = true")
+		}
+
+		if obj.Status.LastHandledApproveAt != "b" {
+			t.Error("ShouldHandleApproveRequest did not update LastHandledApproveAt")
+		}
+
+		if obj.Status.ApprovedUpgradeDigest != "" {
+			t.Error("ShouldHandleApproveRequest updated ApprovedUpgradeDigest")
+		}
+	})
+
+	t.Run("should not handle stale approve request", func(t *testing.T) {
+		obj := &HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					meta.ReconcileRequestAnnotation: "b",
+					ApproveRequestAnnotation:        "b",
+				},
+			},
+			Status: HelmReleaseStatus{
+				LastHandledApproveAt: "b",
+				ReconcileRequestStatus: meta.ReconcileRequestStatus{
+					LastHandledReconcileAt: "a",
+				},
+				UpgradePlan: &UpgradePlan{
+					Digest: "sha256:b",
+				},
+			},
+		}
+
+		if ShouldHandleApproveRequest(obj, "sha256:b") {
+			t.Error("ShouldHandleApproveRequest() = true")
+		}
+
+		if ShouldHandleApproveRequest(obj, "") {
+			t.Error("ShouldHandleApproveRequest() = true for empty digest")
+		}
+	})
+}
+
 func Test_handleRequest(t *testing.T) {
 	const requestAnnotation = "requestAnnotation"
 
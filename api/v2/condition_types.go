@@ -29,6 +29,19 @@ const (
 	// (uninstall/rollback) due to a failure of the last release attempt against the
 	// latest desired state.
 	RemediatedCondition string = "Remediated"
+
+	// AwaitingApprovalCondition represents the fact that a pending Helm
+	// upgrade requires approval before it is performed.
+	AwaitingApprovalCondition string = "AwaitingApproval"
+
+	// PendingUpgradeCondition represents the fact that a pending Helm
+	// upgrade is held by the upgrade policy of the HelmRelease.
+	PendingUpgradeCondition string = "PendingUpgrade"
+
+	// DeprecatedAPIsCondition represents the fact that the rendered manifest
+	// of the HelmRelease uses APIs which are deprecated in the Kubernetes
+	// version of the target cluster.
+	DeprecatedAPIsCondition string = "DeprecatedAPIs"
 )
 
 const (
@@ -79,4 +92,62 @@ const (
 	// DependencyNotReadyReason represents the fact that
 	// one of the dependencies is not ready.
 	DependencyNotReadyReason string = "DependencyNotReady"
+
+	// DependencyCycleReason represents the fact that the dependencies of the
+	// HelmRelease contain a cycle.
+	DependencyCycleReason string = "DependencyCycle"
+
+	// DependantsNotDeletedReason represents the fact that the uninstall of
+	// the HelmRelease is waiting for the HelmReleases which depend on it to
+	// be deleted.
+	DependantsNotDeletedReason string = "DependantsNotDeleted"
+
+	// InvalidCELExpressionReason represents the fact that a CEL expression
+	// in the HelmRelease spec is invalid.
+	InvalidCELExpressionReason string = "InvalidCELExpression"
+
+	// OutputsFailedReason represents the fact that the outputs of the Helm
+	// release could not be written for the HelmRelease.
+	OutputsFailedReason string = "OutputsFailed"
+
+	// UpgradePlannedReason represents the fact that a Helm upgrade has been
+	// planned for the HelmRelease, but not performed.
+	UpgradePlannedReason string = "UpgradePlanned"
+
+	// UpgradePlanFailedReason represents the fact that a Helm upgrade could
+	// not be planned for the HelmRelease.
+	UpgradePlanFailedReason string = "UpgradePlanFailed"
+
+	// ApprovalRequiredReason represents the fact that a pending Helm upgrade
+	// for the HelmRelease has not been approved.
+	ApprovalRequiredReason string = "ApprovalRequired"
+
+	// UpgradeHeldReason represents the fact that a pending Helm upgrade for
+	// the HelmRelease bumps the chart version beyond what the upgrade policy
+	// allows to be upgraded to automatically.
+	UpgradeHeldReason string = "UpgradeHeld"
+
+	// DowngradeRefusedReason represents the fact that a pending Helm upgrade
+	// for the HelmRelease downgrades the chart version, which is refused by
+	// the upgrade policy.
+	DowngradeRefusedReason string = "DowngradeRefused"
+
+	// WaitingForWindowReason represents the fact that a Helm action for the
+	// HelmRelease is waiting for a maintenance window in which it is allowed
+	// to be performed.
+	WaitingForWindowReason string = "WaitingForWindow"
+
+	// InvalidScheduleReason represents the fact that the maintenance windows
+	// of the HelmRelease are invalid.
+	InvalidScheduleReason string = "InvalidSchedule"
+
+	// PreflightFailedReason represents the fact that the server-side dry-run
+	// of the rendered chart failed for the HelmRelease, and the Helm install
+	// or upgrade was not performed.
+	PreflightFailedReason string = "PreflightFailed"
+
+	// APIDeprecatedReason represents the fact that the rendered manifest of
+	// the HelmRelease uses APIs which are deprecated in the Kubernetes
+	// version of the target cluster.
+	APIDeprecatedReason string = "APIDeprecated"
 )
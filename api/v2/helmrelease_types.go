@@ -100,11 +100,11 @@ type HelmReleaseSpec struct {
 	// +optional
 	StorageNamespace string `json:"storageNamespace,omitempty"`
 
-	// DependsOn may contain a meta.NamespacedObjectReference slice with
-	// references to HelmRelease resources that must be ready before this HelmRelease
-	// can be reconciled.
+	// DependsOn may contain a DependencyReference slice with references to
+	// HelmRelease resources, or objects of any other kind, that must be ready
+	// before this HelmRelease can be reconciled.
 	// +optional
-	DependsOn []meta.NamespacedObjectReference `json:"dependsOn,omitempty"`
+	DependsOn []DependencyReference `json:"dependsOn,omitempty"`
 
 	// Timeout is the time to wait for any individual Kubernetes operation (like Jobs
 	// for hooks) during the performance of a Helm action. Defaults to '5m0s'.
@@ -158,6 +158,24 @@ type HelmReleaseSpec struct {
 	// +optional
 	Test *Test `json:"test,omitempty"`
 
+	// Preflight holds the configuration for the checks performed before Helm
+	// install and upgrade actions for this HelmRelease.
+	// +optional
+	Preflight *Preflight `json:"preflight,omitempty"`
+
+	// HealthChecks holds the configuration for the health assessment of the
+	// objects of the release after Helm install and upgrade actions for this
+	// HelmRelease.
+	// +optional
+	HealthChecks *HealthChecks `json:"healthChecks,omitempty"`
+
+	// HealthCheckExprs is a list of CEL expressions which determine the
+	// health of the objects of the release of a kind, for kinds which do not
+	// follow the kstatus conventions. Setting any expressions enables the
+	// health assessment of HealthChecks.
+	// +optional
+	HealthCheckExprs []HealthCheckExpr `json:"healthCheckExprs,omitempty"`
+
 	// Rollback holds the configuration for Helm rollback actions for this HelmRelease.
 	// +optional
 	Rollback *Rollback `json:"rollback,omitempty"`
@@ -166,6 +184,17 @@ type HelmReleaseSpec struct {
 	// +optional
 	Uninstall *Uninstall `json:"uninstall,omitempty"`
 
+	// Schedule holds the maintenance windows in which the controller is
+	// allowed to make changes to the Helm release for this HelmRelease.
+	// +optional
+	Schedule *Schedule `json:"schedule,omitempty"`
+
+	// Decryption holds the configuration for decrypting SOPS encrypted
+	// values documents, referenced in ValuesFrom or declared as string values
+	// in Values, before they are merged.
+	// +optional
+	Decryption *Decryption `json:"decryption,omitempty"`
+
 	// ValuesFrom holds references to resources containing Helm values for this HelmRelease,
 	// and information about how they should be merged.
 	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
@@ -174,14 +203,22 @@ type HelmReleaseSpec struct {
 	// +optional
 	Values *apiextensionsv1.JSON `json:"values,omitempty"`
 
+	// PostBuild describes which actions to perform on the composed values of
+	// the HelmRelease, before they are passed to the Helm install or upgrade
+	// action.
+	// +optional
+	PostBuild *PostBuild `json:"postBuild,omitempty"`
+
 	// PostRenderers holds an array of Helm PostRenderers, which will be applied in order
 	// of their definition.
 	// +optional
 	PostRenderers []PostRenderer `json:"postRenderers,omitempty"`
-}
 
-// +kubebuilder:object:generate=false
-type ValuesReference = meta.ValuesReference
+	// Outputs holds the configuration for exporting data from the Helm release
+	// to a ConfigMap or Secret after a successful install or upgrade.
+	// +optional
+	Outputs *Outputs `json:"outputs,omitempty"`
+}
 
 // Kustomize Helm PostRenderer specification.
 type Kustomize struct {
@@ -204,6 +241,172 @@ type PostRenderer struct {
 	Kustomize *Kustomize `json:"kustomize,omitempty"`
 }
 
+// Decryption defines how values documents are decrypted.
+type Decryption struct {
+	// Provider is the name of the decryption engine.
+	// +kubebuilder:validation:Enum=sops
+	// +required
+	Provider string `json:"provider"`
+
+	// SecretRef is a reference to a Secret in the namespace of the
+	// HelmRelease containing the age identities ('*.agekey' keys) or
+	// OpenPGP private keys ('*.asc' keys) used for decryption.
+	// +optional
+	SecretRef *meta.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+const (
+	// DecryptionProviderSOPS is the SOPS decryption provider.
+	DecryptionProviderSOPS = "sops"
+)
+
+// PostBuild describes which actions to perform on the composed values of
+// the HelmRelease.
+type PostBuild struct {
+	// Substitute holds a map of key/value pairs.
+	// The variables defined in your string values using the ${var}
+	// notation will be substituted with the matching value from this map.
+	// The variable name must match the regular expression
+	// ^[_[:alpha:]][_[:alpha:][:digit:]]*$.
+	// +optional
+	Substitute map[string]string `json:"substitute,omitempty"`
+
+	// SubstituteFrom holds references to ConfigMaps and Secrets containing
+	// the variables and their values to be substituted in the string values.
+	// The references are read in the order given, with the variables from
+	// later references overwriting earlier ones, and the variables in
+	// Substitute overwriting those.
+	// +optional
+	SubstituteFrom []SubstituteReference `json:"substituteFrom,omitempty"`
+}
+
+// SubstituteReference contains a reference to a resource containing
+// the variables name and value.
+type SubstituteReference struct {
+	// Kind of the values referent, valid values are ('Secret', 'ConfigMap').
+	// +kubebuilder:validation:Enum=Secret;ConfigMap
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the values referent. Should reside in the same namespace as the
+	// referring resource.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +required
+	Name string `json:"name"`
+
+	// Optional indicates whether the referenced resource must exist, or whether to
+	// tolerate its absence. If true and the referenced resource is absent, proceed
+	// as if the resource was present but empty, without any variables defined.
+	// +kubebuilder:default:=false
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Outputs defines the ConfigMap or Secret the controller writes data from
+// the Helm release to. The object is created in the namespace of the
+// HelmRelease, and is owned by it.
+type Outputs struct {
+	// Kind of the object to write the outputs to.
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	// +kubebuilder:default:=ConfigMap
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the object to write the outputs to.
+	// Defaults to '<HelmRelease name>-outputs'.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Values is a list of values to export from the Helm release.
+	// +kubebuilder:validation:MinItems=1
+	// +required
+	Values []OutputValue `json:"values"`
+}
+
+// OutputValue defines a single value to export from the Helm release, and
+// the key under which it is written.
+// +kubebuilder:validation:XValidation:rule="self.source != 'Resource' || has(self.resource)",message="resource must be set when source is Resource"
+type OutputValue struct {
+	// Key the value is written to in the data of the ConfigMap or Secret.
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[-._a-zA-Z0-9]+$`
+	// +required
+	Key string `json:"key"`
+
+	// Source of the data the JSONPath expression is evaluated against.
+	// 'Values' selects the computed values of the release, including the
+	// defaults of the chart. 'Chart' selects the metadata of the chart, e.g.
+	// '{.appVersion}'. 'Resource' selects an object of the release as it
+	// currently exists in the cluster.
+	// +kubebuilder:validation:Enum=Values;Chart;Resource
+	// +required
+	Source string `json:"source"`
+
+	// Resource references the object of the release the JSONPath expression
+	// is evaluated against when Source is 'Resource'. The object must be part
+	// of the release manifest.
+	// +optional
+	Resource *OutputResourceReference `json:"resource,omitempty"`
+
+	// JSONPath is the expression used to select the value, e.g.
+	// '{.spec.clusterIP}'. When the expression selects multiple values,
+	// they are separated by a space.
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	JSONPath string `json:"jsonPath"`
+}
+
+// OutputResourceReference references an object of the Helm release.
+type OutputResourceReference struct {
+	// APIVersion of the object.
+	// +required
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the object.
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the object.
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the object, defaults to the target namespace of the
+	// release. Must be omitted for cluster-scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+const (
+	// OutputsKindConfigMap writes the outputs to a ConfigMap.
+	OutputsKindConfigMap = "ConfigMap"
+	// OutputsKindSecret writes the outputs to a Secret.
+	OutputsKindSecret = "Secret"
+)
+
+const (
+	// OutputSourceValues evaluates the JSONPath expression against the
+	// computed values of the release.
+	OutputSourceValues = "Values"
+	// OutputSourceChart evaluates the JSONPath expression against the
+	// metadata of the chart of the release.
+	OutputSourceChart = "Chart"
+	// OutputSourceResource evaluates the JSONPath expression against an
+	// object of the release.
+	OutputSourceResource = "Resource"
+)
+
+// GetKind returns the configured kind of the outputs object, or the
+// default.
+func (in Outputs) GetKind() string {
+	if in.Kind == "" {
+		return OutputsKindConfigMap
+	}
+	return in.Kind
+}
+
 // DriftDetectionMode represents the modes in which a controller can detect and
 // handle differences between the manifest in the Helm storage and the resources
 // currently existing in the cluster.
@@ -232,6 +435,23 @@ var (
 	DriftDetectionDisabled DriftDetectionMode = "disabled"
 )
 
+// DriftCorrection represents the strategies with which a controller can
+// correct differences between the manifest in the Helm storage and the
+// resources currently existing in the cluster.
+type DriftCorrection string
+
+var (
+	// DriftCorrectionPatch instructs the controller to correct drift by
+	// patching the resources with the JSON patches of the detected changes.
+	// This is the default behavior.
+	DriftCorrectionPatch DriftCorrection = "patch"
+
+	// DriftCorrectionServerSideApply instructs the controller to correct
+	// drift by applying the resources from the manifest in the Helm storage
+	// using server-side apply, taking ownership of the changed fields.
+	DriftCorrectionServerSideApply DriftCorrection = "serverSideApply"
+)
+
 var (
 	// DriftDetectionMetadataKey is the label or annotation key used to disable
 	// the diffing of an object.
@@ -257,6 +477,82 @@ type IgnoreRule struct {
 	Target *kustomize.Selector `json:"target,omitempty"`
 }
 
+// Schedule holds the maintenance windows in which the controller is allowed
+// to make changes to a Helm release.
+//
+// Helm upgrades, rollbacks and the correction of cluster drift are only
+// performed inside an allow window, if any is defined, and never inside a
+// deny window. Helm installs and uninstalls are only subject to the windows
+// when configured to be.
+type Schedule struct {
+	// Windows is a list of recurring maintenance windows.
+	// +optional
+	Windows []ScheduleWindow `json:"windows,omitempty"`
+
+	// Install instructs the controller to only perform Helm installs inside
+	// the maintenance windows. Defaults to 'false'. Helm installs are always
+	// subject to the cluster-wide freeze windows of the controller.
+	// +optional
+	Install bool `json:"install,omitempty"`
+
+	// Uninstall instructs the controller to only perform Helm uninstalls
+	// inside the maintenance windows, including the uninstall of the Helm
+	// release when the HelmRelease is deleted. Defaults to 'false'.
+	// +optional
+	Uninstall bool `json:"uninstall,omitempty"`
+}
+
+// ScheduleWindow defines a recurring window of time.
+type ScheduleWindow struct {
+	// Type of the window. Valid values are `allow` and `deny`.
+	// Defaults to `allow`.
+	//
+	// allow: changes are allowed inside the window.
+	//
+	// deny: changes are not allowed inside the window, even when inside an
+	// allow window.
+	// +kubebuilder:validation:Enum=allow;deny
+	// +optional
+	Type ScheduleWindowType `json:"type,omitempty"`
+
+	// Start is a cron expression with five fields (minute, hour, day of
+	// month, month and day of week) which defines when the window starts.
+	// For example, `0 9 * * 1-5` starts the window at 09:00 from Monday to
+	// Friday.
+	// +required
+	Start string `json:"start"`
+
+	// Duration is the duration of the window after each start.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +required
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the IANA time zone name in which the Start expression is
+	// evaluated, for example `Europe/Amsterdam`. Defaults to `UTC`.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ScheduleWindowType represents the type of a ScheduleWindow.
+type ScheduleWindowType string
+
+const (
+	// ScheduleWindowAllow allows changes inside the window.
+	ScheduleWindowAllow ScheduleWindowType = "allow"
+
+	// ScheduleWindowDeny denies changes inside the window.
+	ScheduleWindowDeny ScheduleWindowType = "deny"
+)
+
+// GetType returns the configured Type of the window, or the default.
+func (in ScheduleWindow) GetType() ScheduleWindowType {
+	if in.Type == "" {
+		return ScheduleWindowAllow
+	}
+	return in.Type
+}
+
 // DriftDetection defines the strategy for performing differential analysis and
 // provides a way to define rules for ignoring specific changes during this
 // process.
@@ -272,6 +568,27 @@ type DriftDetection struct {
 	// during diffing.
 	// +optional
 	Ignore []IgnoreRule `json:"ignore,omitempty"`
+
+	// Prune enables the deletion of orphaned objects when Mode is set to
+	// 'enabled'. Orphaned objects carry the origin labels of the HelmRelease,
+	// but are no longer part of the manifest of the release. They are
+	// reported as drift regardless of this setting.
+	// +optional
+	Prune bool `json:"prune,omitempty"`
+
+	// Correction defines how drift is corrected when Mode is set to 'enabled'.
+	// If not explicitly set, it defaults to 'patch'.
+	// +kubebuilder:validation:Enum=patch;serverSideApply
+	// +optional
+	Correction DriftCorrection `json:"correction,omitempty"`
+
+	// ForceConflicts forces the ownership of fields managed by other field
+	// managers when Correction is set to 'serverSideApply'. When not set,
+	// resources with conflicting changes are not corrected. As drift is
+	// typically introduced by another field manager (e.g. 'kubectl edit'),
+	// this is required for most drift to be corrected.
+	// +optional
+	ForceConflicts bool `json:"forceConflicts,omitempty"`
 }
 
 // GetMode returns the DiffMode set on the Diff, or DiffModeDisabled if not
@@ -283,6 +600,15 @@ func (d DriftDetection) GetMode() DriftDetectionMode {
 	return d.Mode
 }
 
+// GetCorrection returns the DriftCorrection set on the DriftDetection, or
+// DriftCorrectionPatch if not set.
+func (d DriftDetection) GetCorrection() DriftCorrection {
+	if d.Correction == "" {
+		return DriftCorrectionPatch
+	}
+	return d.Correction
+}
+
 // MustDetectChanges returns true if the DiffMode is set to DiffModeEnabled or
 // DiffModeWarn.
 func (d DriftDetection) MustDetectChanges() bool {
@@ -671,8 +997,109 @@ type Upgrade struct {
 	// +kubebuilder:validation:Enum=Skip;Create;CreateReplace
 	// +optional
 	CRDs CRDsPolicy `json:"crds,omitempty"`
+
+	// Mode determines how the controller handles a pending Helm upgrade.
+	// Valid values are `apply` and `plan`. Defaults to `apply`.
+	//
+	// apply: the Helm upgrade is performed.
+	//
+	// plan: the Helm upgrade is rendered and compared to the state of the
+	// cluster using a server-side dry-run, without being performed. A
+	// summary of the changes is written to the status, and the full diff to
+	// a ConfigMap owned by the HelmRelease.
+	//
+	// The mode can be overridden using the
+	// `helm.toolkit.fluxcd.io/upgradeMode` annotation.
+	// +kubebuilder:validation:Enum=apply;plan
+	// +optional
+	Mode UpgradeMode `json:"mode,omitempty"`
+
+	// Approval determines whether a pending Helm upgrade must be approved
+	// before it is performed. Valid values are `none` and `required`.
+	// Defaults to `none`.
+	//
+	// When approval is required, the pending upgrade is planned and the
+	// HelmRelease is marked with AwaitingApproval=True until the
+	// `reconcile.fluxcd.io/approve` annotation is set to the digest of the
+	// pending upgrade.
+	// +kubebuilder:validation:Enum=none;required
+	// +optional
+	Approval UpgradeApproval `json:"approval,omitempty"`
+
+	// Policy holds the policy for automatic Helm upgrades based on the
+	// semantic version of the chart. When omitted, an upgrade to any chart
+	// version is performed automatically.
+	// +optional
+	Policy *UpgradePolicy `json:"policy,omitempty"`
+}
+
+// UpgradePolicy holds the policy for automatic Helm upgrades, based on the
+// semantic version bump from the chart version of the latest release to the
+// chart version of the pending upgrade.
+type UpgradePolicy struct {
+	// Automatic is the largest semantic version bump of the chart which is
+	// upgraded to automatically. Valid values are `patch`, `minor` and
+	// `major`. Defaults to `patch`.
+	//
+	// An upgrade with a larger version bump is held, and the HelmRelease is
+	// marked with PendingUpgrade=True until the upgrade is released using
+	// the `reconcile.fluxcd.io/upgradeAt` annotation. An upgrade to a lower
+	// chart version is refused, unless a Helm release is forced using the
+	// `reconcile.fluxcd.io/forceAt` annotation.
+	// +kubebuilder:validation:Enum=patch;minor;major
+	// +optional
+	Automatic VersionBump `json:"automatic,omitempty"`
+}
+
+// GetAutomatic returns the configured Automatic version bump, or the
+// default.
+func (in UpgradePolicy) GetAutomatic() VersionBump {
+	if in.Automatic == "" {
+		return VersionBumpPatch
+	}
+	return in.Automatic
 }
 
+// VersionBump represents a semantic version bump.
+type VersionBump string
+
+const (
+	// VersionBumpPatch is a bump of the patch version.
+	VersionBumpPatch VersionBump = "patch"
+
+	// VersionBumpMinor is a bump of the minor version.
+	VersionBumpMinor VersionBump = "minor"
+
+	// VersionBumpMajor is a bump of the major version.
+	VersionBumpMajor VersionBump = "major"
+)
+
+// UpgradeApproval represents the approval policy for Helm upgrades.
+type UpgradeApproval string
+
+const (
+	// UpgradeApprovalNone performs Helm upgrades without approval.
+	UpgradeApprovalNone UpgradeApproval = "none"
+
+	// UpgradeApprovalRequired performs Helm upgrades only after they have
+	// been approved.
+	UpgradeApprovalRequired UpgradeApproval = "required"
+)
+
+// UpgradeMode represents the modes in which the controller can handle a
+// pending Helm upgrade.
+type UpgradeMode string
+
+const (
+	// UpgradeModeApply instructs the controller to perform the Helm
+	// upgrade.
+	UpgradeModeApply UpgradeMode = "apply"
+
+	// UpgradeModePlan instructs the controller to compute the changes the
+	// Helm upgrade would make to the cluster, without performing it.
+	UpgradeModePlan UpgradeMode = "plan"
+)
+
 // GetTimeout returns the configured timeout for the Helm upgrade action, or the
 // given default.
 func (in Upgrade) GetTimeout(defaultTimeout metav1.Duration) metav1.Duration {
@@ -682,6 +1109,12 @@ func (in Upgrade) GetTimeout(defaultTimeout metav1.Duration) metav1.Duration {
 	return *in.Timeout
 }
 
+// RequiresApproval returns true if Helm upgrades must be approved before
+// they are performed.
+func (in Upgrade) RequiresApproval() bool {
+	return in.Approval == UpgradeApprovalRequired
+}
+
 // GetRemediation returns the configured Remediation for the Helm upgrade
 // action.
 func (in Upgrade) GetRemediation() Remediation {
@@ -777,6 +1210,99 @@ const (
 	UninstallRemediationStrategy RemediationStrategy = "uninstall"
 )
 
+// Preflight holds the configuration for the checks performed before Helm
+// install and upgrade actions for this HelmRelease.
+type Preflight struct {
+	// Enable enables a server-side dry-run apply of the rendered chart before
+	// each Helm install or upgrade action, using the service account the
+	// release is made with. When the dry-run fails, the action is not
+	// performed and no release revision is recorded in the Helm storage.
+	// +optional
+	Enable bool `json:"enable,omitempty"`
+
+	// CheckPermissions enables the verification of the permissions required
+	// by each Helm install or upgrade action using SubjectAccessReviews,
+	// when the release is made with an impersonated service account. When
+	// permissions are missing, the action is not performed.
+	// +optional
+	CheckPermissions bool `json:"checkPermissions,omitempty"`
+
+	// CheckAPIs enables the verification of the APIs used by the rendered
+	// chart against the APIs served by the cluster before each Helm install
+	// or upgrade action. When APIs are missing, the action is not performed.
+	// APIs which are deprecated in the Kubernetes version of the cluster are
+	// reported in the DeprecatedAPIs condition.
+	// +optional
+	CheckAPIs bool `json:"checkAPIs,omitempty"`
+}
+
+// HasChecks returns true if any of the preflight checks is enabled.
+func (in Preflight) HasChecks() bool {
+	return in.Enable || in.CheckPermissions || in.CheckAPIs
+}
+
+// HealthChecks holds the configuration for the health assessment of the
+// objects of the release after Helm install and upgrade actions for this
+// HelmRelease.
+type HealthChecks struct {
+	// Enable replaces the wait of Helm install and upgrade actions with a
+	// kstatus-based health assessment of all the objects in the release
+	// manifest, including custom resources, taking HealthCheckExprs into
+	// account. The assessment uses the timeout of the action, and is not
+	// performed when the wait of the action is disabled. When an object fails
+	// to become healthy, the release is marked as failed.
+	// +optional
+	Enable bool `json:"enable,omitempty"`
+
+	// Interval at which the status of the objects is polled during the health
+	// assessment. Defaults to '5s'.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// GetInterval returns the configured polling interval of the health
+// assessment, or the default of 5s.
+func (in HealthChecks) GetInterval() metav1.Duration {
+	if in.Interval == nil {
+		return metav1.Duration{Duration: 5 * time.Second}
+	}
+	return *in.Interval
+}
+
+// HealthCheckExpr holds the CEL expressions which determine the health of
+// the objects of a kind in the release. The expressions have access to the
+// top-level fields of the object, i.e. `apiVersion`, `kind`, `metadata`,
+// `spec` and `status`, and must evaluate to a boolean. The expressions are
+// evaluated in the order InProgress, Failed and Current, and the object is
+// considered in progress when none of them evaluates to true.
+type HealthCheckExpr struct {
+	// APIVersion of the objects, e.g. 'kafka.strimzi.io/v1beta2'. Only the
+	// group of the APIVersion is used to match the objects.
+	// +required
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the objects, e.g. 'Kafka'.
+	// +required
+	Kind string `json:"kind"`
+
+	// Current is the CEL expression which evaluates to true when the object
+	// is healthy.
+	// +required
+	Current string `json:"current"`
+
+	// InProgress is the CEL expression which evaluates to true when the
+	// object is still being reconciled.
+	// +optional
+	InProgress string `json:"inProgress,omitempty"`
+
+	// Failed is the CEL expression which evaluates to true when the object
+	// failed to become healthy.
+	// +optional
+	Failed string `json:"failed,omitempty"`
+}
+
 // Test holds the configuration for Helm test actions for this HelmRelease.
 type Test struct {
 	// Enable enables Helm test actions for this HelmRelease after an Helm install
@@ -910,6 +1436,12 @@ type Uninstall struct {
 	// +kubebuilder:validation:Enum=background;foreground;orphan
 	// +optional
 	DeletionPropagation *string `json:"deletionPropagation,omitempty"`
+
+	// DisableWaitForDependants disables waiting for the HelmReleases which
+	// depend on this HelmRelease to be deleted, before the Helm uninstall
+	// is performed on deletion of this HelmRelease.
+	// +optional
+	DisableWaitForDependants bool `json:"disableWaitForDependants,omitempty"`
 }
 
 // GetTimeout returns the configured timeout for the Helm uninstall action, or
@@ -951,6 +1483,11 @@ type HelmReleaseStatus struct {
 	// +optional
 	ObservedPostRenderersDigest string `json:"observedPostRenderersDigest,omitempty"`
 
+	// ObservedValuesMergeDigest is the digest for the merge strategies of
+	// the values references of the last successful reconciliation attempt.
+	// +optional
+	ObservedValuesMergeDigest string `json:"observedValuesMergeDigest,omitempty"`
+
 	// LastAttemptedGeneration is the last generation the controller attempted
 	// to reconcile.
 	// +optional
@@ -965,6 +1502,23 @@ type HelmReleaseStatus struct {
 	// +optional
 	HelmChart string `json:"helmChart,omitempty"`
 
+	// Outputs references the ConfigMap or Secret the outputs of the Helm
+	// release have been written to.
+	// +optional
+	Outputs *meta.NamespacedObjectKindReference `json:"outputs,omitempty"`
+
+	// UpgradePlan holds the last Helm upgrade plan computed while the
+	// upgrade mode is `plan`. It is removed once the release is in-sync with
+	// the desired state.
+	// +optional
+	UpgradePlan *UpgradePlan `json:"upgradePlan,omitempty"`
+
+	// DependencyOrder is the resolved order of the transitive dependencies
+	// of the HelmRelease, as observed during the last reconciliation attempt.
+	// Every entry is preceded by its own dependencies.
+	// +optional
+	DependencyOrder []meta.NamespacedObjectReference `json:"dependencyOrder,omitempty"`
+
 	// StorageNamespace is the namespace of the Helm release storage for the
 	// current release.
 	// +kubebuilder:validation:MaxLength=63
@@ -978,6 +1532,12 @@ type HelmReleaseStatus struct {
 	// +optional
 	History Snapshots `json:"history,omitempty"`
 
+	// Inventory contains the list of Kubernetes resource object references
+	// of the objects in the manifest of the latest Helm release, as recorded
+	// after the last successful Helm action.
+	// +optional
+	Inventory *ResourceInventory `json:"inventory,omitempty"`
+
 	// LastAttemptedReleaseAction is the last release action performed for this
 	// HelmRelease. It is used to determine the active remediation strategy.
 	// +kubebuilder:validation:Enum=install;upgrade
@@ -1022,10 +1582,17 @@ type HelmReleaseStatus struct {
 	LastReleaseRevision int `json:"lastReleaseRevision,omitempty"`
 
 	// LastAttemptedConfigDigest is the digest for the config (better known as
-	// "values") of the last reconciliation attempt.
+	// "values") of the last reconciliation attempt. When HelmReleaseDefaults
+	// are applied, the digest includes the defaults.
 	// +optional
 	LastAttemptedConfigDigest string `json:"lastAttemptedConfigDigest,omitempty"`
 
+	// AppliedDefaults holds the names of the HelmReleaseDefaults objects
+	// which were merged into the spec during the last reconciliation attempt,
+	// in order of increasing precedence.
+	// +optional
+	AppliedDefaults []string `json:"appliedDefaults,omitempty"`
+
 	// LastHandledForceAt holds the value of the most recent force request
 	// value, so a change of the annotation value can be detected.
 	// +optional
@@ -1036,9 +1603,102 @@ type HelmReleaseStatus struct {
 	// +optional
 	LastHandledResetAt string `json:"lastHandledResetAt,omitempty"`
 
+	// LastHandledApproveAt holds the value of the most recent approve
+	// request value, so a change of the annotation value can be detected.
+	// +optional
+	LastHandledApproveAt string `json:"lastHandledApproveAt,omitempty"`
+
+	// ApprovedUpgradeDigest is the digest of the last approved Helm upgrade.
+	// The approval remains valid for retries of this upgrade until the
+	// release is in-sync.
+	// +optional
+	ApprovedUpgradeDigest string `json:"approvedUpgradeDigest,omitempty"`
+
+	// LastHandledUpgradeAt holds the value of the most recent upgrade
+	// request value, so a change of the annotation value can be detected.
+	// +optional
+	LastHandledUpgradeAt string `json:"lastHandledUpgradeAt,omitempty"`
+
+	// HeldChartVersion is the chart version of the Helm upgrade which is
+	// held by the upgrade policy. An upgrade request only releases the
+	// upgrade to this chart version.
+	// +optional
+	HeldChartVersion string `json:"heldChartVersion,omitempty"`
+
 	meta.ReconcileRequestStatus `json:",inline"`
 }
 
+// UpgradePlan holds a summary of the changes a pending Helm upgrade would
+// make to the cluster.
+type UpgradePlan struct {
+	// ChartName is the name of the chart the upgrade was planned for.
+	// +required
+	ChartName string `json:"chartName"`
+
+	// ChartVersion is the version of the chart the upgrade was planned for.
+	// +required
+	ChartVersion string `json:"chartVersion"`
+
+	// ConfigDigest is the digest of the values the upgrade was planned for.
+	// +required
+	ConfigDigest string `json:"configDigest"`
+
+	// Digest is the digest of the planned upgrade, calculated over the
+	// release name and namespace, chart, values, post-renderers and the
+	// install and upgrade settings which affect the release. An approval
+	// only approves the upgrade with this digest.
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// PlannedAt is the time the upgrade was last planned.
+	// +required
+	PlannedAt metav1.Time `json:"plannedAt"`
+
+	// Summary is a summary of the number of objects per action, in the
+	// format `create: x, update: y, delete: z`.
+	// +optional
+	Summary string `json:"summary,omitempty"`
+
+	// Changes holds the objects the upgrade would change, without their
+	// contents. The list is truncated to the first 100 changes, the full
+	// diff can be found in the DiffConfigMap.
+	// +optional
+	Changes []UpgradePlanChange `json:"changes,omitempty"`
+
+	// DiffConfigMap references the ConfigMap in the namespace of the
+	// HelmRelease which holds the full diff of the upgrade.
+	// +optional
+	DiffConfigMap *meta.LocalObjectReference `json:"diffConfigMap,omitempty"`
+}
+
+// Matches returns true if the plan was computed for the given chart name,
+// version and values digest.
+func (in *UpgradePlan) Matches(chartName, chartVersion, configDigest string) bool {
+	return in != nil && in.ChartName == chartName && in.ChartVersion == chartVersion && in.ConfigDigest == configDigest
+}
+
+// UpgradePlanChange describes a change a pending Helm upgrade would make to
+// an object in the cluster.
+type UpgradePlanChange struct {
+	// Action is the action the upgrade would perform on the object.
+	// +kubebuilder:validation:Enum=Create;Update;Delete
+	// +required
+	Action string `json:"action"`
+
+	// Object is the object in the format `Kind/namespace/name`.
+	// +required
+	Object string `json:"object"`
+}
+
+const (
+	// UpgradePlanActionCreate indicates the object would be created.
+	UpgradePlanActionCreate = "Create"
+	// UpgradePlanActionUpdate indicates the object would be updated.
+	UpgradePlanActionUpdate = "Update"
+	// UpgradePlanActionDelete indicates the object would be deleted.
+	UpgradePlanActionDelete = "Delete"
+)
+
 // ClearHistory clears the History.
 func (in *HelmReleaseStatus) ClearHistory() {
 	in.History = nil
@@ -1070,6 +1730,22 @@ const (
 	// SourceIndexKey is the key used for indexing HelmReleases based on
 	// their sources.
 	SourceIndexKey string = ".metadata.source"
+
+	// ConfigMapIndexKey is the key used for indexing HelmReleases based on
+	// the ConfigMaps they reference.
+	ConfigMapIndexKey string = ".metadata.configMaps"
+
+	// SecretIndexKey is the key used for indexing HelmReleases based on
+	// the Secrets they reference.
+	SecretIndexKey string = ".metadata.secrets"
+
+	// DependsOnIndexKey is the key used for indexing HelmReleases based on
+	// the HelmReleases they depend on.
+	DependsOnIndexKey string = ".metadata.dependsOn"
+
+	// ValuesSourceIndexKey is the key used for indexing HelmReleases based
+	// on the source artifacts their values are referenced from.
+	ValuesSourceIndexKey string = ".metadata.valuesSources"
 )
 
 // +genclient
@@ -1119,6 +1795,20 @@ func (in *HelmRelease) GetUpgrade() Upgrade {
 	return *in.Spec.Upgrade
 }
 
+// GetUpgradeMode returns the mode in which a pending Helm upgrade is
+// handled. The UpgradeModeAnnotation takes precedence over the mode
+// configured in the spec.
+func (in *HelmRelease) GetUpgradeMode() UpgradeMode {
+	switch mode := UpgradeMode(in.GetAnnotations()[UpgradeModeAnnotation]); mode {
+	case UpgradeModeApply, UpgradeModePlan:
+		return mode
+	}
+	if mode := in.GetUpgrade().Mode; mode != "" {
+		return mode
+	}
+	return UpgradeModeApply
+}
+
 // GetTest returns the configuration for Helm test actions for this HelmRelease.
 func (in *HelmRelease) GetTest() Test {
 	if in.Spec.Test == nil {
@@ -1127,6 +1817,30 @@ func (in *HelmRelease) GetTest() Test {
 	return *in.Spec.Test
 }
 
+// GetPreflight returns the configuration for the checks performed before
+// Helm install and upgrade actions for this HelmRelease.
+func (in *HelmRelease) GetPreflight() Preflight {
+	if in.Spec.Preflight == nil {
+		return Preflight{}
+	}
+	return *in.Spec.Preflight
+}
+
+// GetHealthChecks returns the configuration for the health assessment of
+// the objects of the release for this HelmRelease.
+func (in *HelmRelease) GetHealthChecks() HealthChecks {
+	if in.Spec.HealthChecks == nil {
+		return HealthChecks{}
+	}
+	return *in.Spec.HealthChecks
+}
+
+// HasHealthChecks returns true if the health assessment of the objects of
+// the release is enabled by HealthChecks or HealthCheckExprs.
+func (in *HelmRelease) HasHealthChecks() bool {
+	return in.GetHealthChecks().Enable || len(in.Spec.HealthCheckExprs) > 0
+}
+
 // GetRollback returns the configuration for Helm rollback actions for this
 // HelmRelease.
 func (in *HelmRelease) GetRollback() Rollback {
@@ -1204,6 +1918,21 @@ func (in HelmRelease) GetStorageNamespace() string {
 	return in.Namespace
 }
 
+// GetOutputsName returns the name of the ConfigMap or Secret the outputs
+// of the Helm release are written to.
+func (in HelmRelease) GetOutputsName() string {
+	if in.Spec.Outputs != nil && in.Spec.Outputs.Name != "" {
+		return in.Spec.Outputs.Name
+	}
+	return strings.Join([]string{in.GetName(), "outputs"}, "-")
+}
+
+// GetUpgradePlanName returns the name of the ConfigMap the diff of a
+// planned Helm upgrade is written to.
+func (in HelmRelease) GetUpgradePlanName() string {
+	return strings.Join([]string{in.GetName(), "upgrade-plan"}, "-")
+}
+
 // GetHelmChartName returns the name used by the controller for the HelmChart creation.
 func (in HelmRelease) GetHelmChartName() string {
 	return strings.Join([]string{in.Namespace, in.Name}, "-")
@@ -1234,9 +1963,20 @@ func (in HelmRelease) UsePersistentClient() bool {
 	return *in.Spec.PersistentClient
 }
 
-// GetDependsOn returns the list of dependencies across-namespaces.
+// GetDependsOn returns the list of HelmRelease dependencies across-namespaces.
+// Dependencies on objects of other kinds are omitted.
 func (in HelmRelease) GetDependsOn() []meta.NamespacedObjectReference {
-	return in.Spec.DependsOn
+	var deps []meta.NamespacedObjectReference
+	for _, d := range in.Spec.DependsOn {
+		if !d.IsHelmRelease() {
+			continue
+		}
+		deps = append(deps, meta.NamespacedObjectReference{
+			Name:      d.Name,
+			Namespace: d.Namespace,
+		})
+	}
+	return deps
 }
 
 // GetConditions returns the status conditions of the object.
@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// HelmReleaseDefaultsKind is the kind in string format.
+	HelmReleaseDefaultsKind = "HelmReleaseDefaults"
+)
+
+// HelmReleaseDefaultsSpec defines the defaults which are merged into the
+// spec of HelmReleases. Fields which are explicitly set on a HelmRelease
+// take precedence over the defaults.
+type HelmReleaseDefaultsSpec struct {
+	// Namespaces is a list of namespaces the defaults apply to. When empty,
+	// the defaults apply to HelmReleases in all namespaces. Defaults for
+	// specific namespaces take precedence over defaults for all namespaces.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Timeout is the default for HelmReleaseSpec.Timeout.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// MaxHistory is the default for HelmReleaseSpec.MaxHistory.
+	// +optional
+	MaxHistory *int `json:"maxHistory,omitempty"`
+
+	// DriftDetection is the default for HelmReleaseSpec.DriftDetection.
+	// +optional
+	DriftDetection *DriftDetection `json:"driftDetection,omitempty"`
+
+	// Install holds the defaults for HelmReleaseSpec.Install.
+	// +optional
+	Install *InstallDefaults `json:"install,omitempty"`
+
+	// Upgrade holds the defaults for HelmReleaseSpec.Upgrade.
+	// +optional
+	Upgrade *UpgradeDefaults `json:"upgrade,omitempty"`
+}
+
+// InstallDefaults holds the defaults for Helm install actions.
+type InstallDefaults struct {
+	// Remediation holds the defaults for Install.Remediation.
+	// +optional
+	Remediation *InstallRemediationDefaults `json:"remediation,omitempty"`
+}
+
+// InstallRemediationDefaults holds the defaults for Helm install
+// remediation. Unlike InstallRemediation, a field set to its zero value
+// takes precedence over defaults with a lower precedence.
+type InstallRemediationDefaults struct {
+	// Retries is the default for InstallRemediation.Retries.
+	// +optional
+	Retries *int `json:"retries,omitempty"`
+
+	// IgnoreTestFailures is the default for
+	// InstallRemediation.IgnoreTestFailures.
+	// +optional
+	IgnoreTestFailures *bool `json:"ignoreTestFailures,omitempty"`
+
+	// RemediateLastFailure is the default for
+	// InstallRemediation.RemediateLastFailure.
+	// +optional
+	RemediateLastFailure *bool `json:"remediateLastFailure,omitempty"`
+}
+
+// UpgradeDefaults holds the defaults for Helm upgrade actions.
+type UpgradeDefaults struct {
+	// Remediation holds the defaults for Upgrade.Remediation.
+	// +optional
+	Remediation *UpgradeRemediationDefaults `json:"remediation,omitempty"`
+}
+
+// UpgradeRemediationDefaults holds the defaults for Helm upgrade
+// remediation. Unlike UpgradeRemediation, a field set to its zero value
+// takes precedence over defaults with a lower precedence.
+type UpgradeRemediationDefaults struct {
+	// Retries is the default for UpgradeRemediation.Retries.
+	// +optional
+	Retries *int `json:"retries,omitempty"`
+
+	// IgnoreTestFailures is the default for
+	// UpgradeRemediation.IgnoreTestFailures.
+	// +optional
+	IgnoreTestFailures *bool `json:"ignoreTestFailures,omitempty"`
+
+	// RemediateLastFailure is the default for
+	// UpgradeRemediation.RemediateLastFailure.
+	// +optional
+	RemediateLastFailure *bool `json:"remediateLastFailure,omitempty"`
+
+	// Strategy is the default for UpgradeRemediation.Strategy.
+	// +kubebuilder:validation:Enum=rollback;uninstall
+	// +optional
+	Strategy *RemediationStrategy `json:"strategy,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=hrd
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+// +kubebuilder:printcolumn:name="Namespaces",type="string",JSONPath=".spec.namespaces",description=""
+
+// HelmReleaseDefaults is the Schema for the helmreleasedefaults API
+type HelmReleaseDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HelmReleaseDefaultsSpec `json:"spec,omitempty"`
+}
+
+// IsGlobal returns true if the defaults apply to HelmReleases in all
+// namespaces.
+func (in *HelmReleaseDefaults) IsGlobal() bool {
+	return len(in.Spec.Namespaces) == 0
+}
+
+// AppliesTo returns true if the defaults apply to HelmReleases in the given
+// namespace.
+func (in *HelmReleaseDefaults) AppliesTo(namespace string) bool {
+	return in.IsGlobal() || slices.Contains(in.Spec.Namespaces, namespace)
+}
+
+// +kubebuilder:object:root=true
+
+// HelmReleaseDefaultsList contains a list of HelmReleaseDefaults objects.
+type HelmReleaseDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HelmReleaseDefaults `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HelmReleaseDefaults{}, &HelmReleaseDefaultsList{})
+}
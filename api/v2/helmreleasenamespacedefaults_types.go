@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// HelmReleaseNamespaceDefaultsKind is the kind in string format.
+	HelmReleaseNamespaceDefaultsKind = "HelmReleaseNamespaceDefaults"
+)
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=hrnd
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description=""
+
+// HelmReleaseNamespaceDefaults is the Schema for the
+// helmreleasenamespacedefaults API. It holds the defaults for the
+// HelmReleases in its namespace, which take precedence over the
+// HelmReleaseDefaults of the cluster.
+type HelmReleaseNamespaceDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the defaults for the HelmReleases in the namespace of the
+	// object. The namespaces field can not be set.
+	// +kubebuilder:validation:XValidation:rule="!has(self.namespaces)",message="namespaces can not be set on HelmReleaseNamespaceDefaults"
+	Spec HelmReleaseDefaultsSpec `json:"spec,omitempty"`
+}
+
+// ToHelmReleaseDefaults returns the HelmReleaseDefaults equivalent of the
+// object, which applies to its namespace only and is named after the
+// '<namespace>/<name>' of the object.
+func (in *HelmReleaseNamespaceDefaults) ToHelmReleaseDefaults() HelmReleaseDefaults {
+	d := HelmReleaseDefaults{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: in.GetNamespace() + "/" + in.GetName(),
+		},
+		Spec: *in.Spec.DeepCopy(),
+	}
+	d.Spec.Namespaces = []string{in.GetNamespace()}
+	return d
+}
+
+// +kubebuilder:object:root=true
+
+// HelmReleaseNamespaceDefaultsList contains a list of
+// HelmReleaseNamespaceDefaults objects.
+type HelmReleaseNamespaceDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HelmReleaseNamespaceDefaults `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HelmReleaseNamespaceDefaults{}, &HelmReleaseNamespaceDefaultsList{})
+}
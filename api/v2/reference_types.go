@@ -16,6 +16,10 @@ limitations under the License.
 
 package v2
 
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
 // CrossNamespaceObjectReference contains enough information to let you locate
 // the typed referenced object at cluster level.
 type CrossNamespaceObjectReference struct {
@@ -68,3 +72,180 @@ type CrossNamespaceSourceReference struct {
 	// +optional
 	Namespace string `json:"namespace,omitempty"`
 }
+
+// ValuesReference contains a reference to a resource containing Helm values,
+// and optionally the key or path they can be found at.
+// +kubebuilder:validation:XValidation:rule="!has(self.valuesKey) || self.kind in ['Secret', 'ConfigMap']", message="valuesKey can only be set for Secret and ConfigMap references"
+// +kubebuilder:validation:XValidation:rule="!has(self.path) || self.kind in ['GitRepository', 'Bucket', 'OCIRepository']", message="path can only be set for GitRepository, Bucket and OCIRepository references"
+// +kubebuilder:validation:XValidation:rule="!has(self.mergeStrategy) || !has(self.targetPath)", message="mergeStrategy cannot be combined with targetPath"
+type ValuesReference struct {
+	// Kind of the values referent, valid values are ('Secret', 'ConfigMap',
+	// 'GitRepository', 'Bucket', 'OCIRepository').
+	// +kubebuilder:validation:Enum=Secret;ConfigMap;GitRepository;Bucket;OCIRepository
+	// +required
+	Kind string `json:"kind"`
+
+	// Name of the values referent. Should reside in the same namespace as the
+	// referring resource.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +required
+	Name string `json:"name"`
+
+	// ValuesKey is the data key of a Secret or ConfigMap where the
+	// values.yaml or a specific value can be found at. Defaults to
+	// 'values.yaml'.
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[\-._a-zA-Z0-9]+$`
+	// +optional
+	ValuesKey string `json:"valuesKey,omitempty"`
+
+	// Path is the path of the file in the artifact of a GitRepository,
+	// Bucket or OCIRepository where the values.yaml or a specific value can
+	// be found at. Defaults to 'values.yaml'.
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// TargetPath is the YAML dot notation path the value should be merged at. When
+	// set, the ValuesKey or Path is expected to be a single flat value. Defaults
+	// to 'None', which results in the values getting merged at the root.
+	// +kubebuilder:validation:MaxLength=250
+	// +kubebuilder:validation:Pattern=`^([a-zA-Z0-9_\-.\\\/]|\[[0-9]{1,5}\])+$`
+	// +optional
+	TargetPath string `json:"targetPath,omitempty"`
+
+	// MergeStrategy is the strategy used to merge the values of the
+	// referent into the values merged so far. Supported strategies are
+	// 'deepMerge', 'replace', 'appendLists', 'mergeListsByKey:<key>' and
+	// 'jsonMergePatch'. Defaults to 'deepMerge'.
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^(deepMerge|replace|appendLists|jsonMergePatch|mergeListsByKey:[\-._a-zA-Z0-9]+)$`
+	// +optional
+	MergeStrategy string `json:"mergeStrategy,omitempty"`
+
+	// Optional marks this ValuesReference as optional. When set, a not found error
+	// for the values reference is ignored, but any ValuesKey, Path, TargetPath or
+	// transient error will still result in a reconciliation failure.
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// GetValuesKey returns the defined ValuesKey, or the default ('values.yaml').
+func (in ValuesReference) GetValuesKey() string {
+	if in.ValuesKey == "" {
+		return "values.yaml"
+	}
+	return in.ValuesKey
+}
+
+// GetPath returns the defined Path, or the default ('values.yaml').
+func (in ValuesReference) GetPath() string {
+	if in.Path == "" {
+		return "values.yaml"
+	}
+	return in.Path
+}
+
+// GetMergeStrategy returns the defined MergeStrategy, or the default
+// (ValuesMergeStrategyDeepMerge).
+func (in ValuesReference) GetMergeStrategy() string {
+	if in.MergeStrategy == "" {
+		return ValuesMergeStrategyDeepMerge
+	}
+	return in.MergeStrategy
+}
+
+// IsSource returns true if the referent is a source artifact, i.e. a
+// GitRepository, Bucket or OCIRepository.
+func (in ValuesReference) IsSource() bool {
+	switch in.Kind {
+	case "GitRepository", "Bucket", "OCIRepository":
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	// ValuesMergeStrategyDeepMerge merges maps recursively, while any other
+	// value, including lists, is replaced.
+	ValuesMergeStrategyDeepMerge = "deepMerge"
+	// ValuesMergeStrategyReplace replaces the top-level keys wholesale,
+	// without merging nested maps.
+	ValuesMergeStrategyReplace = "replace"
+	// ValuesMergeStrategyAppendLists merges maps recursively, and appends
+	// lists to the existing lists.
+	ValuesMergeStrategyAppendLists = "appendLists"
+	// ValuesMergeStrategyMergeListsByKeyPrefix is the prefix of the strategy
+	// which merges maps recursively, and merges lists of maps by the value
+	// of the key following the prefix.
+	ValuesMergeStrategyMergeListsByKeyPrefix = "mergeListsByKey:"
+	// ValuesMergeStrategyJSONMergePatch applies the values as an RFC 7386
+	// JSON merge patch, in which null values delete keys.
+	ValuesMergeStrategyJSONMergePatch = "jsonMergePatch"
+)
+
+// DependencyReference contains enough information to let you locate the
+// object the HelmRelease depends on, and to determine its readiness.
+// +kubebuilder:validation:XValidation:rule="has(self.apiVersion) == has(self.kind)", message="apiVersion and kind must be set together"
+type DependencyReference struct {
+	// APIVersion of the referent, defaults to the API version of the
+	// HelmRelease.
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the referent, defaults to HelmRelease.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the referent.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the referent, defaults to the namespace of the HelmRelease
+	// resource object that contains the reference. Ignored for cluster-scoped
+	// referents.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Optional
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// ReadyExpr is a CEL expression which determines the readiness of the
+	// referent. The expression has access to the referent as `dep` and to
+	// the HelmRelease as `self`, and must evaluate to a boolean. When not
+	// specified, the readiness of a HelmRelease referent is determined by its
+	// Ready condition, and the readiness of any other referent by kstatus.
+	// +optional
+	ReadyExpr string `json:"readyExpr,omitempty"`
+}
+
+// GetAPIVersion returns the API version of the referent, or the API version
+// of the HelmRelease if not specified.
+func (in DependencyReference) GetAPIVersion() string {
+	if in.APIVersion == "" {
+		return GroupVersion.String()
+	}
+	return in.APIVersion
+}
+
+// GetKind returns the kind of the referent, or HelmReleaseKind if not
+// specified.
+func (in DependencyReference) GetKind() string {
+	if in.Kind == "" {
+		return HelmReleaseKind
+	}
+	return in.Kind
+}
+
+// IsHelmRelease returns true if the referent is a HelmRelease.
+func (in DependencyReference) IsHelmRelease() bool {
+	gv, err := schema.ParseGroupVersion(in.GetAPIVersion())
+	if err != nil {
+		return false
+	}
+	return gv.Group == GroupVersion.Group && in.GetKind() == HelmReleaseKind
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"testing"
+)
+
+func TestDependencyReference_IsHelmRelease(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  DependencyReference
+		want bool
+	}{
+		{name: "defaults", ref: DependencyReference{Name: "foo"}, want: true},
+		{name: "HelmRelease of current version", ref: DependencyReference{APIVersion: GroupVersion.String(), Kind: HelmReleaseKind}, want: true},
+		{name: "HelmRelease of other version", ref: DependencyReference{APIVersion: "helm.toolkit.fluxcd.io/v2beta2", Kind: HelmReleaseKind}, want: true},
+		{name: "other kind", ref: DependencyReference{APIVersion: "kustomize.toolkit.fluxcd.io/v1", Kind: "Kustomization"}, want: false},
+		{name: "HelmRelease kind of other group", ref: DependencyReference{APIVersion: "example.com/v1", Kind: HelmReleaseKind}, want: false},
+		{name: "invalid API version", ref: DependencyReference{APIVersion: "a/b/c", Kind: HelmReleaseKind}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ref.IsHelmRelease(); got != tt.want {
+				t.Errorf("IsHelmRelease() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta1
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/helm-controller/api/internal/fuzzer"
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/api/v2beta2"
+)
+
+func TestHelmRelease_ConvertRoundTrip(t *testing.T) {
+	for seed := int64(0); seed < 500; seed++ {
+		f := fuzzer.New(seed)
+
+		spoke := &HelmRelease{}
+		fuzzer.Fuzz(f, spoke)
+		hub := &v2.HelmRelease{}
+		if err := spoke.ConvertTo(hub); err != nil {
+			t.Fatalf("seed %d: ConvertTo() error = %v", seed, err)
+		}
+		got := &HelmRelease{}
+		if err := got.ConvertFrom(hub); err != nil {
+			t.Fatalf("seed %d: ConvertFrom() error = %v", seed, err)
+		}
+		assertJSONEqual(t, seed, got, spoke)
+
+		hub = &v2.HelmRelease{}
+		fuzzer.Fuzz(f, hub)
+		spoke = &HelmRelease{}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			t.Fatalf("seed %d: ConvertFrom() error = %v", seed, err)
+		}
+		gotHub := &v2.HelmRelease{}
+		if err := spoke.ConvertTo(gotHub); err != nil {
+			t.Fatalf("seed %d: ConvertTo() error = %v", seed, err)
+		}
+		assertJSONEqual(t, seed, gotHub, hub)
+	}
+}
+
+func TestHelmRelease_ConvertViaHub(t *testing.T) {
+	for seed := int64(0); seed < 500; seed++ {
+		f := fuzzer.New(seed)
+
+		spoke := &HelmRelease{}
+		fuzzer.Fuzz(f, spoke)
+		hub := &v2.HelmRelease{}
+		if err := spoke.ConvertTo(hub); err != nil {
+			t.Fatalf("seed %d: ConvertTo() error = %v", seed, err)
+		}
+		other := &v2beta2.HelmRelease{}
+		if err := other.ConvertFrom(hub); err != nil {
+			t.Fatalf("seed %d: ConvertFrom() error = %v", seed, err)
+		}
+		hub = &v2.HelmRelease{}
+		if err := other.ConvertTo(hub); err != nil {
+			t.Fatalf("seed %d: ConvertTo() error = %v", seed, err)
+		}
+		got := &HelmRelease{}
+		if err := got.ConvertFrom(hub); err != nil {
+			t.Fatalf("seed %d: ConvertFrom() error = %v", seed, err)
+		}
+		assertJSONEqual(t, seed, got, spoke)
+	}
+}
+
+func TestHelmRelease_ConvertFrom(t *testing.T) {
+	hub := &v2.HelmRelease{
+		Status: v2.HelmReleaseStatus{
+			AppliedDefaults:             []string{"cluster"},
+			LastAttemptedRevisionDigest: "sha256:1234",
+			History: v2.Snapshots{
+				{Name: "podinfo", Version: 2, Status: "deployed", FirstDeployed: metav1.Unix(1, 0)},
+				{Name: "podinfo", Version: 1, Status: "superseded", FirstDeployed: metav1.Unix(1, 0)},
+			},
+		},
+	}
+
+	spoke := &HelmRelease{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() error = %v", err)
+	}
+
+	// Changes made to the spoke do not affect the retained fields.
+	spoke.Status.LastAppliedRevision = "1.0.0"
+	spoke.Spec.ReleaseName = "podinfo"
+
+	got := &v2.HelmRelease{}
+	if err := spoke.ConvertTo(got); err != nil {
+		t.Fatalf("ConvertTo() error = %v", err)
+	}
+	if got.Spec.ReleaseName != "podinfo" {
+		t.Errorf("ReleaseName = %q, want %q", got.Spec.ReleaseName, "podinfo")
+	}
+	if !reflect.DeepEqual(got.Status.AppliedDefaults, hub.Status.AppliedDefaults) {
+		t.Errorf("AppliedDefaults = %v, want %v", got.Status.AppliedDefaults, hub.Status.AppliedDefaults)
+	}
+	if got.Status.LastAttemptedRevisionDigest != hub.Status.LastAttemptedRevisionDigest {
+		t.Errorf("LastAttemptedRevisionDigest = %q, want %q", got.Status.LastAttemptedRevisionDigest, hub.Status.LastAttemptedRevisionDigest)
+	}
+	if len(got.Status.History) != 2 || got.Status.History.Latest().Version != 2 {
+		t.Errorf("History = %v, want %v", got.Status.History, hub.Status.History)
+	}
+}
+
+func assertJSONEqual(t *testing.T, seed int64, got, want interface{}) {
+	t.Helper()
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("seed %d: failed to marshal: %v", seed, err)
+	}
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("seed %d: failed to marshal: %v", seed, err)
+	}
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("seed %d: round trip mismatch\ngot:  %s\nwant: %s", seed, gotJSON, wantJSON)
+	}
+}
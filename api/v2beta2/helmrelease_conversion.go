@@ -0,0 +1,36 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta2
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	intconversion "github.com/fluxcd/helm-controller/api/internal/conversion"
+)
+
+// ConvertTo converts this HelmRelease to the hub version (v2). Deprecated
+// fields are rewritten into their v2 equivalent, and are restored when the
+// object is converted back.
+func (in *HelmRelease) ConvertTo(dst conversion.Hub) error {
+	return intconversion.Convert(in, dst, intconversion.ConvertDeprecatedFields)
+}
+
+// ConvertFrom converts the hub version (v2) to this HelmRelease. Fields which
+// can not be represented in v2beta2 are retained in an annotation.
+func (in *HelmRelease) ConvertFrom(src conversion.Hub) error {
+	return intconversion.Convert(src, in, nil)
+}
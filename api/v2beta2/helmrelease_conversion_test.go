@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2beta2
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/fluxcd/pkg/apis/kustomize"
+
+	"github.com/fluxcd/helm-controller/api/internal/fuzzer"
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func TestHelmRelease_ConvertRoundTrip(t *testing.T) {
+	for seed := int64(0); seed < 500; seed++ {
+		f := fuzzer.New(seed)
+
+		spoke := &HelmRelease{}
+		fuzzer.Fuzz(f, spoke)
+		hub := &v2.HelmRelease{}
+		if err := spoke.ConvertTo(hub); err != nil {
+			t.Fatalf("seed %d: ConvertTo() error = %v", seed, err)
+		}
+		got := &HelmRelease{}
+		if err := got.ConvertFrom(hub); err != nil {
+			t.Fatalf("seed %d: ConvertFrom() error = %v", seed, err)
+		}
+		assertJSONEqual(t, seed, got, spoke)
+
+		hub = &v2.HelmRelease{}
+		fuzzer.Fuzz(f, hub)
+		spoke = &HelmRelease{}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			t.Fatalf("seed %d: ConvertFrom() error = %v", seed, err)
+		}
+		gotHub := &v2.HelmRelease{}
+		if err := spoke.ConvertTo(gotHub); err != nil {
+			t.Fatalf("seed %d: ConvertTo() error = %v", seed, err)
+		}
+		assertJSONEqual(t, seed, gotHub, hub)
+	}
+}
+
+func TestHelmRelease_ConvertTo(t *testing.T) {
+	spoke := &HelmRelease{
+		Spec: HelmReleaseSpec{
+			Chart: &HelmChartTemplate{
+				Spec: HelmChartTemplateSpec{
+					Chart:       "podinfo",
+					ValuesFile:  "values-prod.yaml",
+					ValuesFiles: []string{"values-eu.yaml"},
+				},
+			},
+			PostRenderers: []PostRenderer{
+				{
+					Kustomize: &Kustomize{
+						Patches: []kustomize.Patch{
+							{Patch: "patch", Target: &kustomize.Selector{Kind: "Deployment"}},
+						},
+						PatchesStrategicMerge: []apiextensionsv1.JSON{
+							{Raw: []byte(`{"kind":"Deployment"}`)},
+						},
+						PatchesJSON6902: []kustomize.JSON6902Patch{
+							{
+								Patch: []kustomize.JSON6902{
+									{Op: "remove", Path: "/spec/replicas"},
+								},
+								Target: kustomize.Selector{Kind: "Service"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	hub := &v2.HelmRelease{}
+	if err := spoke.ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo() error = %v", err)
+	}
+
+	if want := []string{"values-prod.yaml", "values-eu.yaml"}; !reflect.DeepEqual(hub.Spec.Chart.Spec.ValuesFiles, want) {
+		t.Errorf("ValuesFiles = %v, want %v", hub.Spec.Chart.Spec.ValuesFiles, want)
+	}
+	want := []kustomize.Patch{
+		{Patch: `{"kind":"Deployment"}`},
+		{Patch: "patch", Target: &kustomize.Selector{Kind: "Deployment"}},
+		{Patch: `[{"op":"remove","path":"/spec/replicas"}]`, Target: &kustomize.Selector{Kind: "Service"}},
+	}
+	if got := hub.Spec.PostRenderers[0].Kustomize.Patches; !reflect.DeepEqual(got, want) {
+		t.Errorf("Patches = %v, want %v", got, want)
+	}
+
+	got := &HelmRelease{}
+	if err := got.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() error = %v", err)
+	}
+	assertJSONEqual(t, 0, got, spoke)
+
+	// Changes made to the hub take precedence over the deprecated fields.
+	hub.Spec.Chart.Spec.ValuesFiles = []string{"values.yaml"}
+	got = &HelmRelease{}
+	if err := got.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom() error = %v", err)
+	}
+	if got.Spec.Chart.Spec.ValuesFile != "" {
+		t.Errorf("ValuesFile = %q, want empty", got.Spec.Chart.Spec.ValuesFile)
+	}
+	if want := []string{"values.yaml"}; !reflect.DeepEqual(got.Spec.Chart.Spec.ValuesFiles, want) {
+		t.Errorf("ValuesFiles = %v, want %v", got.Spec.Chart.Spec.ValuesFiles, want)
+	}
+	if got := got.Spec.PostRenderers[0].Kustomize; got.PatchesStrategicMerge == nil || got.PatchesJSON6902 == nil {
+		t.Errorf("expected unchanged deprecated patches to be restored, got %v", got)
+	}
+}
+
+func assertJSONEqual(t *testing.T, seed int64, got, want interface{}) {
+	t.Helper()
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("seed %d: failed to marshal: %v", seed, err)
+	}
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("seed %d: failed to marshal: %v", seed, err)
+	}
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("seed %d: round trip mismatch\ngot:  %s\nwant: %s", seed, gotJSON, wantJSON)
+	}
+}
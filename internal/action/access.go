@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	helmaction "helm.sh/helm/v3/pkg/action"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apierrutil "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/kube"
+)
+
+// waitAccess holds the resources Helm lists while waiting for objects of a
+// kind to become ready, in addition to getting the objects themselves.
+var waitAccess = map[schema.GroupKind]schema.GroupResource{
+	{Group: "apps", Kind: "Deployment"}: {Group: "apps", Resource: "replicasets"},
+	{Group: "apps", Kind: "ReplicaSet"}: {Resource: "pods"},
+	{Kind: "ReplicationController"}:     {Resource: "pods"},
+}
+
+// accessOptions holds the configuration of a Helm action which determines
+// the permissions it requires.
+type accessOptions struct {
+	// policy is the CRDs policy of the action.
+	policy v2.CRDsPolicy
+	// hookEvents are the events of the hooks run by the action.
+	hookEvents []helmrelease.HookEvent
+	// disableHooks disables the hooks of the action.
+	disableHooks bool
+	// wait makes the action wait for the objects to become ready.
+	wait bool
+	// force makes the action replace the objects instead of patching them.
+	force bool
+	// createNamespace makes the action create the namespace of the release.
+	createNamespace bool
+	// storageDriver is the name of the Helm storage driver.
+	storageDriver string
+	// storageNamespace is the namespace of the Helm storage.
+	storageNamespace string
+}
+
+// checkAccess verifies the user impersonated by the RESTClientGetter of the
+// given config is allowed to perform the Helm action for the target release
+// rls, using SubjectAccessReviews. The current release cur is used to
+// determine the objects which would be deleted, and may be nil.
+//
+// The reviews are created without impersonation, and nothing is verified
+// when the RESTClientGetter does not impersonate a user. It returns an error
+// listing all the missing permissions.
+func checkAccess(ctx context.Context, config *helmaction.Configuration, cur, rls *helmrelease.Release,
+	chrt *helmchart.Chart, opts accessOptions) error {
+	restCfg, err := config.RESTClientGetter.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	user := restCfg.Impersonate.UserName
+	if user == "" {
+		return nil
+	}
+	groups := append(kube.ServiceAccountGroups(user), restCfg.Impersonate.Groups...)
+
+	// Like NewClient, but without impersonation: reuse the RESTMapper of the
+	// getter to prevent API discovery from running for every check.
+	mapper, err := config.RESTClientGetter.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+	cfg := rest.CopyConfig(restCfg)
+	cfg.Impersonate = rest.ImpersonationConfig{}
+	c, err := client.New(cfg, client.Options{Mapper: mapper})
+	if err != nil {
+		return err
+	}
+
+	attrs, err := requiredAccess(c.RESTMapper(), cur, rls, chrt, opts)
+	if err != nil {
+		return err
+	}
+
+	var (
+		missing []string
+		errs    []error
+	)
+	for i := range attrs {
+		review := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:               user,
+				Groups:             groups,
+				ResourceAttributes: &attrs[i],
+			},
+		}
+		if err := c.Create(ctx, review); err != nil {
+			errs = append(errs, fmt.Errorf("failed to review access to %s: %w", describeAccess(attrs[i]), err))
+			continue
+		}
+		if !review.Status.Allowed {
+			missing = append(missing, describeAccess(attrs[i]))
+		}
+	}
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("%s is missing permissions to %s", user, strings.Join(missing, ", ")))
+	}
+	return apierrutil.Reduce(apierrutil.Flatten(apierrutil.NewAggregate(errs)))
+}
+
+// requiredAccess returns the resource attributes of all the requests the Helm
+// action for the target release rls would make, sorted and without
+// duplicates. Requests for objects are made by name, as permissions may be
+// restricted to specific resource names.
+func requiredAccess(mapper apimeta.RESTMapper, cur, rls *helmrelease.Release, chrt *helmchart.Chart,
+	opts accessOptions) ([]authorizationv1.ResourceAttributes, error) {
+	var (
+		attrs = make(map[authorizationv1.ResourceAttributes]struct{})
+		errs  []error
+	)
+	add := func(gr schema.GroupResource, namespace, name string, verbs ...string) {
+		for _, verb := range verbs {
+			a := authorizationv1.ResourceAttributes{
+				Verb:      verb,
+				Group:     gr.Group,
+				Resource:  gr.Resource,
+				Namespace: namespace,
+			}
+			// Create requests can not be restricted by name.
+			if verb != "create" {
+				a.Name = name
+			}
+			attrs[a] = struct{}{}
+		}
+	}
+
+	// Resources of the CRDs applied by the action can not be mapped before
+	// the CRDs exist, but are known from the CRDs themselves.
+	crdResources := make(map[schema.GroupKind]apimeta.RESTMapping)
+	if opts.policy != v2.Skip {
+		crdGR := apiextensionsv1.Resource("customresourcedefinitions")
+		for _, crd := range chrt.CRDObjects() {
+			objects, err := ssautil.ReadObjects(bytes.NewReader(crd.File.Data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CustomResourceDefinitions from %s: %w", crd.Name, err)
+			}
+			for _, obj := range objects {
+				add(crdGR, "", obj.GetName(), "create")
+				if opts.policy == v2.CreateReplace {
+					add(crdGR, "", obj.GetName(), "get", "update")
+				}
+
+				group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+				kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+				plural, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "plural")
+				scope, _, _ := unstructured.NestedString(obj.Object, "spec", "scope")
+				mapping := apimeta.RESTMapping{
+					Resource: schema.GroupVersionResource{Group: group, Resource: plural},
+					Scope:    apimeta.RESTScopeRoot,
+				}
+				if scope == string(apiextensionsv1.NamespaceScoped) {
+					mapping.Scope = apimeta.RESTScopeNamespace
+				}
+				crdResources[schema.GroupKind{Group: group, Kind: kind}] = mapping
+			}
+		}
+	}
+
+	// addObjects adds the given verbs for the given objects, which default
+	// to the given namespace.
+	addObjects := func(objects []*unstructured.Unstructured, namespace string, verbs ...string) {
+		for _, obj := range objects {
+			gvk := obj.GroupVersionKind()
+			mapping, ok := crdResources[gvk.GroupKind()]
+			if !ok {
+				m, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to map %s: %w", ssautil.FmtUnstructured(obj), err))
+					continue
+				}
+				mapping = *m
+			}
+
+			ns := ""
+			if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+				if ns = obj.GetNamespace(); ns == "" {
+					ns = namespace
+				}
+			}
+			add(mapping.Resource.GroupResource(), ns, obj.GetName(), verbs...)
+			if gr, ok := waitAccess[gvk.GroupKind()]; ok && opts.wait && gr.Resource != "" {
+				add(gr, ns, "", "list")
+			}
+		}
+	}
+
+	verbs := []string{"get", "create", "patch"}
+	if opts.force {
+		verbs = append(verbs, "update", "delete")
+	}
+	objects, err := ssautil.ReadObjects(strings.NewReader(rls.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objects from release manifest: %w", err)
+	}
+	addObjects(objects, rls.Namespace, verbs...)
+	if !opts.disableHooks {
+		for _, hook := range rls.Hooks {
+			if !hasHookEvent(hook, opts.hookEvents...) {
+				continue
+			}
+			objects, err := ssautil.ReadObjects(strings.NewReader(hook.Manifest))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read objects from hook %s: %w", hook.Name, err)
+			}
+			addObjects(objects, rls.Namespace, "get", "create", "delete", "watch")
+		}
+	}
+	if cur != nil {
+		removed, err := removedObjects(cur, rls)
+		if err != nil {
+			return nil, err
+		}
+		addObjects(removed, cur.Namespace, "delete")
+	}
+
+	if opts.createNamespace {
+		add(schema.GroupResource{Resource: "namespaces"}, "", rls.Namespace, "create")
+	}
+
+	switch opts.storageDriver {
+	case helmdriver.SecretsDriverName:
+		add(schema.GroupResource{Resource: "secrets"}, opts.storageNamespace, "", "get", "list", "create", "update", "delete")
+	case helmdriver.ConfigMapsDriverName:
+		add(schema.GroupResource{Resource: "configmaps"}, opts.storageNamespace, "", "get", "list", "create", "update", "delete")
+	}
+
+	if len(errs) > 0 {
+		return nil, apierrutil.Reduce(apierrutil.Flatten(apierrutil.NewAggregate(errs)))
+	}
+
+	result := make([]authorizationv1.ResourceAttributes, 0, len(attrs))
+	for a := range attrs {
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return describeAccess(result[i]) < describeAccess(result[j])
+	})
+	return result, nil
+}
+
+// describeAccess returns a human-readable description of the given resource
+// attributes, e.g. "create deployments.apps in namespace 'default'".
+func describeAccess(a authorizationv1.ResourceAttributes) string {
+	var b strings.Builder
+	b.WriteString(a.Verb + " " + a.Resource)
+	if a.Group != "" {
+		b.WriteString("." + a.Group)
+	}
+	if a.Name != "" {
+		b.WriteString(" '" + a.Name + "'")
+	}
+	if a.Namespace != "" {
+		b.WriteString(" in namespace '" + a.Namespace + "'")
+	}
+	return b.String()
+}
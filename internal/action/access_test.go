@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func Test_requiredAccess(t *testing.T) {
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, apimeta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, apimeta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, apimeta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, apimeta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, apimeta.RESTScopeRoot)
+
+	chrt := &helmchart.Chart{
+		Files: []*helmchart.File{
+			{
+				Name: "crds/widgets.yaml",
+				Data: []byte(`---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+`),
+			},
+		},
+	}
+	cur := &helmrelease.Release{
+		Namespace: "release",
+		Manifest: `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: removed
+`,
+	}
+	rls := &helmrelease.Release{
+		Namespace: "release",
+		Manifest: `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: role
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  namespace: other
+`,
+		Hooks: []*helmrelease.Hook{
+			{
+				Name: "migrate",
+				Manifest: `---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+`,
+				Events: []helmrelease.HookEvent{helmrelease.HookPreUpgrade},
+			},
+			{
+				Name: "test",
+				Manifest: `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+`,
+				Events: []helmrelease.HookEvent{helmrelease.HookTest},
+			},
+		},
+	}
+
+	g := NewWithT(t)
+
+	got, err := requiredAccess(mapper, cur, rls, chrt, accessOptions{
+		policy:           v2.Create,
+		hookEvents:       []helmrelease.HookEvent{helmrelease.HookPreUpgrade, helmrelease.HookPostUpgrade},
+		wait:             true,
+		storageDriver:    helmdriver.SecretsDriverName,
+		storageNamespace: "storage",
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var descriptions []string
+	for _, a := range got {
+		descriptions = append(descriptions, describeAccess(a))
+	}
+	g.Expect(descriptions).To(Equal([]string{
+		"create clusterroles.rbac.authorization.k8s.io",
+		"create customresourcedefinitions.apiextensions.k8s.io",
+		"create deployments.apps in namespace 'release'",
+		"create jobs.batch in namespace 'release'",
+		"create secrets in namespace 'storage'",
+		"create widgets.example.com in namespace 'other'",
+		"delete jobs.batch 'migrate' in namespace 'release'",
+		"delete secrets 'removed' in namespace 'release'",
+		"delete secrets in namespace 'storage'",
+		"get clusterroles.rbac.authorization.k8s.io 'role'",
+		"get deployments.apps 'app' in namespace 'release'",
+		"get jobs.batch 'migrate' in namespace 'release'",
+		"get secrets in namespace 'storage'",
+		"get widgets.example.com 'widget' in namespace 'other'",
+		"list replicasets.apps in namespace 'release'",
+		"list secrets in namespace 'storage'",
+		"patch clusterroles.rbac.authorization.k8s.io 'role'",
+		"patch deployments.apps 'app' in namespace 'release'",
+		"patch widgets.example.com 'widget' in namespace 'other'",
+		"update secrets in namespace 'storage'",
+		"watch jobs.batch 'migrate' in namespace 'release'",
+	}))
+
+	_, err = requiredAccess(mapper, nil, rls, chrt, accessOptions{policy: v2.Skip})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("Widget"))
+}
+
+func Test_describeAccess(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(describeAccess(authorizationv1.ResourceAttributes{
+		Verb:     "create",
+		Resource: "namespaces",
+	})).To(Equal("create namespaces"))
+	g.Expect(describeAccess(authorizationv1.ResourceAttributes{
+		Verb:      "patch",
+		Group:     "apps",
+		Resource:  "deployments",
+		Name:      "app",
+		Namespace: "default",
+	})).To(Equal("patch deployments.apps 'app' in namespace 'default'"))
+}
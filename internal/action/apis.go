@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// APIDeprecation describes an API which is deprecated in, or removed from,
+// a Kubernetes version.
+type APIDeprecation struct {
+	// GroupVersionKind is the deprecated API.
+	GroupVersionKind schema.GroupVersionKind
+	// DeprecatedIn is the Kubernetes version the API is deprecated in.
+	DeprecatedIn string
+	// RemovedIn is the Kubernetes version the API is removed in.
+	RemovedIn string
+	// Replacement is the API version which replaces the API, if any.
+	Replacement string
+}
+
+// String returns a human-readable description of the deprecation.
+func (d APIDeprecation) String() string {
+	gvk := d.GroupVersionKind
+	msg := fmt.Sprintf("%s %s is deprecated since %s and removed in %s", gvk.GroupVersion().String(), gvk.Kind,
+		d.DeprecatedIn, d.RemovedIn)
+	if d.Replacement != "" {
+		msg += fmt.Sprintf(", use %s", d.Replacement)
+	}
+	return msg
+}
+
+// apiDeprecations holds the deprecations of the built-in Kubernetes APIs,
+// as documented in the Kubernetes deprecated API migration guide.
+// xref: https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+var apiDeprecations = []APIDeprecation{
+	// v1.16
+	deprecation("extensions", "v1beta1", "DaemonSet", "v1.8", "v1.16", "apps/v1"),
+	deprecation("extensions", "v1beta1", "Deployment", "v1.8", "v1.16", "apps/v1"),
+	deprecation("extensions", "v1beta1", "ReplicaSet", "v1.8", "v1.16", "apps/v1"),
+	deprecation("extensions", "v1beta1", "NetworkPolicy", "v1.9", "v1.16", "networking.k8s.io/v1"),
+	deprecation("extensions", "v1beta1", "PodSecurityPolicy", "v1.11", "v1.16", "policy/v1beta1"),
+	deprecation("apps", "v1beta1", "Deployment", "v1.9", "v1.16", "apps/v1"),
+	deprecation("apps", "v1beta1", "StatefulSet", "v1.9", "v1.16", "apps/v1"),
+	deprecation("apps", "v1beta2", "DaemonSet", "v1.9", "v1.16", "apps/v1"),
+	deprecation("apps", "v1beta2", "Deployment", "v1.9", "v1.16", "apps/v1"),
+	deprecation("apps", "v1beta2", "ReplicaSet", "v1.9", "v1.16", "apps/v1"),
+	deprecation("apps", "v1beta2", "StatefulSet", "v1.9", "v1.16", "apps/v1"),
+
+	// v1.22
+	deprecation("admissionregistration.k8s.io", "v1beta1", "MutatingWebhookConfiguration", "v1.16", "v1.22", "admissionregistration.k8s.io/v1"),
+	deprecation("admissionregistration.k8s.io", "v1beta1", "ValidatingWebhookConfiguration", "v1.16", "v1.22", "admissionregistration.k8s.io/v1"),
+	deprecation("apiextensions.k8s.io", "v1beta1", "CustomResourceDefinition", "v1.16", "v1.22", "apiextensions.k8s.io/v1"),
+	deprecation("apiregistration.k8s.io", "v1beta1", "APIService", "v1.19", "v1.22", "apiregistration.k8s.io/v1"),
+	deprecation("certificates.k8s.io", "v1beta1", "CertificateSigningRequest", "v1.19", "v1.22", "certificates.k8s.io/v1"),
+	deprecation("coordination.k8s.io", "v1beta1", "Lease", "v1.19", "v1.22", "coordination.k8s.io/v1"),
+	deprecation("extensions", "v1beta1", "Ingress", "v1.14", "v1.22", "networking.k8s.io/v1"),
+	deprecation("networking.k8s.io", "v1beta1", "Ingress", "v1.19", "v1.22", "networking.k8s.io/v1"),
+	deprecation("networking.k8s.io", "v1beta1", "IngressClass", "v1.19", "v1.22", "networking.k8s.io/v1"),
+	deprecation("rbac.authorization.k8s.io", "v1beta1", "ClusterRole", "v1.17", "v1.22", "rbac.authorization.k8s.io/v1"),
+	deprecation("rbac.authorization.k8s.io", "v1beta1", "ClusterRoleBinding", "v1.17", "v1.22", "rbac.authorization.k8s.io/v1"),
+	deprecation("rbac.authorization.k8s.io", "v1beta1", "Role", "v1.17", "v1.22", "rbac.authorization.k8s.io/v1"),
+	deprecation("rbac.authorization.k8s.io", "v1beta1", "RoleBinding", "v1.17", "v1.22", "rbac.authorization.k8s.io/v1"),
+	deprecation("scheduling.k8s.io", "v1beta1", "PriorityClass", "v1.14", "v1.22", "scheduling.k8s.io/v1"),
+	deprecation("storage.k8s.io", "v1beta1", "CSIDriver", "v1.19", "v1.22", "storage.k8s.io/v1"),
+	deprecation("storage.k8s.io", "v1beta1", "CSINode", "v1.17", "v1.22", "storage.k8s.io/v1"),
+	deprecation("storage.k8s.io", "v1beta1", "StorageClass", "v1.19", "v1.22", "storage.k8s.io/v1"),
+	deprecation("storage.k8s.io", "v1beta1", "VolumeAttachment", "v1.19", "v1.22", "storage.k8s.io/v1"),
+
+	// v1.25
+	deprecation("batch", "v1beta1", "CronJob", "v1.21", "v1.25", "batch/v1"),
+	deprecation("discovery.k8s.io", "v1beta1", "EndpointSlice", "v1.21", "v1.25", "discovery.k8s.io/v1"),
+	deprecation("events.k8s.io", "v1beta1", "Event", "v1.19", "v1.25", "events.k8s.io/v1"),
+	deprecation("autoscaling", "v2beta1", "HorizontalPodAutoscaler", "v1.22", "v1.25", "autoscaling/v2"),
+	deprecation("policy", "v1beta1", "PodDisruptionBudget", "v1.21", "v1.25", "policy/v1"),
+	deprecation("policy", "v1beta1", "PodSecurityPolicy", "v1.21", "v1.25", ""),
+	deprecation("node.k8s.io", "v1beta1", "RuntimeClass", "v1.20", "v1.25", "node.k8s.io/v1"),
+
+	// v1.26
+	deprecation("flowcontrol.apiserver.k8s.io", "v1beta1", "FlowSchema", "v1.23", "v1.26", "flowcontrol.apiserver.k8s.io/v1"),
+	deprecation("flowcontrol.apiserver.k8s.io", "v1beta1", "PriorityLevelConfiguration", "v1.23", "v1.26", "flowcontrol.apiserver.k8s.io/v1"),
+	deprecation("autoscaling", "v2beta2", "HorizontalPodAutoscaler", "v1.23", "v1.26", "autoscaling/v2"),
+
+	// v1.27
+	deprecation("storage.k8s.io", "v1beta1", "CSIStorageCapacity", "v1.24", "v1.27", "storage.k8s.io/v1"),
+
+	// v1.29
+	deprecation("flowcontrol.apiserver.k8s.io", "v1beta2", "FlowSchema", "v1.26", "v1.29", "flowcontrol.apiserver.k8s.io/v1"),
+	deprecation("flowcontrol.apiserver.k8s.io", "v1beta2", "PriorityLevelConfiguration", "v1.26", "v1.29", "flowcontrol.apiserver.k8s.io/v1"),
+
+	// v1.32
+	deprecation("flowcontrol.apiserver.k8s.io", "v1beta3", "FlowSchema", "v1.29", "v1.32", "flowcontrol.apiserver.k8s.io/v1"),
+	deprecation("flowcontrol.apiserver.k8s.io", "v1beta3", "PriorityLevelConfiguration", "v1.29", "v1.32", "flowcontrol.apiserver.k8s.io/v1"),
+}
+
+// deprecation returns an APIDeprecation for the given values.
+func deprecation(group, version, kind, deprecatedIn, removedIn, replacement string) APIDeprecation {
+	return APIDeprecation{
+		GroupVersionKind: schema.GroupVersionKind{Group: group, Version: version, Kind: kind},
+		DeprecatedIn:     deprecatedIn,
+		RemovedIn:        removedIn,
+		Replacement:      replacement,
+	}
+}
+
+// lookupDeprecation returns the APIDeprecation of the given API, or nil if
+// the API is not deprecated.
+func lookupDeprecation(gvk schema.GroupVersionKind) *APIDeprecation {
+	for i := range apiDeprecations {
+		if apiDeprecations[i].GroupVersionKind == gvk {
+			return &apiDeprecations[i]
+		}
+	}
+	return nil
+}
+
+// checkAPIs verifies the APIs of the given objects are served by the cluster
+// using the given mapper, and returns an error listing the APIs which are
+// not. In addition, it returns the APIs which are deprecated in the given
+// Kubernetes version of the cluster.
+func checkAPIs(mapper apimeta.RESTMapper, kubeVersion *version.Version,
+	objects []*unstructured.Unstructured) ([]APIDeprecation, error) {
+	var (
+		checked      = make(map[schema.GroupVersionKind]struct{})
+		missing      []string
+		deprecations []APIDeprecation
+	)
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		if _, ok := checked[gvk]; ok {
+			continue
+		}
+		checked[gvk] = struct{}{}
+
+		d := lookupDeprecation(gvk)
+		if _, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if !apimeta.IsNoMatchError(err) {
+				return nil, fmt.Errorf("failed to map %s: %w", gvk.String(), err)
+			}
+			msg := fmt.Sprintf("%s %s", gvk.GroupVersion().String(), gvk.Kind)
+			if d != nil {
+				msg = d.String()
+			}
+			missing = append(missing, msg)
+			continue
+		}
+
+		if d != nil && kubeVersion != nil && kubeVersion.AtLeast(version.MustParseGeneric(d.DeprecatedIn)) {
+			deprecations = append(deprecations, *d)
+		}
+	}
+
+	sort.Slice(deprecations, func(i, j int) bool {
+		return deprecations[i].String() < deprecations[j].String()
+	})
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return deprecations, fmt.Errorf("APIs not served by the cluster: %s", strings.Join(missing, "; "))
+	}
+	return deprecations, nil
+}
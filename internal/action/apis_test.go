@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+)
+
+func Test_checkAPIs(t *testing.T) {
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, apimeta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Kind: "FlowSchema"}, apimeta.RESTScopeRoot)
+
+	objects, err := ssautil.ReadObjects(strings.NewReader(`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other
+---
+apiVersion: flowcontrol.apiserver.k8s.io/v1beta3
+kind: FlowSchema
+metadata:
+  name: schema
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, err := ssautil.ReadObjects(strings.NewReader(`---
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: pdb
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		kubeVersion string
+		missing     bool
+		want        []string
+		wantErr     string
+	}{
+		{
+			name:        "returns deprecated APIs",
+			kubeVersion: "v1.30.2",
+			want:        []string{"flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema is deprecated since v1.29 and removed in v1.32, use flowcontrol.apiserver.k8s.io/v1"},
+		},
+		{
+			name:        "ignores APIs deprecated in later versions",
+			kubeVersion: "v1.28.0",
+		},
+		{
+			name:        "returns error for APIs not served",
+			kubeVersion: "v1.30.2",
+			missing:     true,
+			want:        []string{"flowcontrol.apiserver.k8s.io/v1beta3 FlowSchema is deprecated since v1.29 and removed in v1.32, use flowcontrol.apiserver.k8s.io/v1"},
+			wantErr:     "APIs not served by the cluster: example.com/v1 Widget; policy/v1beta1 PodDisruptionBudget is deprecated since v1.21 and removed in v1.25, use policy/v1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			objs := objects
+			if tt.missing {
+				objs = append(objs, missing...)
+			}
+			got, err := checkAPIs(mapper, version.MustParseGeneric(tt.kubeVersion), objs)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(tt.wantErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+
+			var descriptions []string
+			for _, d := range got {
+				descriptions = append(descriptions, d.String())
+			}
+			g.Expect(descriptions).To(Equal(tt.want))
+		})
+	}
+}
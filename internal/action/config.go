@@ -24,6 +24,7 @@ import (
 	helmstorage "helm.sh/helm/v3/pkg/storage"
 	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fluxcd/helm-controller/internal/storage"
 )
@@ -164,6 +165,23 @@ func (c *ConfigFactory) Build(log helmaction.DebugLog, observers ...storage.Obse
 	}
 }
 
+// NewClient returns a new controller-runtime client for the Kubernetes API
+// of the given RESTClientGetter, configured with the provided options. The
+// RESTMapper of the getter is used, which prevents API discovery from running
+// for every client.
+func NewClient(getter helmaction.RESTClientGetter, opts client.Options) (client.Client, error) {
+	cfg, err := getter.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	mapper, err := getter.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	opts.Mapper = mapper
+	return client.New(cfg, opts)
+}
+
 // Valid returns an error if the ConfigFactory is missing configuration
 // required to run a Helm action.
 func (c *ConfigFactory) Valid() error {
@@ -58,6 +58,13 @@ func crdPolicyOrDefault(policy v2.CRDsPolicy) (v2.CRDsPolicy, error) {
 	return policy, nil
 }
 
+// ValidateCRDsPolicy returns an error if the given CRD policy is not empty
+// and not one of the supported policies.
+func ValidateCRDsPolicy(policy v2.CRDsPolicy) error {
+	_, err := crdPolicyOrDefault(policy)
+	return err
+}
+
 type rootScoped struct{}
 
 func (*rootScoped) Name() apimeta.RESTScopeName {
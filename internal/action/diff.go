@@ -27,6 +27,7 @@ import (
 	helmaction "helm.sh/helm/v3/pkg/action"
 	helmrelease "helm.sh/helm/v3/pkg/release"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	apierrutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/utils/ptr"
@@ -105,25 +106,7 @@ func Diff(ctx context.Context, config *helmaction.Configuration, rls *helmreleas
 	}
 
 	// Add ignore rules to the diffing configuration.
-	var ignoreRules jsondiff.IgnoreRules
-	for _, rule := range ignore {
-		r := jsondiff.IgnoreRule{
-			Paths: rule.Paths,
-		}
-		if rule.Target != nil {
-			r.Selector = &jsondiff.Selector{
-				Group:              rule.Target.Group,
-				Version:            rule.Target.Version,
-				Kind:               rule.Target.Kind,
-				Name:               rule.Target.Name,
-				Namespace:          rule.Target.Namespace,
-				AnnotationSelector: rule.Target.AnnotationSelector,
-				LabelSelector:      rule.Target.LabelSelector,
-			}
-		}
-		ignoreRules = append(ignoreRules, r)
-	}
-	if len(ignoreRules) > 0 {
+	if ignoreRules := toIgnoreRules(ignore); len(ignoreRules) > 0 {
 		diffOpts = append(diffOpts, ignoreRules)
 	}
 
@@ -195,6 +178,121 @@ func ApplyDiff(ctx context.Context, config *helmaction.Configuration, diffSet js
 	return changeSet, apierrutil.NewAggregate(errs)
 }
 
+// ApplyDiffServerSide corrects the changes described in the provided
+// jsondiff.DiffSet by applying the desired objects to the Kubernetes cluster
+// using server-side apply, taking ownership of the applied fields. The paths
+// ignored by the given rules are removed from the objects before applying,
+// to not take ownership of them.
+//
+// Unless forceConflicts is set, objects with changed fields owned by other
+// field managers are not applied, but reported as a conflict.
+func ApplyDiffServerSide(ctx context.Context, config *helmaction.Configuration, diffSet jsondiff.DiffSet, fieldOwner string,
+	forceConflicts bool, ignore ...v2.IgnoreRule) (*ssa.ChangeSet, error) {
+	c, err := NewClient(config.RESTClientGetter, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	var selectors = make(map[*jsondiff.SelectorRegex][]string, len(ignore))
+	for _, rule := range toIgnoreRules(ignore) {
+		sr, err := jsondiff.NewSelectorRegex(rule.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ignore rule selector: %w", err)
+		}
+		selectors[sr] = append(selectors[sr], rule.Paths...)
+	}
+
+	var (
+		objects []*unstructured.Unstructured
+		errs    []error
+	)
+	for _, d := range diffSet {
+		if d.Type != jsondiff.DiffTypeCreate && d.Type != jsondiff.DiffTypeUpdate {
+			continue
+		}
+		obj, ok := d.DesiredObject.(*unstructured.Unstructured)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s apply failure: unexpected object type %T",
+				diff.ResourceName(d.DesiredObject), d.DesiredObject))
+			continue
+		}
+		obj = obj.DeepCopy()
+
+		var ignorePaths []string
+		for sr, paths := range selectors {
+			if sr.MatchUnstructured(obj) {
+				ignorePaths = append(ignorePaths, paths...)
+			}
+		}
+		if err := jsondiff.ApplyPatchToUnstructured(obj, jsondiff.GenerateRemovePatch(ignorePaths...)); err != nil {
+			errs = append(errs, fmt.Errorf("%s apply failure: %w", diff.ResourceName(obj), err))
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	sort.Sort(ssa.SortableUnstructureds(objects))
+
+	var (
+		changeSet = ssa.NewChangeSet()
+		manager   = ssa.NewResourceManager(c, nil, ssa.Owner{Field: fieldOwner, Group: v2.GroupVersion.Group})
+		applyOpts = ssa.ApplyOptions{
+			ExclusionSelector: map[string]string{v2.DriftDetectionMetadataKey: v2.DriftDetectionDisabledValue},
+		}
+	)
+	for _, obj := range objects {
+		if !forceConflicts {
+			// The resource manager always forces the ownership of fields,
+			// detect conflicts with a dry-run apply which does not.
+			dryRunObj := obj.DeepCopy()
+			err := c.Patch(ctx, dryRunObj, client.Apply, client.DryRunAll, client.FieldOwner(fieldOwner))
+			if apierrors.IsConflict(err) {
+				if obj.GetKind() == "Secret" {
+					err = maskSensitiveErrData(err)
+				}
+				errs = append(errs, fmt.Errorf("%s apply conflict: %w", diff.ResourceName(obj), err))
+				continue
+			}
+		}
+
+		entry, err := manager.Apply(ctx, obj, applyOpts)
+		if err != nil {
+			if obj.GetKind() == "Secret" {
+				err = maskSensitiveErrData(err)
+			}
+			errs = append(errs, fmt.Errorf("%s apply failure: %w", diff.ResourceName(obj), err))
+			continue
+		}
+		if entry.Action == ssa.CreatedAction || entry.Action == ssa.ConfiguredAction {
+			changeSet.Add(*entry)
+		}
+	}
+
+	return changeSet, apierrutil.NewAggregate(errs)
+}
+
+// toIgnoreRules converts the given v2.IgnoreRule list to jsondiff.IgnoreRules.
+func toIgnoreRules(ignore []v2.IgnoreRule) jsondiff.IgnoreRules {
+	var ignoreRules jsondiff.IgnoreRules
+	for _, rule := range ignore {
+		r := jsondiff.IgnoreRule{
+			Paths: rule.Paths,
+		}
+		if rule.Target != nil {
+			r.Selector = &jsondiff.Selector{
+				Group:              rule.Target.Group,
+				Version:            rule.Target.Version,
+				Kind:               rule.Target.Kind,
+				Name:               rule.Target.Name,
+				Namespace:          rule.Target.Namespace,
+				AnnotationSelector: rule.Target.AnnotationSelector,
+				LabelSelector:      rule.Target.LabelSelector,
+			}
+		}
+		ignoreRules = append(ignoreRules, r)
+	}
+	return ignoreRules
+}
+
 const (
 	appManagedByLabel              = "app.kubernetes.io/managed-by"
 	appManagedByHelm               = "Helm"
@@ -792,6 +792,214 @@ func TestApplyDiff(t *testing.T) {
 	}
 }
 
+func TestApplyDiffServerSide(t *testing.T) {
+	// Normally, we would create e.g. a `suite_test.go` file with a `TestMain`
+	// function. As this is one of the few tests in this package which needs a
+	// test cluster, we create it here instead.
+	config, cleanup := newTestCluster(t)
+	t.Cleanup(func() {
+		t.Log("Stopping the test environment")
+		if err := cleanup(); err != nil {
+			t.Logf("Failed to stop the test environment: %v", err)
+		}
+	})
+
+	// Construct a REST client getter for Helm's action configuration.
+	getter := kube.NewMemoryRESTClientGetter(config)
+
+	// Construct a client for to be able to mutate the cluster.
+	c, err := client.New(config, client.Options{})
+	if err != nil {
+		t.Fatalf("Failed to create client for test environment: %v", err)
+	}
+
+	const (
+		testOwner  = "helm-controller"
+		otherOwner = "kubectl-edit"
+	)
+
+	configMapDiff := func(namespace string, desired, cluster map[string]interface{}) *jsondiff.Diff {
+		return &jsondiff.Diff{
+			Type: jsondiff.DiffTypeUpdate,
+			DesiredObject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name":      "test-cm",
+						"namespace": namespace,
+					},
+					"data": desired,
+				},
+			},
+			ClusterObject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name":      "test-cm",
+						"namespace": namespace,
+					},
+					"data": cluster,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		diffSet        func(namespace string) jsondiff.DiffSet
+		forceConflicts bool
+		ignore         []v2.IgnoreRule
+		expect         func(g *GomegaWithT, namespace string, got *ssa.ChangeSet, err error)
+	}{
+		{
+			name: "creates and applies resources",
+			diffSet: func(namespace string) jsondiff.DiffSet {
+				return jsondiff.DiffSet{
+					{
+						Type: jsondiff.DiffTypeCreate,
+						DesiredObject: &unstructured.Unstructured{
+							Object: map[string]interface{}{
+								"apiVersion": "v1",
+								"kind":       "Secret",
+								"metadata": map[string]interface{}{
+									"name":      "test-secret",
+									"namespace": namespace,
+								},
+								"stringData": map[string]interface{}{
+									"key": "value",
+								},
+							},
+						},
+					},
+					configMapDiff(namespace, map[string]interface{}{"key": "value"}, map[string]interface{}{"key": "changed"}),
+				}
+			},
+			forceConflicts: true,
+			expect: func(g *GomegaWithT, namespace string, got *ssa.ChangeSet, err error) {
+				g.THelper()
+
+				g.Expect(err).NotTo(HaveOccurred())
+
+				g.Expect(got).NotTo(BeNil())
+				g.Expect(got.Entries).To(HaveLen(2))
+
+				g.Expect(got.Entries[0].Subject).To(Equal("Secret/" + namespace + "/test-secret"))
+				g.Expect(got.Entries[0].Action).To(Equal(ssa.CreatedAction))
+				g.Expect(c.Get(context.TODO(), types.NamespacedName{
+					Namespace: namespace,
+					Name:      "test-secret",
+				}, &corev1.Secret{})).To(Succeed())
+
+				g.Expect(got.Entries[1].Subject).To(Equal("ConfigMap/" + namespace + "/test-cm"))
+				g.Expect(got.Entries[1].Action).To(Equal(ssa.ConfiguredAction))
+				cm := &corev1.ConfigMap{}
+				g.Expect(c.Get(context.TODO(), types.NamespacedName{
+					Namespace: namespace,
+					Name:      "test-cm",
+				}, cm)).To(Succeed())
+				g.Expect(cm.Data).To(HaveKeyWithValue("key", "value"))
+
+				var managers []string
+				for _, f := range cm.ManagedFields {
+					if f.Operation == metav1.ManagedFieldsOperationApply {
+						managers = append(managers, f.Manager)
+					}
+				}
+				g.Expect(managers).To(ConsistOf(testOwner))
+			},
+		},
+		{
+			name: "reports conflicts",
+			diffSet: func(namespace string) jsondiff.DiffSet {
+				return jsondiff.DiffSet{
+					configMapDiff(namespace, map[string]interface{}{"key": "value"}, map[string]interface{}{"key": "changed"}),
+				}
+			},
+			expect: func(g *GomegaWithT, namespace string, got *ssa.ChangeSet, err error) {
+				g.THelper()
+
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("ConfigMap/" + namespace + "/test-cm apply conflict"))
+
+				g.Expect(got).NotTo(BeNil())
+				g.Expect(got.Entries).To(BeEmpty())
+
+				cm := &corev1.ConfigMap{}
+				g.Expect(c.Get(context.TODO(), types.NamespacedName{
+					Namespace: namespace,
+					Name:      "test-cm",
+				}, cm)).To(Succeed())
+				g.Expect(cm.Data).To(HaveKeyWithValue("key", "changed"))
+			},
+		},
+		{
+			name: "does not apply ignored paths",
+			diffSet: func(namespace string) jsondiff.DiffSet {
+				return jsondiff.DiffSet{
+					configMapDiff(namespace,
+						map[string]interface{}{"key": "value", "ignored": "value"},
+						map[string]interface{}{"key": "changed", "ignored": "changed"},
+					),
+				}
+			},
+			forceConflicts: true,
+			ignore: []v2.IgnoreRule{
+				{Paths: []string{"/data/ignored"}, Target: &kustomize.Selector{Kind: "ConfigMap"}},
+			},
+			expect: func(g *GomegaWithT, namespace string, got *ssa.ChangeSet, err error) {
+				g.THelper()
+
+				g.Expect(err).NotTo(HaveOccurred())
+
+				g.Expect(got).NotTo(BeNil())
+				g.Expect(got.Entries).To(HaveLen(1))
+
+				cm := &corev1.ConfigMap{}
+				g.Expect(c.Get(context.TODO(), types.NamespacedName{
+					Namespace: namespace,
+					Name:      "test-cm",
+				}, cm)).To(Succeed())
+				g.Expect(cm.Data).To(HaveKeyWithValue("key", "value"))
+				g.Expect(cm.Data).To(HaveKeyWithValue("ignored", "changed"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			t.Cleanup(cancel)
+
+			ns, err := generateNamespace(ctx, c, "diff-action")
+			if err != nil {
+				t.Fatalf("Failed to generate namespace: %v", err)
+			}
+			t.Cleanup(func() {
+				if err := c.Delete(context.Background(), ns); client.IgnoreNotFound(err) != nil {
+					t.Logf("Failed to delete generated namespace: %v", err)
+				}
+			})
+
+			diff := tt.diffSet(ns.Name)
+
+			for _, d := range diff {
+				if d.ClusterObject != nil {
+					if err := c.Create(ctx, d.ClusterObject, client.FieldOwner(otherOwner)); err != nil {
+						t.Fatalf("Failed to create cluster object: %v", err)
+					}
+				}
+			}
+
+			got, err := ApplyDiffServerSide(context.Background(), &helmaction.Configuration{RESTClientGetter: getter}, diff,
+				testOwner, tt.forceConflicts, tt.ignore...)
+			tt.expect(g, ns.Name, got, err)
+		})
+	}
+}
+
 // newTestCluster creates a new test cluster and returns a rest.Config and a
 // function to stop the test cluster.
 func newTestCluster(t *testing.T) (*rest.Config, func() error) {
@@ -0,0 +1,309 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	helmaction "helm.sh/helm/v3/pkg/action"
+	helmkube "helm.sh/helm/v3/pkg/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/aggregator"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/clusterreader"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/collector"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/engine"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/event"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+// errUnsupportedKubeClient is returned by the healthCheckKubeClient when the
+// wrapped client does not implement an optional Helm interface method.
+var errUnsupportedKubeClient = errors.New("operation not supported by the wrapped Helm Kubernetes client")
+
+var (
+	_ helmkube.Interface                    = (*healthCheckKubeClient)(nil)
+	_ helmkube.InterfaceExt                 = (*healthCheckKubeClient)(nil)
+	_ helmkube.InterfaceDeletionPropagation = (*healthCheckKubeClient)(nil)
+	_ helmkube.InterfaceResources           = (*healthCheckKubeClient)(nil)
+)
+
+// healthCheckOptions holds the configuration of a health assessment.
+type healthCheckOptions struct {
+	// interval is the interval at which the status of the objects is polled.
+	interval time.Duration
+	// timeout is the time to wait for the objects to become healthy.
+	timeout time.Duration
+	// waitForJobs includes the Jobs of the release in the assessment.
+	waitForJobs bool
+	// exprs are the expressions which determine the health of the objects
+	// of their kinds.
+	exprs []*healthCheckExpr
+}
+
+// healthChecksEnabled returns true if the health assessment of the given
+// object replaces the Helm wait of an action for which the wait is enabled
+// according to the given disableWait.
+func healthChecksEnabled(obj *v2.HelmRelease, disableWait bool) bool {
+	return obj.HasHealthChecks() && !disableWait
+}
+
+// healthCheckKubeClient is a Helm Kubernetes client which replaces the Helm
+// wait with a health assessment of the objects. As Helm waits before it runs
+// the post-install and post-upgrade hooks, a failed assessment fails the
+// release like a failed Helm wait does, and the hooks are not run.
+//
+// The optional interfaces Helm asserts the client to implement are forwarded
+// to the wrapped client, as embedding the Interface hides them.
+type healthCheckKubeClient struct {
+	helmkube.Interface
+
+	ctx    context.Context
+	config *helmaction.Configuration
+	opts   healthCheckOptions
+	// deadline is the time at which the timeout of the action expires. The
+	// assessment never waits beyond it.
+	deadline time.Time
+}
+
+// withHealthChecks returns a copy of the given config of which the Helm wait
+// is replaced by a health assessment with the given options, and the client
+// which performs it. The deadline of the client must be set before the
+// action is run.
+func withHealthChecks(ctx context.Context, config *helmaction.Configuration,
+	opts healthCheckOptions) (*helmaction.Configuration, *healthCheckKubeClient) {
+	c := &healthCheckKubeClient{
+		Interface: config.KubeClient,
+		ctx:       ctx,
+		config:    config,
+		opts:      opts,
+	}
+	cfg := *config
+	cfg.KubeClient = c
+	return &cfg, c
+}
+
+// Wait assesses the health of the given resources, excluding Jobs.
+func (c *healthCheckKubeClient) Wait(resources helmkube.ResourceList, timeout time.Duration) error {
+	return c.wait(resources, timeout, false)
+}
+
+// WaitWithJobs assesses the health of the given resources, including Jobs.
+func (c *healthCheckKubeClient) WaitWithJobs(resources helmkube.ResourceList, timeout time.Duration) error {
+	return c.wait(resources, timeout, true)
+}
+
+// WaitForDelete waits for the given resources to be deleted, if the wrapped
+// client supports it. Otherwise, it returns immediately, like Helm does.
+func (c *healthCheckKubeClient) WaitForDelete(resources helmkube.ResourceList, timeout time.Duration) error {
+	if ext, ok := c.Interface.(helmkube.InterfaceExt); ok {
+		return ext.WaitForDelete(resources, timeout)
+	}
+	return nil
+}
+
+// DeleteWithPropagationPolicy deletes the given resources with the given
+// propagation policy, if the wrapped client supports it. Otherwise, it
+// deletes them with the default policy, like Helm does.
+func (c *healthCheckKubeClient) DeleteWithPropagationPolicy(resources helmkube.ResourceList,
+	policy metav1.DeletionPropagation) (*helmkube.Result, []error) {
+	if ext, ok := c.Interface.(helmkube.InterfaceDeletionPropagation); ok {
+		return ext.DeleteWithPropagationPolicy(resources, policy)
+	}
+	return c.Interface.Delete(resources)
+}
+
+// Get returns the details of the given resources, if the wrapped client
+// supports it.
+func (c *healthCheckKubeClient) Get(resources helmkube.ResourceList, related bool) (map[string][]runtime.Object, error) {
+	if ext, ok := c.Interface.(helmkube.InterfaceResources); ok {
+		return ext.Get(resources, related)
+	}
+	return nil, errUnsupportedKubeClient
+}
+
+// BuildTable creates a table of the resources in the given reader, if the
+// wrapped client supports it.
+func (c *healthCheckKubeClient) BuildTable(reader io.Reader, validate bool) (helmkube.ResourceList, error) {
+	if ext, ok := c.Interface.(helmkube.InterfaceResources); ok {
+		return ext.BuildTable(reader, validate)
+	}
+	return nil, errUnsupportedKubeClient
+}
+
+func (c *healthCheckKubeClient) wait(resources helmkube.ResourceList, timeout time.Duration, waitForJobs bool) error {
+	if !c.deadline.IsZero() {
+		timeout = min(timeout, max(time.Until(c.deadline), 0))
+	}
+	opts := c.opts
+	opts.timeout = timeout
+	opts.waitForJobs = waitForJobs
+	return waitForHealthy(c.ctx, c.config, healthCheckSet(resources, waitForJobs), opts)
+}
+
+// waitForHealthy waits for the objects in the given set to become healthy
+// according to kstatus, or the expressions of their kinds, using the
+// RESTClientGetter of the given config. The status of the objects is polled
+// like ssa.ResourceManager.WaitForSet does, while every change of the status
+// of an object is logged to the config.
+//
+// It returns an error listing the objects which failed, or did not become
+// healthy before the timeout.
+func waitForHealthy(ctx context.Context, config *helmaction.Configuration, set object.ObjMetadataSet,
+	opts healthCheckOptions) error {
+	if len(set) == 0 {
+		return nil
+	}
+
+	restCfg, err := config.RESTClientGetter.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	mapper, err := config.RESTClientGetter.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+	c, err := client.New(restCfg, client.Options{Mapper: mapper})
+	if err != nil {
+		return err
+	}
+
+	// Read the objects individually instead of listing all objects of their
+	// kinds, which requires the same permissions as the Helm wait.
+	poller := polling.NewStatusPoller(c, mapper, polling.Options{
+		CustomStatusReaders:  newHealthCheckExprReaders(ctx, mapper, opts.exprs),
+		ClusterReaderFactory: engine.ClusterReaderFactoryFunc(clusterreader.NewDirectClusterReader),
+	})
+	return waitForSet(ctx, poller, set, opts, config.Log)
+}
+
+// healthCheckSet returns the object metadata of the given resources. Jobs
+// are excluded unless waitForJobs is set.
+func healthCheckSet(resources helmkube.ResourceList, waitForJobs bool) object.ObjMetadataSet {
+	set := make(object.ObjMetadataSet, 0, len(resources))
+	for _, info := range resources {
+		gk := info.Mapping.GroupVersionKind.GroupKind()
+		if !waitForJobs && gk == (schema.GroupKind{Group: "batch", Kind: "Job"}) {
+			continue
+		}
+		set = append(set, object.ObjMetadata{Namespace: info.Namespace, Name: info.Name, GroupKind: gk})
+	}
+	return set
+}
+
+// waitForSet polls the status of the objects in the given set using the
+// given poller until all of them are current, any of them failed, or the
+// timeout of the given options expired. Every change of the status of an
+// object is logged using the given log.
+func waitForSet(ctx context.Context, poller *polling.StatusPoller, set object.ObjMetadataSet,
+	opts healthCheckOptions, log helmaction.DebugLog) error {
+	waitCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+
+	log("waiting for %d objects to become healthy", len(set))
+
+	statusCollector := collector.NewResourceStatusCollector(set)
+	eventsChan := poller.Poll(waitCtx, set, polling.PollOptions{PollInterval: opts.interval})
+	lastStatus := make(map[object.ObjMetadata]*event.ResourceStatus)
+
+	done := statusCollector.ListenWithObserver(eventsChan, collector.ObserverFunc(
+		func(statusCollector *collector.ResourceStatusCollector, _ event.Event) {
+			var (
+				rss         []*event.ResourceStatus
+				countFailed int
+			)
+			for _, rs := range statusCollector.ResourceStatuses {
+				if rs == nil {
+					continue
+				}
+				// Skip DeadlineExceeded errors, as kstatus emits them for
+				// every object when the timeout expires.
+				if !errors.Is(rs.Error, context.DeadlineExceeded) {
+					if last, ok := lastStatus[rs.Identifier]; !ok || last.Status != rs.Status || last.Message != rs.Message {
+						log("%s", fmtResourceStatus(rs))
+					}
+					lastStatus[rs.Identifier] = rs
+				}
+				if rs.Status == status.FailedStatus {
+					countFailed++
+				}
+				rss = append(rss, rs)
+			}
+
+			if aggregator.AggregateStatus(rss, status.CurrentStatus) == status.CurrentStatus || countFailed > 0 {
+				cancel()
+			}
+		}),
+	)
+	<-done
+
+	if statusCollector.Error != nil {
+		return statusCollector.Error
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("health check canceled: %w", err)
+	}
+
+	var errs []string
+	for id, rs := range statusCollector.ResourceStatuses {
+		switch last := lastStatus[id]; {
+		case rs == nil || last == nil:
+			errs = append(errs, fmt.Sprintf("can't determine status for %s", ssautil.FmtObjMetadata(id)))
+		case last.Status == status.FailedStatus,
+			errors.Is(waitCtx.Err(), context.DeadlineExceeded) && last.Status != status.CurrentStatus:
+			errs = append(errs, fmtResourceStatus(last))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		msg := "health check failed"
+		if errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+			msg = fmt.Sprintf("health check timed out after %s", opts.timeout.String())
+		}
+		return fmt.Errorf("%s: [%s]", msg, strings.Join(errs, ", "))
+	}
+
+	log("all %d objects are healthy", len(set))
+	return nil
+}
+
+// fmtResourceStatus returns a human-readable description of the given
+// status, e.g. "Deployment/default/app status: 'InProgress': message".
+func fmtResourceStatus(rs *event.ResourceStatus) string {
+	msg := fmt.Sprintf("%s status: '%s'", ssautil.FmtObjMetadata(rs.Identifier), rs.Status)
+	switch {
+	case rs.Error != nil:
+		msg += fmt.Sprintf(": %s", rs.Error)
+	case rs.Message != "":
+		msg += fmt.Sprintf(": %s", rs.Message)
+	}
+	return msg
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/engine"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/statusreaders"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	intcel "github.com/fluxcd/helm-controller/internal/cel"
+)
+
+// healthCheckExprVars are the variables of the CEL expressions of a
+// v2.HealthCheckExpr, which hold the top-level fields of the object.
+var healthCheckExprVars = []string{"apiVersion", "kind", "metadata", "spec", "status"}
+
+// healthCheckExpr is a compiled v2.HealthCheckExpr.
+type healthCheckExpr struct {
+	groupKind  schema.GroupKind
+	current    *intcel.Expression
+	inProgress *intcel.Expression
+	failed     *intcel.Expression
+}
+
+// ValidateHealthCheckExpr returns an error if the CEL expressions of the
+// given v2.HealthCheckExpr are invalid.
+func ValidateHealthCheckExpr(expr v2.HealthCheckExpr) error {
+	_, err := newHealthCheckExpr(expr)
+	return err
+}
+
+// newHealthCheckExpr compiles the CEL expressions of the given
+// v2.HealthCheckExpr.
+func newHealthCheckExpr(expr v2.HealthCheckExpr) (*healthCheckExpr, error) {
+	gv, err := schema.ParseGroupVersion(expr.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion '%s': %w", expr.APIVersion, err)
+	}
+	if expr.Kind == "" {
+		return nil, fmt.Errorf("kind must be specified for apiVersion '%s'", expr.APIVersion)
+	}
+
+	e := &healthCheckExpr{groupKind: schema.GroupKind{Group: gv.Group, Kind: expr.Kind}}
+	if e.current, err = intcel.NewExpression(expr.Current, healthCheckExprVars...); err != nil {
+		return nil, fmt.Errorf("invalid current expression for %s: %w", e.groupKind.String(), err)
+	}
+	if expr.InProgress != "" {
+		if e.inProgress, err = intcel.NewExpression(expr.InProgress, healthCheckExprVars...); err != nil {
+			return nil, fmt.Errorf("invalid inProgress expression for %s: %w", e.groupKind.String(), err)
+		}
+	}
+	if expr.Failed != "" {
+		if e.failed, err = intcel.NewExpression(expr.Failed, healthCheckExprVars...); err != nil {
+			return nil, fmt.Errorf("invalid failed expression for %s: %w", e.groupKind.String(), err)
+		}
+	}
+	return e, nil
+}
+
+// compileHealthCheckExprs compiles the given expressions. When multiple
+// expressions are given for the same kind, the last one takes precedence.
+func compileHealthCheckExprs(exprs []v2.HealthCheckExpr) ([]*healthCheckExpr, error) {
+	var (
+		compiled []*healthCheckExpr
+		index    = make(map[schema.GroupKind]int, len(exprs))
+	)
+	for _, expr := range exprs {
+		e, err := newHealthCheckExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[e.groupKind]; ok {
+			compiled[i] = e
+			continue
+		}
+		index[e.groupKind] = len(compiled)
+		compiled = append(compiled, e)
+	}
+	return compiled, nil
+}
+
+// evaluate computes the status of the given object using the expressions
+// within the given context.
+// The expressions are evaluated in the order InProgress, Failed and
+// Current, and the object is considered in progress when none of them
+// evaluates to true, or its status does not reflect its latest generation.
+func (e *healthCheckExpr) evaluate(ctx context.Context, obj *unstructured.Unstructured) (*status.Result, error) {
+	observedGeneration, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err == nil && found && observedGeneration != obj.GetGeneration() {
+		return &status.Result{
+			Status:  status.InProgressStatus,
+			Message: fmt.Sprintf("status of generation %d is not yet observed", obj.GetGeneration()),
+		}, nil
+	}
+
+	data := make(map[string]any, len(healthCheckExprVars))
+	for _, v := range healthCheckExprVars {
+		data[v] = map[string]any{}
+	}
+	for k, v := range obj.UnstructuredContent() {
+		data[k] = v
+	}
+	for _, check := range []struct {
+		name   string
+		expr   *intcel.Expression
+		status status.Status
+	}{
+		{name: "inProgress", expr: e.inProgress, status: status.InProgressStatus},
+		{name: "failed", expr: e.failed, status: status.FailedStatus},
+		{name: "current", expr: e.current, status: status.CurrentStatus},
+	} {
+		if check.expr == nil {
+			continue
+		}
+		ok, err := check.expr.EvaluateBoolean(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &status.Result{
+				Status:  check.status,
+				Message: fmt.Sprintf("%s expression evaluated to true", check.name),
+			}, nil
+		}
+	}
+	return &status.Result{
+		Status:  status.InProgressStatus,
+		Message: "no expression evaluated to true",
+	}, nil
+}
+
+// healthCheckExprReader is an engine.StatusReader which computes the status
+// of the objects of a kind using a healthCheckExpr.
+type healthCheckExprReader struct {
+	engine.StatusReader
+	groupKind schema.GroupKind
+}
+
+// newHealthCheckExprReaders returns the engine.StatusReader for each of the
+// given expressions, which are evaluated within the given context.
+func newHealthCheckExprReaders(ctx context.Context, mapper apimeta.RESTMapper, exprs []*healthCheckExpr) []engine.StatusReader {
+	readers := make([]engine.StatusReader, 0, len(exprs))
+	for _, e := range exprs {
+		readers = append(readers, &healthCheckExprReader{
+			StatusReader: statusreaders.NewGenericStatusReader(mapper, func(obj *unstructured.Unstructured) (*status.Result, error) {
+				return e.evaluate(ctx, obj)
+			}),
+			groupKind: e.groupKind,
+		})
+	}
+	return readers
+}
+
+// Supports returns true for the kind of the expression.
+func (r *healthCheckExprReader) Supports(gk schema.GroupKind) bool {
+	return gk == r.groupKind
+}
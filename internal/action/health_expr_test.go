@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func TestValidateHealthCheckExpr(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    v2.HealthCheckExpr
+		wantErr string
+	}{
+		{
+			name: "valid expressions",
+			expr: v2.HealthCheckExpr{
+				APIVersion: "kafka.strimzi.io/v1beta2",
+				Kind:       "Kafka",
+				Current:    "status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')",
+				Failed:     "status.phase == 'Error'",
+			},
+		},
+		{
+			name:    "invalid apiVersion",
+			expr:    v2.HealthCheckExpr{APIVersion: "a/b/c", Kind: "Kafka", Current: "true"},
+			wantErr: "invalid apiVersion 'a/b/c'",
+		},
+		{
+			name:    "invalid current expression",
+			expr:    v2.HealthCheckExpr{APIVersion: "example.com/v1", Kind: "Widget", Current: "status.phase =="},
+			wantErr: "invalid current expression for Widget.example.com",
+		},
+		{
+			name:    "undeclared variable",
+			expr:    v2.HealthCheckExpr{APIVersion: "example.com/v1", Kind: "Widget", Current: "true", InProgress: "self.ready"},
+			wantErr: "invalid inProgress expression for Widget.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := ValidateHealthCheckExpr(tt.expr)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func Test_compileHealthCheckExprs(t *testing.T) {
+	g := NewWithT(t)
+
+	got, err := compileHealthCheckExprs([]v2.HealthCheckExpr{
+		{APIVersion: "example.com/v1", Kind: "Widget", Current: "false"},
+		{APIVersion: "example.com/v1", Kind: "Gadget", Current: "true"},
+		{APIVersion: "example.com/v2", Kind: "Widget", Current: "true"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(HaveLen(2))
+	g.Expect(got[0].groupKind).To(Equal(schema.GroupKind{Group: "example.com", Kind: "Widget"}))
+	g.Expect(got[0].current.String()).To(Equal("true"))
+	g.Expect(got[1].groupKind).To(Equal(schema.GroupKind{Group: "example.com", Kind: "Gadget"}))
+
+	readers := newHealthCheckExprReaders(context.TODO(), nil, got)
+	g.Expect(readers).To(HaveLen(2))
+	g.Expect(readers[0].Supports(schema.GroupKind{Group: "example.com", Kind: "Widget"})).To(BeTrue())
+	g.Expect(readers[0].Supports(schema.GroupKind{Group: "example.com", Kind: "Gadget"})).To(BeFalse())
+}
+
+func Test_healthCheckExpr_evaluate(t *testing.T) {
+	e, err := newHealthCheckExpr(v2.HealthCheckExpr{
+		APIVersion: "example.com/v1",
+		Kind:       "Widget",
+		Current:    "status.phase == 'Ready'",
+		InProgress: "has(status.phase) && status.phase == 'Pending'",
+		Failed:     "status.phase == 'Error'",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		status map[string]any
+		want   status.Status
+	}{
+		{name: "current", status: map[string]any{"phase": "Ready"}, want: status.CurrentStatus},
+		{name: "failed", status: map[string]any{"phase": "Error"}, want: status.FailedStatus},
+		{name: "in progress", status: map[string]any{"phase": "Pending"}, want: status.InProgressStatus},
+		{name: "no match", status: map[string]any{"phase": "Unknown"}, want: status.InProgressStatus},
+		{name: "stale status", status: map[string]any{"phase": "Ready", "observedGeneration": int64(1)}, want: status.InProgressStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"metadata":   map[string]any{"name": "widget", "generation": int64(2)},
+				"status":     tt.status,
+			}}
+			got, err := e.evaluate(context.TODO(), obj)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got.Status).To(Equal(tt.want))
+		})
+	}
+
+	t.Run("object without status", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]any{"name": "widget"},
+		}}
+		_, err := e.evaluate(context.TODO(), obj)
+		g.Expect(err).To(HaveOccurred())
+	})
+}
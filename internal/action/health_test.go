@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	helmaction "helm.sh/helm/v3/pkg/action"
+	helmkube "helm.sh/helm/v3/pkg/kube"
+	helmfake "helm.sh/helm/v3/pkg/kube/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/clusterreader"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/polling/engine"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func Test_healthCheckSet(t *testing.T) {
+	info := func(gvk schema.GroupVersionKind, namespace, name string) *resource.Info {
+		return &resource.Info{
+			Name:      name,
+			Namespace: namespace,
+			Mapping:   &apimeta.RESTMapping{GroupVersionKind: gvk},
+		}
+	}
+	resources := helmkube.ResourceList{
+		info(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, "release", "config"),
+		info(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, "other", "other"),
+		info(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, "", "role"),
+		info(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, "release", "job"),
+	}
+
+	tests := []struct {
+		name        string
+		waitForJobs bool
+		want        []string
+	}{
+		{
+			name:        "includes all objects",
+			waitForJobs: true,
+			want:        []string{"ConfigMap/release/config", "ConfigMap/other/other", "ClusterRole/role", "Job/release/job"},
+		},
+		{
+			name: "excludes jobs",
+			want: []string{"ConfigMap/release/config", "ConfigMap/other/other", "ClusterRole/role"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var names []string
+			for _, id := range healthCheckSet(resources, tt.waitForJobs) {
+				names = append(names, ssautil.FmtObjMetadata(id))
+			}
+			g.Expect(names).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_healthCheckKubeClient_optionalInterfaces(t *testing.T) {
+	t.Run("forwards to the wrapped client", func(t *testing.T) {
+		g := NewWithT(t)
+
+		wrapped := &helmfake.PrintingKubeClient{Out: io.Discard}
+		cfg, _ := withHealthChecks(context.TODO(), &helmaction.Configuration{KubeClient: wrapped}, healthCheckOptions{})
+
+		c, ok := cfg.KubeClient.(helmkube.InterfaceResources)
+		g.Expect(ok).To(BeTrue())
+		res, err := c.Get(helmkube.ResourceList{}, true)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res).ToNot(BeNil())
+		_, err = c.BuildTable(strings.NewReader(""), false)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		d, ok := cfg.KubeClient.(helmkube.InterfaceDeletionPropagation)
+		g.Expect(ok).To(BeTrue())
+		_, errs := d.DeleteWithPropagationPolicy(helmkube.ResourceList{}, metav1.DeletePropagationBackground)
+		g.Expect(errs).To(BeEmpty())
+	})
+
+	t.Run("falls back when the wrapped client does not implement them", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// Hide the optional interfaces of the fake client.
+		wrapped := struct{ helmkube.Interface }{&helmfake.PrintingKubeClient{Out: io.Discard}}
+		cfg, _ := withHealthChecks(context.TODO(), &helmaction.Configuration{KubeClient: wrapped}, healthCheckOptions{})
+
+		c := cfg.KubeClient.(helmkube.InterfaceResources)
+		_, err := c.Get(helmkube.ResourceList{}, true)
+		g.Expect(err).To(MatchError(errUnsupportedKubeClient))
+
+		d := cfg.KubeClient.(helmkube.InterfaceDeletionPropagation)
+		res, errs := d.DeleteWithPropagationPolicy(helmkube.ResourceList{}, metav1.DeletePropagationBackground)
+		g.Expect(errs).To(BeEmpty())
+		g.Expect(res).ToNot(BeNil())
+
+		e := cfg.KubeClient.(helmkube.InterfaceExt)
+		g.Expect(e.WaitForDelete(helmkube.ResourceList{}, time.Second)).To(Succeed())
+	})
+}
+
+func Test_waitForSet(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "release"},
+	}
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "release", Generation: 1},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}},
+		},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 1},
+	}
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion, appsv1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), apimeta.RESTScopeNamespace)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), apimeta.RESTScopeNamespace)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("ReplicaSet"), apimeta.RESTScopeNamespace)
+	c := fake.NewClientBuilder().WithRESTMapper(mapper).WithObjects(configMap, deployment).Build()
+	poller := polling.NewStatusPoller(c, mapper, polling.Options{
+		ClusterReaderFactory: engine.ClusterReaderFactoryFunc(clusterreader.NewDirectClusterReader),
+	})
+	configMapID := object.ObjMetadata{Namespace: "release", Name: "config", GroupKind: schema.GroupKind{Kind: "ConfigMap"}}
+	deploymentID := object.ObjMetadata{Namespace: "release", Name: "app", GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"}}
+	opts := healthCheckOptions{interval: 10 * time.Millisecond, timeout: 500 * time.Millisecond}
+
+	t.Run("succeeds when objects are current", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var logs []string
+		err := waitForSet(context.TODO(), poller, object.ObjMetadataSet{
+			configMapID,
+		}, opts, func(format string, v ...interface{}) {
+			logs = append(logs, format)
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(logs).To(ContainElement("all %d objects are healthy"))
+	})
+
+	t.Run("reports objects which are not current", func(t *testing.T) {
+		g := NewWithT(t)
+
+		err := waitForSet(context.TODO(), poller, object.ObjMetadataSet{
+			configMapID,
+			deploymentID,
+		}, opts, func(string, ...interface{}) {})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(HavePrefix("health check timed out after 500ms: [Deployment/release/app status: 'InProgress'"))
+		g.Expect(err.Error()).ToNot(ContainSubstring("ConfigMap"))
+	})
+
+	t.Run("uses health check expressions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		exprs, err := compileHealthCheckExprs([]v2.HealthCheckExpr{
+			{APIVersion: "v1", Kind: "ConfigMap", Current: "false", Failed: "metadata.name == 'config'"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		poller := polling.NewStatusPoller(c, mapper, polling.Options{
+			CustomStatusReaders:  newHealthCheckExprReaders(context.TODO(), mapper, exprs),
+			ClusterReaderFactory: engine.ClusterReaderFactoryFunc(clusterreader.NewDirectClusterReader),
+		})
+
+		err = waitForSet(context.TODO(), poller, object.ObjMetadataSet{
+			configMapID,
+		}, opts, func(string, ...interface{}) {})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal("health check failed: [ConfigMap/release/config status: 'Failed': failed expression evaluated to true]"))
+	})
+
+	t.Run("returns error when context is canceled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		err := waitForSet(ctx, poller, object.ObjMetadataSet{
+			deploymentID,
+		}, opts, func(string, ...interface{}) {})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("health check canceled"))
+	})
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	helmaction "helm.sh/helm/v3/pkg/action"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apierrutil "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/pkg/ssa"
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/diff"
+)
+
+// crdGroupKind is the GroupKind of a CustomResourceDefinition, which are
+// never considered orphans as their deletion cascades to all custom
+// resources of their kind.
+var crdGroupKind = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+
+// Orphans returns the objects in the cluster which carry the origin labels of
+// the given HelmRelease, but are not part of the manifest of the given
+// release. The kinds and namespaces to search are determined from the
+// manifests of the release history in the Helm storage of the config.
+//
+// Objects of CustomResourceDefinitions, objects controlled by another object
+// and objects with drift detection disabled are never considered orphans.
+func Orphans(ctx context.Context, config *helmaction.Configuration, obj *v2.HelmRelease, rls *helmrelease.Release) ([]*unstructured.Unstructured, error) {
+	c, err := NewClient(config.RESTClientGetter, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := config.Releases.History(rls.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve release history: %w", err)
+	}
+
+	labels := originLabels(v2.GroupVersion.Group, obj.GetNamespace(), obj.GetName())
+	return findOrphans(ctx, c, c.RESTMapper(), labels, rls, history)
+}
+
+// findOrphans lists the objects with the given labels of the kinds in the
+// manifests of the given history, in the namespaces the objects of the kind
+// were released to, and returns the ones which are not part of the manifest
+// of the given release.
+func findOrphans(ctx context.Context, c client.Reader, mapper apimeta.RESTMapper, labels map[string]string,
+	rls *helmrelease.Release, history []*helmrelease.Release) ([]*unstructured.Unstructured, error) {
+	current, err := manifestObjMetadata(mapper, rls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the namespaces per kind from all the releases in the history.
+	scopes := make(map[schema.GroupKind]sets.Set[string])
+	for _, r := range append(history, rls) {
+		objects, err := ssautil.ReadObjects(strings.NewReader(r.Manifest))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read objects from manifest of release %s/%s.v%d: %w",
+				r.Namespace, r.Name, r.Version, err)
+		}
+		for _, obj := range objects {
+			gk := obj.GroupVersionKind().GroupKind()
+			if gk == crdGroupKind {
+				continue
+			}
+			if _, ok := scopes[gk]; !ok {
+				scopes[gk] = sets.New[string]()
+			}
+			ns := obj.GetNamespace()
+			if ns == "" {
+				ns = r.Namespace
+			}
+			scopes[gk].Insert(ns)
+		}
+	}
+
+	gks := make([]schema.GroupKind, 0, len(scopes))
+	for gk := range scopes {
+		gks = append(gks, gk)
+	}
+	sort.Slice(gks, func(i, j int) bool {
+		return gks[i].String() < gks[j].String()
+	})
+
+	var (
+		orphans []*unstructured.Unstructured
+		errs    []error
+	)
+	for _, gk := range gks {
+		mapping, err := mapper.RESTMapping(gk)
+		if err != nil {
+			// Kinds which are no longer served can not have any objects.
+			if apimeta.IsNoMatchError(err) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("failed to map %s: %w", gk.String(), err))
+			continue
+		}
+
+		namespaces := sets.List(scopes[gk])
+		if mapping.Scope.Name() != apimeta.RESTScopeNameNamespace {
+			namespaces = []string{""}
+		}
+		for _, ns := range namespaces {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(mapping.GroupVersionKind.GroupVersion().WithKind(gk.Kind + "List"))
+			if err := c.List(ctx, list, client.InNamespace(ns), client.MatchingLabels(labels)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to list %s: %w", gk.String(), err))
+				continue
+			}
+			for i := range list.Items {
+				item := &list.Items[i]
+				if current.Contains(object.UnstructuredToObjMetadata(item)) ||
+					item.GetAnnotations()[v2.DriftDetectionMetadataKey] == v2.DriftDetectionDisabledValue ||
+					item.GetLabels()[v2.DriftDetectionMetadataKey] == v2.DriftDetectionDisabledValue ||
+					metav1.GetControllerOf(item) != nil {
+					continue
+				}
+				orphans = append(orphans, item)
+			}
+		}
+	}
+	sort.Sort(ssa.SortableUnstructureds(orphans))
+
+	return orphans, apierrutil.Reduce(apierrutil.Flatten(apierrutil.NewAggregate(errs)))
+}
+
+// manifestObjMetadata returns the object metadata of the objects in the
+// manifest of the given release. Objects without a namespace default to the
+// namespace of the release when their kind is namespaced.
+func manifestObjMetadata(mapper apimeta.RESTMapper, rls *helmrelease.Release) (object.ObjMetadataSet, error) {
+	objects, err := ssautil.ReadObjects(strings.NewReader(rls.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objects from release manifest: %w", err)
+	}
+	set := make(object.ObjMetadataSet, 0, len(objects))
+	for _, obj := range objects {
+		if obj.GetNamespace() == "" {
+			gvk := obj.GroupVersionKind()
+			if mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil &&
+				mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+				obj.SetNamespace(rls.Namespace)
+			}
+		}
+		set = append(set, object.UnstructuredToObjMetadata(obj))
+	}
+	return set, nil
+}
+
+// PruneOrphans deletes the given orphaned objects from the Kubernetes
+// cluster, in the reverse order of which they would be applied.
+func PruneOrphans(ctx context.Context, config *helmaction.Configuration, orphans []*unstructured.Unstructured) (*ssa.ChangeSet, error) {
+	c, err := NewClient(config.RESTClientGetter, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		changeSet = ssa.NewChangeSet()
+		errs      []error
+	)
+	for i := len(orphans) - 1; i >= 0; i-- {
+		obj := orphans[i].DeepCopy()
+		if err := c.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s deletion failure: %w", diff.ResourceName(obj), err))
+			continue
+		}
+		changeSet.Add(objectToChangeSetEntry(obj, ssa.DeletedAction))
+	}
+
+	return changeSet, apierrutil.NewAggregate(errs)
+}
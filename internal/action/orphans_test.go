@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/diff"
+)
+
+func Test_findOrphans(t *testing.T) {
+	labels := originLabels(v2.GroupVersion.Group, "flux-system", "podinfo")
+	otherLabels := originLabels(v2.GroupVersion.Group, "flux-system", "other")
+
+	objects := []client.Object{
+		// Part of the current manifest.
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "release", Labels: labels}},
+		// Removed from the manifest in the current release.
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "old-config", Namespace: "release", Labels: labels}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "old-other", Namespace: "other", Labels: labels}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "old-role", Labels: labels}},
+		// Belongs to another HelmRelease.
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foreign", Namespace: "release", Labels: otherLabels}},
+		// Excluded from drift detection.
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:        "excluded",
+			Namespace:   "release",
+			Labels:      labels,
+			Annotations: map[string]string{v2.DriftDetectionMetadataKey: v2.DriftDetectionDisabledValue},
+		}},
+		// Controlled by another object.
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:      "controlled",
+			Namespace: "release",
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "Deployment", Name: "app", UID: "uid", Controller: ptr.To(true),
+			}},
+		}},
+		// Of a kind which was never part of the release.
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "release", Labels: labels}},
+	}
+
+	mapper := apimeta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion, rbacv1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), apimeta.RESTScopeNamespace)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), apimeta.RESTScopeNamespace)
+	mapper.Add(rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), apimeta.RESTScopeRoot)
+	c := fake.NewClientBuilder().WithRESTMapper(mapper).WithObjects(objects...).Build()
+
+	history := []*helmrelease.Release{
+		{
+			Name:      "podinfo",
+			Namespace: "release",
+			Version:   1,
+			Manifest: `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old-other
+  namespace: other
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: old-role
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: no-longer-served
+`,
+		},
+	}
+	rls := &helmrelease.Release{
+		Name:      "podinfo",
+		Namespace: "release",
+		Version:   2,
+		Manifest: `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`,
+	}
+
+	g := NewWithT(t)
+
+	got, err := findOrphans(context.TODO(), c, mapper, labels, rls, history)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var names []string
+	for _, obj := range got {
+		names = append(names, diff.ResourceName(obj))
+	}
+	g.Expect(names).To(Equal([]string{
+		"ClusterRole/old-role",
+		"ConfigMap/other/old-other",
+		"ConfigMap/release/old-config",
+	}))
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	helmaction "helm.sh/helm/v3/pkg/action"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/kube"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/fluxcd/pkg/ssa/jsondiff"
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/release"
+)
+
+// UpgradePlan holds the result of planning a Helm upgrade.
+type UpgradePlan struct {
+	// Release is the release as it would be created by the upgrade.
+	Release *helmrelease.Release
+	// Diff holds the differences between the manifest of the Release and
+	// the state of the cluster.
+	Diff jsondiff.DiffSet
+	// Removed holds the objects of the current release which are no longer
+	// part of the manifest of the Release, and would be deleted.
+	Removed []*unstructured.Unstructured
+}
+
+// PlanUpgrade runs the Helm upgrade action with the provided config as a
+// server-side dry-run, using the v2.HelmReleaseSpec of the given object to
+// determine the target release. When the release is not installed, the
+// Helm install action is run as a server-side dry-run instead. The rendered
+// manifest is compared to the state of the cluster using Diff, and to the
+// manifest of the current release to determine the objects which would be
+// removed.
+//
+// Contrary to Upgrade and Install, it does not apply any CRDs and does not
+// write to the Helm storage.
+func PlanUpgrade(ctx context.Context, config *helmaction.Configuration, obj *v2.HelmRelease, chrt *helmchart.Chart,
+	vals helmchartutil.Values, fieldOwner string) (*UpgradePlan, error) {
+	cur, err := config.Releases.Last(release.ShortenName(obj.GetReleaseName()))
+	if err != nil && !errors.Is(err, helmdriver.ErrReleaseNotFound) {
+		return nil, fmt.Errorf("failed to retrieve current release: %w", err)
+	}
+
+	var rls *helmrelease.Release
+	if cur == nil || cur.Info.Status == helmrelease.StatusUninstalled {
+		install := newInstall(config, obj, []InstallOption{func(install *helmaction.Install) {
+			install.DryRun = true
+			install.DryRunOption = "server"
+		}})
+		if rls, err = install.RunWithContext(ctx, chrt, vals.AsMap()); err != nil {
+			return nil, fmt.Errorf("failed to render install: %w", err)
+		}
+		// Nothing is removed by an install.
+		cur = nil
+	} else {
+		upgrade := newUpgrade(config, obj, []UpgradeOption{func(upgrade *helmaction.Upgrade) {
+			upgrade.DryRun = true
+			upgrade.DryRunOption = "server"
+		}})
+		if rls, err = upgrade.RunWithContext(ctx, release.ShortenName(obj.GetReleaseName()), chrt, vals.AsMap()); err != nil {
+			return nil, fmt.Errorf("failed to render upgrade: %w", err)
+		}
+	}
+
+	set, err := Diff(ctx, config, rls, fieldOwner)
+	if err != nil && !set.HasChanges() {
+		return nil, fmt.Errorf("failed to diff upgrade against cluster state: %w", err)
+	}
+
+	var removed []*unstructured.Unstructured
+	if cur != nil {
+		if removed, err = removedObjects(cur, rls); err != nil {
+			return nil, err
+		}
+	}
+
+	return &UpgradePlan{Release: rls, Diff: set, Removed: removed}, nil
+}
+
+// removedObjects returns the objects in the manifest of the current release
+// which are absent from the manifest of the target release. Objects with a
+// Helm resource policy to keep them are not included, as Helm does not
+// delete them.
+func removedObjects(cur, target *helmrelease.Release) ([]*unstructured.Unstructured, error) {
+	curObjects, err := ssautil.ReadObjects(strings.NewReader(cur.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objects from current release manifest: %w", err)
+	}
+	targetObjects, err := ssautil.ReadObjects(strings.NewReader(target.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objects from target release manifest: %w", err)
+	}
+
+	keep := make(map[string]struct{}, len(targetObjects))
+	for _, obj := range targetObjects {
+		keep[objectKey(obj, target.Namespace)] = struct{}{}
+	}
+
+	var removed []*unstructured.Unstructured
+	for _, obj := range curObjects {
+		if _, ok := keep[objectKey(obj, cur.Namespace)]; ok {
+			continue
+		}
+		if obj.GetAnnotations()[kube.ResourcePolicyAnno] == kube.KeepPolicy {
+			continue
+		}
+		removed = append(removed, obj)
+	}
+	return removed, nil
+}
+
+// objectKey returns a key which identifies the object by its group, kind,
+// namespace and name. The given namespace is used if the object does not
+// have a namespace set.
+func objectKey(obj *unstructured.Unstructured, namespace string) string {
+	gk := obj.GroupVersionKind().GroupKind()
+	if ns := obj.GetNamespace(); ns != "" {
+		namespace = ns
+	}
+	return strings.Join([]string{gk.String(), namespace, obj.GetName()}, "/")
+}
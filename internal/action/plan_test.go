@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+)
+
+func Test_removedObjects(t *testing.T) {
+	g := NewWithT(t)
+
+	cur := &helmrelease.Release{
+		Namespace: "release",
+		Manifest: `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kept
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: moved
+  namespace: other
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: removed
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: retained
+  annotations:
+    helm.sh/resource-policy: keep
+`,
+	}
+	target := &helmrelease.Release{
+		Namespace: "release",
+		Manifest: `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kept
+  namespace: release
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: moved
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: added
+`,
+	}
+
+	got, err := removedObjects(cur, target)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var names []string
+	for _, obj := range got {
+		names = append(names, obj.GetKind()+"/"+obj.GetName())
+	}
+	g.Expect(names).To(ConsistOf("ConfigMap/moved", "Secret/removed"))
+}
@@ -0,0 +1,358 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	helmaction "helm.sh/helm/v3/pkg/action"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+	helmkube "helm.sh/helm/v3/pkg/kube"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apierrutil "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/release"
+)
+
+// PreflightResult holds the findings of the preflight checks which do not
+// prevent the Helm action from being performed.
+type PreflightResult struct {
+	// Deprecations holds the APIs used by the rendered chart which are
+	// deprecated in the Kubernetes version of the cluster.
+	Deprecations []APIDeprecation
+}
+
+// PreflightInstall renders the Helm install action with the provided config,
+// using the v2.HelmReleaseSpec of the given object to determine the target
+// release, and performs the checks enabled in the v2.Preflight of the object
+// using the RESTClientGetter of the config:
+//
+//   - When CheckAPIs is enabled, it verifies the APIs of the rendered objects
+//     are served by the cluster, and collects the deprecated APIs.
+//   - When CheckPermissions is enabled, it verifies the impersonated user is
+//     allowed to make all the requests of the action.
+//   - When Enable is set, it performs a server-side dry-run apply of the
+//     rendered objects.
+//
+// It returns an aggregate of the errors of the first check which failed.
+// Contrary to Install, it does not apply any CRDs and does not write to the
+// Helm storage.
+func PreflightInstall(ctx context.Context, config *helmaction.Configuration, obj *v2.HelmRelease,
+	chrt *helmchart.Chart, vals helmchartutil.Values, fieldOwner string) (*PreflightResult, error) {
+	policy, err := crdPolicyOrDefault(obj.GetInstall().CRDs)
+	if err != nil {
+		return nil, err
+	}
+
+	install := newInstall(renderConfig(config), obj, []InstallOption{func(install *helmaction.Install) {
+		install.DryRun = true
+		install.DryRunOption = "server"
+	}})
+	rls, err := install.RunWithContext(ctx, chrt, vals.AsMap())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render install: %w", err)
+	}
+
+	var createdNamespaces []string
+	if install.CreateNamespace {
+		createdNamespaces = append(createdNamespaces, install.Namespace)
+	}
+	return preflight(ctx, config, obj, chrt, nil, rls, accessOptions{
+		policy:           policy,
+		hookEvents:       []helmrelease.HookEvent{helmrelease.HookPreInstall, helmrelease.HookPostInstall},
+		disableHooks:     install.DisableHooks,
+		wait:             install.Wait,
+		createNamespace:  install.CreateNamespace,
+		storageDriver:    config.Releases.Name(),
+		storageNamespace: obj.GetStorageNamespace(),
+	}, createdNamespaces, fieldOwner)
+}
+
+// PreflightUpgrade renders the Helm upgrade action with the provided config,
+// using the v2.HelmReleaseSpec of the given object to determine the target
+// release, and performs the checks enabled in the v2.Preflight of the object
+// like PreflightInstall. The permissions verified include the deletion of
+// the objects of the current release which are no longer part of the
+// target release.
+//
+// It returns an aggregate of the errors of the first check which failed.
+// Contrary to Upgrade, it does not apply any CRDs and does not write to the
+// Helm storage.
+func PreflightUpgrade(ctx context.Context, config *helmaction.Configuration, obj *v2.HelmRelease,
+	chrt *helmchart.Chart, vals helmchartutil.Values, fieldOwner string) (*PreflightResult, error) {
+	cur, err := config.Releases.Last(release.ShortenName(obj.GetReleaseName()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve current release: %w", err)
+	}
+
+	policy, err := crdPolicyOrDefault(obj.GetUpgrade().CRDs)
+	if err != nil {
+		return nil, err
+	}
+
+	upgrade := newUpgrade(renderConfig(config), obj, []UpgradeOption{func(upgrade *helmaction.Upgrade) {
+		upgrade.DryRun = true
+		upgrade.DryRunOption = "server"
+	}})
+	rls, err := upgrade.RunWithContext(ctx, release.ShortenName(obj.GetReleaseName()), chrt, vals.AsMap())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render upgrade: %w", err)
+	}
+
+	return preflight(ctx, config, obj, chrt, cur, rls, accessOptions{
+		policy:           policy,
+		hookEvents:       []helmrelease.HookEvent{helmrelease.HookPreUpgrade, helmrelease.HookPostUpgrade},
+		disableHooks:     upgrade.DisableHooks,
+		wait:             upgrade.Wait,
+		force:            upgrade.Force,
+		storageDriver:    config.Releases.Name(),
+		storageNamespace: obj.GetStorageNamespace(),
+	}, nil, fieldOwner)
+}
+
+// preflight performs the checks enabled in the v2.Preflight of the given
+// object for the target release rls, which replaces the current release cur
+// (if any). The objects in the given namespaces, which are created by the
+// action, are not required to pass the dry-run.
+func preflight(ctx context.Context, config *helmaction.Configuration, obj *v2.HelmRelease, chrt *helmchart.Chart,
+	cur, rls *helmrelease.Release, opts accessOptions, createdNamespaces []string, fieldOwner string) (*PreflightResult, error) {
+	checks := obj.GetPreflight()
+	result := &PreflightResult{}
+
+	objects, err := preflightObjects(rls, chrt, opts.policy, opts.disableHooks, opts.hookEvents...)
+	if err != nil {
+		return result, err
+	}
+
+	if checks.CheckAPIs {
+		mapper, err := config.RESTClientGetter.ToRESTMapper()
+		if err != nil {
+			return result, err
+		}
+		kubeVersion, err := serverVersion(config)
+		if err != nil {
+			return result, err
+		}
+		if result.Deprecations, err = checkAPIs(mapper, kubeVersion, objects); err != nil {
+			return result, err
+		}
+	}
+
+	if checks.CheckPermissions {
+		if err = checkAccess(ctx, config, cur, rls, chrt, opts); err != nil {
+			return result, err
+		}
+	}
+
+	if checks.Enable {
+		return result, dryRunApply(ctx, config, rls, objects, opts.policy, createdNamespaces, fieldOwner)
+	}
+	return result, nil
+}
+
+// serverVersion returns the Kubernetes version of the cluster of the
+// RESTClientGetter of the given config.
+func serverVersion(config *helmaction.Configuration) (*version.Version, error) {
+	dc, err := config.RESTClientGetter.ToDiscoveryClient()
+	if err != nil {
+		return nil, fmt.Errorf("could not get Kubernetes discovery client: %w", err)
+	}
+	info, err := dc.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("could not get server version from Kubernetes: %w", err)
+	}
+	return version.ParseGeneric(info.GitVersion)
+}
+
+// renderConfig returns a copy of the given config with a Kubernetes client
+// which does not build the objects of the rendered manifest. This allows the
+// chart to be rendered while the CRDs it depends on are not yet installed,
+// leaving the validation of the objects to the server-side dry-run.
+func renderConfig(config *helmaction.Configuration) *helmaction.Configuration {
+	c := *config
+	c.KubeClient = &renderClient{Interface: config.KubeClient}
+	return &c
+}
+
+// renderClient is a helmkube.Interface which builds an empty
+// helmkube.ResourceList from any manifest.
+type renderClient struct {
+	helmkube.Interface
+}
+
+// Build returns an empty helmkube.ResourceList.
+func (c *renderClient) Build(_ io.Reader, _ bool) (helmkube.ResourceList, error) {
+	return helmkube.ResourceList{}, nil
+}
+
+// preflightObjects returns the objects which would be applied by the Helm
+// action for the given release. This includes the CRDs of the chart unless
+// the policy is to skip them, and the hooks for the given events unless
+// hooks are disabled. Objects of kinds defined by the CRDs of the chart are
+// left out when the CRDs are applied by the action, as they can not be
+// validated against the CRDs before these are applied.
+func preflightObjects(rls *helmrelease.Release, chrt *helmchart.Chart, policy v2.CRDsPolicy, disableHooks bool,
+	events ...helmrelease.HookEvent) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	crdKinds := make(map[schema.GroupKind]struct{})
+	if policy != v2.Skip {
+		for _, crd := range chrt.CRDObjects() {
+			crdObjects, err := ssautil.ReadObjects(bytes.NewReader(crd.File.Data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CustomResourceDefinitions from %s: %w", crd.Name, err)
+			}
+			for _, obj := range crdObjects {
+				group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+				kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+				crdKinds[schema.GroupKind{Group: group, Kind: kind}] = struct{}{}
+			}
+			objects = append(objects, crdObjects...)
+		}
+	}
+
+	manifests := []string{rls.Manifest}
+	if !disableHooks {
+		for _, hook := range rls.Hooks {
+			if hasHookEvent(hook, events...) {
+				manifests = append(manifests, hook.Manifest)
+			}
+		}
+	}
+	for _, manifest := range manifests {
+		manifestObjects, err := ssautil.ReadObjects(strings.NewReader(manifest))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read objects from release manifest: %w", err)
+		}
+		for _, obj := range manifestObjects {
+			if _, ok := crdKinds[obj.GroupVersionKind().GroupKind()]; ok {
+				continue
+			}
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// hasHookEvent returns true if the given hook is executed on any of the given
+// events.
+func hasHookEvent(hook *helmrelease.Hook, events ...helmrelease.HookEvent) bool {
+	for _, e := range hook.Events {
+		for _, event := range events {
+			if e == event {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dryRunApply performs a server-side dry-run apply of the given objects with
+// the RESTClientGetter of the given config. CRDs which would only be created
+// by the CRD policy are dry-run created instead. Objects in the given
+// namespaces, or in namespaces which are part of the objects, are allowed
+// to fail because their namespace does not exist yet.
+func dryRunApply(ctx context.Context, config *helmaction.Configuration, rls *helmrelease.Release,
+	objects []*unstructured.Unstructured, policy v2.CRDsPolicy, createdNamespaces []string, fieldOwner string) error {
+	c, err := NewClient(config.RESTClientGetter, client.Options{DryRun: ptr.To(true)})
+	if err != nil {
+		return err
+	}
+
+	created := make(map[string]struct{}, len(createdNamespaces))
+	for _, ns := range createdNamespaces {
+		created[ns] = struct{}{}
+	}
+	for _, obj := range objects {
+		if obj.GroupVersionKind().GroupKind() == (schema.GroupKind{Kind: "Namespace"}) {
+			created[obj.GetName()] = struct{}{}
+		}
+	}
+
+	var (
+		isNamespacedGVK = map[string]bool{}
+		errs            []error
+	)
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		isCRD := gvk.GroupKind() == apiextensionsv1.Kind("CustomResourceDefinition")
+		if !isCRD {
+			setHelmMetadata(obj, rls)
+		}
+
+		if obj.GetNamespace() == "" {
+			if _, ok := isNamespacedGVK[gvk.String()]; !ok {
+				namespaced, err := apiutil.IsObjectNamespaced(obj, c.Scheme(), c.RESTMapper())
+				if err != nil {
+					if apimeta.IsNoMatchError(err) {
+						err = fmt.Errorf("%s is not served by the cluster", gvk.String())
+					}
+					errs = append(errs, fmt.Errorf("%s dry-run failed: %w", ssautil.FmtUnstructured(obj), err))
+					continue
+				}
+				isNamespacedGVK[gvk.String()] = namespaced
+			}
+			if isNamespacedGVK[gvk.String()] {
+				obj.SetNamespace(rls.Namespace)
+			}
+		}
+
+		if isCRD && policy == v2.Create {
+			err = c.Create(ctx, obj, client.FieldOwner(fieldOwner))
+			if apierrors.IsAlreadyExists(err) {
+				err = nil
+			}
+		} else {
+			err = c.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership)
+		}
+		if err != nil {
+			if _, ok := created[obj.GetNamespace()]; ok && isNamespaceNotFound(err, obj.GetNamespace()) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s dry-run failed: %w", ssautil.FmtUnstructured(obj), maskSensitiveErrData(err)))
+		}
+	}
+	return apierrutil.Reduce(apierrutil.Flatten(apierrutil.NewAggregate(errs)))
+}
+
+// isNamespaceNotFound returns true if the given error is a NotFound error for
+// the namespace with the given name.
+func isNamespaceNotFound(err error, namespace string) bool {
+	var status apierrors.APIStatus
+	if !apierrors.IsNotFound(err) || !errors.As(err, &status) {
+		return false
+	}
+	details := status.Status().Details
+	return details != nil && details.Kind == "namespaces" && details.Name == namespace
+}
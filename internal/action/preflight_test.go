@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package action
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func Test_preflightObjects(t *testing.T) {
+	chrt := &helmchart.Chart{
+		Files: []*helmchart.File{
+			{
+				Name: "crds/widgets.yaml",
+				Data: []byte(`---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+`),
+			},
+		},
+	}
+	rls := &helmrelease.Release{
+		Namespace: "release",
+		Manifest: `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+`,
+		Hooks: []*helmrelease.Hook{
+			{
+				Manifest: `---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+`,
+				Events: []helmrelease.HookEvent{helmrelease.HookPreInstall, helmrelease.HookPreUpgrade},
+			},
+			{
+				Manifest: `---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+`,
+				Events: []helmrelease.HookEvent{helmrelease.HookTest},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		policy       v2.CRDsPolicy
+		disableHooks bool
+		events       []helmrelease.HookEvent
+		want         []string
+	}{
+		{
+			name:   "includes CRDs and hooks for events",
+			policy: v2.Create,
+			events: []helmrelease.HookEvent{helmrelease.HookPreUpgrade, helmrelease.HookPostUpgrade},
+			want:   []string{"CustomResourceDefinition/widgets.example.com", "ConfigMap/config", "Job/migrate"},
+		},
+		{
+			name:         "excludes hooks when disabled",
+			policy:       v2.CreateReplace,
+			disableHooks: true,
+			events:       []helmrelease.HookEvent{helmrelease.HookPreInstall, helmrelease.HookPostInstall},
+			want:         []string{"CustomResourceDefinition/widgets.example.com", "ConfigMap/config"},
+		},
+		{
+			name:   "includes custom resources when CRDs are skipped",
+			policy: v2.Skip,
+			events: []helmrelease.HookEvent{helmrelease.HookPostInstall},
+			want:   []string{"ConfigMap/config", "Widget/widget"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := preflightObjects(rls, chrt, tt.policy, tt.disableHooks, tt.events...)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var names []string
+			for _, obj := range got {
+				names = append(names, obj.GetKind()+"/"+obj.GetName())
+			}
+			g.Expect(names).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_isNamespaceNotFound(t *testing.T) {
+	g := NewWithT(t)
+
+	err := apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "release")
+	g.Expect(isNamespaceNotFound(err, "release")).To(BeTrue())
+	g.Expect(isNamespaceNotFound(err, "other")).To(BeFalse())
+
+	err = apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "release")
+	g.Expect(isNamespaceNotFound(err, "release")).To(BeFalse())
+	g.Expect(isNamespaceNotFound(errors.New("namespaces \"release\" not found"), "release")).To(BeFalse())
+}
@@ -22,13 +22,15 @@ import (
 	"helm.sh/helm/v3/pkg/chartutil"
 
 	v2 "github.com/fluxcd/helm-controller/api/v2"
-	intchartutil "github.com/fluxcd/pkg/chartutil"
+	intdefaults "github.com/fluxcd/helm-controller/internal/defaults"
+	intvalues "github.com/fluxcd/helm-controller/internal/values"
 )
 
 const (
 	differentGenerationReason = "generation differs from last attempt"
 	differentRevisionReason   = "chart version differs from last attempt"
 	differentValuesReason     = "values differ from last attempt"
+	differentConfigReason     = "config differs from last attempt"
 	resetRequestedReason      = "reset requested through annotation"
 )
 
@@ -36,9 +38,11 @@ const (
 // indicates that the HelmRelease failure counters must be reset.
 // This is the case if the data used to make the last (failed) attempt has
 // changed in a way that indicates that a new attempt should be made.
-// For example, a change in generation, chart version, or values.
+// For example, a change in generation, chart version, values, merge
+// strategies of the values references, or the HelmReleaseDefaults applied to
+// the spec.
 // If no change is detected, an empty string is returned along with false.
-func MustResetFailures(obj *v2.HelmRelease, chart *chart.Metadata, values chartutil.Values) (string, bool) {
+func MustResetFailures(obj *v2.HelmRelease, chart *chart.Metadata, values chartutil.Values, defaults []v2.HelmReleaseDefaults) (string, bool) {
 	// Always check if a reset is requested.
 	// This is done first, so that the HelmReleaseStatus.LastHandledResetAt
 	// field is updated even if the reset request is not handled due to other
@@ -56,7 +60,11 @@ func MustResetFailures(obj *v2.HelmRelease, chart *chart.Metadata, values chartu
 			// TODO: remove this when the deprecated field is removed.
 			d = "sha1:" + obj.Status.LastAttemptedValuesChecksum
 		}
-		if ok := intchartutil.VerifyValues(digest.Digest(d), values); !ok {
+		if ok := intdefaults.VerifyConfig(digest.Digest(d), values, obj.Spec.ValuesFrom, defaults); !ok {
+			if len(defaults) > 0 || len(obj.Status.AppliedDefaults) > 0 ||
+				intvalues.Digest(digest.Canonical, obj.Spec.ValuesFrom) != "" {
+				return differentConfigReason, true
+			}
 			return differentValuesReason, true
 		}
 	}
@@ -23,6 +23,7 @@ import (
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chartutil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 
 	"github.com/fluxcd/pkg/apis/meta"
 
@@ -35,6 +36,7 @@ func TestMustResetFailures(t *testing.T) {
 		obj        *v2.HelmRelease
 		chart      *chart.Metadata
 		values     chartutil.Values
+		defaults   []v2.HelmReleaseDefaults
 		want       bool
 		wantReason string
 	}{
@@ -89,6 +91,63 @@ func TestMustResetFailures(t *testing.T) {
 			want:       true,
 			wantReason: differentValuesReason,
 		},
+		{
+			name: "on defaults change",
+			obj: &v2.HelmRelease{
+				ObjectMeta: metav1.ObjectMeta{
+					Generation: 1,
+				},
+				Status: v2.HelmReleaseStatus{
+					LastAttemptedGeneration:   1,
+					LastAttemptedRevision:     "1.0.0",
+					LastAttemptedConfigDigest: "sha256:1dabc4e3cbbd6a0818bd460f3a6c9855bfe95d506c74726bc0f2edb0aecb1f4e",
+				},
+			},
+			chart: &chart.Metadata{
+				Version: "1.0.0",
+			},
+			values: chartutil.Values{
+				"foo": "bar",
+			},
+			defaults: []v2.HelmReleaseDefaults{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "global"},
+					Spec: v2.HelmReleaseDefaultsSpec{
+						Upgrade: &v2.UpgradeDefaults{
+							Remediation: &v2.UpgradeRemediationDefaults{Retries: ptr.To(3)},
+						},
+					},
+				},
+			},
+			want:       true,
+			wantReason: differentConfigReason,
+		},
+		{
+			name: "on merge strategies change",
+			obj: &v2.HelmRelease{
+				ObjectMeta: metav1.ObjectMeta{
+					Generation: 1,
+				},
+				Spec: v2.HelmReleaseSpec{
+					ValuesFrom: []v2.ValuesReference{
+						{Kind: "ConfigMap", Name: "values", MergeStrategy: v2.ValuesMergeStrategyAppendLists},
+					},
+				},
+				Status: v2.HelmReleaseStatus{
+					LastAttemptedGeneration:   1,
+					LastAttemptedRevision:     "1.0.0",
+					LastAttemptedConfigDigest: "sha256:1dabc4e3cbbd6a0818bd460f3a6c9855bfe95d506c74726bc0f2edb0aecb1f4e",
+				},
+			},
+			chart: &chart.Metadata{
+				Version: "1.0.0",
+			},
+			values: chartutil.Values{
+				"foo": "bar",
+			},
+			want:       true,
+			wantReason: differentConfigReason,
+		},
 		{
 			name: "on (deprecated) values checksum change",
 			obj: &v2.HelmRelease{
@@ -160,7 +219,7 @@ func TestMustResetFailures(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
-			reason, got := MustResetFailures(tt.obj, tt.chart, tt.values)
+			reason, got := MustResetFailures(tt.obj, tt.chart, tt.values, tt.defaults)
 			g.Expect(got).To(Equal(tt.want))
 			g.Expect(reason).To(Equal(tt.wantReason))
 		})
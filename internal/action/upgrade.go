@@ -19,6 +19,7 @@ package action
 import (
 	"context"
 	"fmt"
+	"time"
 
 	helmaction "helm.sh/helm/v3/pkg/action"
 	helmchart "helm.sh/helm/v3/pkg/chart"
@@ -43,12 +44,30 @@ type UpgradeOption func(upgrade *helmaction.Upgrade)
 // It performs the upgrade according to the spec, which includes upgrading the
 // CRDs according to the defined policy.
 //
+// When health checks are enabled, the Helm wait is replaced by a kstatus-based
+// health assessment of the objects of the release, which is performed before
+// the post-upgrade hooks are run. When the assessment fails, the release is
+// marked as failed like it is when the Helm wait fails.
+//
 // It does not determine if there is a desire to perform the action, this is
 // expected to be done by the caller. In addition, it does not take note of the
 // action result. The caller is expected to listen to this using a
 // storage.ObserveFunc, which provides superior access to Helm storage writes.
 func Upgrade(ctx context.Context, config *helmaction.Configuration, obj *v2.HelmRelease, chrt *helmchart.Chart,
 	vals helmchartutil.Values, opts ...UpgradeOption) (*helmrelease.Release, error) {
+	exprs, err := compileHealthCheckExprs(obj.Spec.HealthCheckExprs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the Helm wait with the health assessment of the objects.
+	var healthChecks *healthCheckKubeClient
+	if healthChecksEnabled(obj, obj.GetUpgrade().DisableWait) {
+		config, healthChecks = withHealthChecks(ctx, config, healthCheckOptions{
+			interval: obj.GetHealthChecks().GetInterval().Duration,
+			exprs:    exprs,
+		})
+	}
 	upgrade := newUpgrade(config, obj, opts)
 
 	policy, err := crdPolicyOrDefault(obj.GetUpgrade().CRDs)
@@ -59,6 +78,9 @@ func Upgrade(ctx context.Context, config *helmaction.Configuration, obj *v2.Helm
 		return nil, fmt.Errorf("failed to apply CustomResourceDefinitions: %w", err)
 	}
 
+	if healthChecks != nil {
+		healthChecks.deadline = time.Now().Add(upgrade.Timeout)
+	}
 	return upgrade.RunWithContext(ctx, release.ShortenName(obj.GetReleaseName()), chrt, vals.AsMap())
 }
 
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+)
+
+// Expression is a compiled CEL expression which evaluates to a boolean.
+type Expression struct {
+	expr string
+	prog cel.Program
+}
+
+// NewExpression compiles the given CEL expression, declaring the given
+// variable names as dynamically typed variables. It returns an error if the
+// expression is invalid, or does not evaluate to a boolean.
+func NewExpression(expr string, vars ...string) (*Expression, error) {
+	opts := []cel.EnvOption{
+		cel.HomogeneousAggregateLiterals(),
+		cel.EagerlyValidateDeclarations(true),
+		cel.DefaultUTCTimeZone(true),
+		ext.Strings(),
+	}
+	for _, v := range vars {
+		opts = append(opts, cel.Variable(v, cel.DynType))
+	}
+
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to compile CEL expression '%s': %w", expr, issues.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("CEL expression '%s' must evaluate to a boolean, got %s", expr, t)
+	}
+
+	prog, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create program for CEL expression '%s': %w", expr, err)
+	}
+
+	return &Expression{expr: expr, prog: prog}, nil
+}
+
+// EvaluateBoolean evaluates the expression against the given data, which
+// maps variable names to their values. It returns an error if the evaluation
+// fails, or the result is not a boolean.
+func (e *Expression) EvaluateBoolean(ctx context.Context, data map[string]any) (bool, error) {
+	val, _, err := e.prog.ContextEval(ctx, data)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate CEL expression '%s': %w", e.expr, err)
+	}
+
+	result, ok := val.(types.Bool)
+	if !ok {
+		return false, fmt.Errorf("CEL expression '%s' must evaluate to a boolean, got %s", e.expr, val.Type())
+	}
+	return bool(result), nil
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.expr
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		vars    []string
+		wantErr string
+	}{
+		{name: "valid expression", expr: "dep.status.ready == true", vars: []string{"dep"}},
+		{name: "undeclared variable", expr: "foo.bar", vars: []string{"dep"}, wantErr: "undeclared reference to 'foo'"},
+		{name: "syntax error", expr: "dep.status.ready ==", vars: []string{"dep"}, wantErr: "failed to compile CEL expression"},
+		{name: "non-boolean result", expr: "'foo'", wantErr: "must evaluate to a boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := NewExpression(tt.expr, tt.vars...)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestExpression_EvaluateBoolean(t *testing.T) {
+	data := map[string]any{
+		"dep": map[string]any{
+			"metadata": map[string]any{"generation": int64(2)},
+			"status": map[string]any{
+				"observedGeneration": int64(2),
+				"conditions": []any{
+					map[string]any{"type": "Ready", "status": "True"},
+				},
+			},
+		},
+		"self": map[string]any{
+			"metadata": map[string]any{"name": "app"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    bool
+		wantErr string
+	}{
+		{
+			name: "true",
+			expr: "dep.metadata.generation == dep.status.observedGeneration && dep.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')",
+			want: true,
+		},
+		{
+			name: "false",
+			expr: "dep.status.conditions.exists(c, c.type == 'Stalled')",
+			want: false,
+		},
+		{
+			name: "access to self",
+			expr: "self.metadata.name.startsWith('app')",
+			want: true,
+		},
+		{
+			name:    "missing field",
+			expr:    "dep.status.missing == true",
+			wantErr: "no such key: missing",
+		},
+		{
+			name:    "non-boolean result",
+			expr:    "dep.metadata.generation",
+			wantErr: "must evaluate to a boolean",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			expr, err := NewExpression(tt.expr, "dep", "self")
+			g.Expect(err).ToNot(HaveOccurred())
+
+			got, err := expr.EvaluateBoolean(context.TODO(), data)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
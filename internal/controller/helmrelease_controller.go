@@ -18,15 +18,22 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"helm.sh/helm/v3/pkg/chart"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
 	corev1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	apierrutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -36,14 +43,18 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/Masterminds/semver"
+	kstatus "github.com/fluxcd/cli-utils/pkg/kstatus/status"
 	aclv1 "github.com/fluxcd/pkg/apis/acl"
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/runtime/acl"
@@ -58,30 +69,41 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 
-	"github.com/fluxcd/pkg/chartutil"
-
 	v2 "github.com/fluxcd/helm-controller/api/v2"
 	intacl "github.com/fluxcd/helm-controller/internal/acl"
 	"github.com/fluxcd/helm-controller/internal/action"
+	intcel "github.com/fluxcd/helm-controller/internal/cel"
+	"github.com/fluxcd/helm-controller/internal/decryptor"
+	intdefaults "github.com/fluxcd/helm-controller/internal/defaults"
+	"github.com/fluxcd/helm-controller/internal/dependency"
 	"github.com/fluxcd/helm-controller/internal/digest"
 	interrors "github.com/fluxcd/helm-controller/internal/errors"
 	"github.com/fluxcd/helm-controller/internal/features"
 	"github.com/fluxcd/helm-controller/internal/kube"
 	"github.com/fluxcd/helm-controller/internal/loader"
+	"github.com/fluxcd/helm-controller/internal/postbuild"
 	"github.com/fluxcd/helm-controller/internal/postrender"
 	intpredicates "github.com/fluxcd/helm-controller/internal/predicates"
 	intreconcile "github.com/fluxcd/helm-controller/internal/reconcile"
 	"github.com/fluxcd/helm-controller/internal/release"
+	"github.com/fluxcd/helm-controller/internal/schedule"
+	intvalues "github.com/fluxcd/helm-controller/internal/values"
 )
 
 // +kubebuilder:rbac:groups=helm.toolkit.fluxcd.io,resources=helmreleases,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=helm.toolkit.fluxcd.io,resources=helmreleases/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=helm.toolkit.fluxcd.io,resources=helmreleases/finalizers,verbs=get;create;update;patch;delete
+// +kubebuilder:rbac:groups=helm.toolkit.fluxcd.io,resources=helmreleasedefaults,verbs=get;list;watch
+// +kubebuilder:rbac:groups=helm.toolkit.fluxcd.io,resources=helmreleasenamespacedefaults,verbs=get;list;watch
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmcharts,verbs=get;list;watch
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmcharts/status,verbs=get
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=ocirepositories,verbs=get;list;watch
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=ocirepositories/status,verbs=get
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=gitrepositories;buckets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=gitrepositories/status;buckets/status,verbs=get
+// +kubebuilder:rbac:groups="",resources=configmaps;secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
 
 // HelmReleaseReconciler reconciles a HelmRelease object.
 type HelmReleaseReconciler struct {
@@ -97,8 +119,35 @@ type HelmReleaseReconciler struct {
 	FieldManager          string
 	DefaultServiceAccount string
 
+	// FreezeWindows are cluster-wide deny windows in which no changes are
+	// made to Helm releases.
+	FreezeWindows []schedule.Window
+	// FreezeConfigMap is the reference to an optional ConfigMap holding
+	// additional cluster-wide deny windows.
+	FreezeConfigMap *types.NamespacedName
+	// FreezeConfigMapReader reads the FreezeConfigMap, typically from a
+	// cache dedicated to it. Defaults to the Client when nil.
+	FreezeConfigMapReader client.Reader
+
 	requeueDependency    time.Duration
 	artifactFetchRetries int
+
+	controller        controller.Controller
+	cache             cache.Cache
+	dependencyKinds   map[schema.GroupKind]struct{}
+	dependencyKindsMu sync.Mutex
+
+	// configDigests holds the digests of the data of the ConfigMaps and
+	// Secrets referenced by HelmReleases, as last observed by their watch.
+	configDigests   map[string]string
+	configDigestsMu sync.Mutex
+
+	// explicitSpecs holds the fields of the spec of HelmReleases which are
+	// explicitly set, as read from the API server for a generation of the
+	// object. It is used to merge defaults without reading the object from
+	// the API server on every reconciliation.
+	explicitSpecs   map[types.NamespacedName]explicitSpec
+	explicitSpecsMu sync.Mutex
 }
 
 type HelmReleaseReconcilerOptions struct {
@@ -110,6 +159,8 @@ type HelmReleaseReconcilerOptions struct {
 var (
 	errWaitForDependency = errors.New("must wait for dependency")
 	errWaitForChart      = errors.New("must wait for chart")
+	errWaitForWindow     = errors.New("must wait for maintenance window")
+	errInvalidReadyExpr  = errors.New("invalid readyExpr")
 )
 
 func (r *HelmReleaseReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, opts HelmReleaseReconcilerOptions) error {
@@ -129,13 +180,43 @@ func (r *HelmReleaseReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 		return err
 	}
 
+	// Index the HelmRelease by the ConfigMaps they reference.
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &v2.HelmRelease{}, v2.ConfigMapIndexKey, indexConfigMaps); err != nil {
+		return err
+	}
+
+	// Index the HelmRelease by the Secrets they reference.
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &v2.HelmRelease{}, v2.SecretIndexKey, indexSecrets); err != nil {
+		return err
+	}
+
+	// Index the HelmRelease by the sources they reference values from.
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &v2.HelmRelease{}, v2.ValuesSourceIndexKey, indexValuesSources); err != nil {
+		return err
+	}
+
+	// Index the HelmRelease by the HelmReleases they depend on.
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &v2.HelmRelease{}, v2.DependsOnIndexKey, indexDependsOn); err != nil {
+		return err
+	}
+
 	r.requeueDependency = opts.DependencyRequeueInterval
 	r.artifactFetchRetries = opts.HTTPRetry
 
-	return ctrl.NewControllerManagedBy(mgr).
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&v2.HelmRelease{}, builder.WithPredicates(
 			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
 		)).
+		Watches(
+			&v2.HelmRelease{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForDependencyChange),
+			builder.WithPredicates(intpredicates.HelmReleaseReadyPredicate{}),
+		).
+		Watches(
+			&v2.HelmRelease{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForDependantDeletion),
+			builder.WithPredicates(intpredicates.HelmReleaseDeletedPredicate{}),
+		).
 		Watches(
 			&sourcev1.HelmChart{},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForHelmChartChange),
@@ -146,10 +227,56 @@ func (r *HelmReleaseReconciler) SetupWithManager(ctx context.Context, mgr ctrl.M
 			handler.EnqueueRequestsFromMapFunc(r.requestsForOCIRrepositoryChange),
 			builder.WithPredicates(intpredicates.SourceRevisionChangePredicate{}),
 		).
+		Watches(
+			&sourcev1.GitRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForValuesSourceChange(sourcev1.GitRepositoryKind)),
+			builder.WithPredicates(intpredicates.SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&sourcev1.Bucket{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForValuesSourceChange(sourcev1.BucketKind)),
+			builder.WithPredicates(intpredicates.SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&sourcev1beta2.OCIRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForValuesSourceChange(sourcev1beta2.OCIRepositoryKind)),
+			builder.WithPredicates(intpredicates.SourceRevisionChangePredicate{}),
+		).
+		Watches(
+			&v2.HelmReleaseDefaults{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForDefaultsChange),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&v2.HelmReleaseNamespaceDefaults{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForNamespaceDefaultsChange),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		// Only watch the metadata of ConfigMaps and Secrets, to not cache
+		// every ConfigMap and Secret in the cluster.
+		WatchesMetadata(
+			&corev1.ConfigMap{},
+			r.configChangeHandler(v2.ConfigMapIndexKey, func() client.Object { return &corev1.ConfigMap{} }),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		WatchesMetadata(
+			&corev1.Secret{},
+			r.configChangeHandler(v2.SecretIndexKey, func() client.Object { return &corev1.Secret{} }),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		WithOptions(controller.Options{
 			RateLimiter: opts.RateLimiter,
 		}).
-		Complete(r)
+		Build(r)
+	if err != nil {
+		return err
+	}
+
+	// Keep a reference to the controller and cache to dynamically watch the
+	// kinds of dependencies which are not HelmReleases.
+	r.controller = c
+	r.cache = mgr.GetCache()
+	return nil
 }
 
 func (r *HelmReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
@@ -159,6 +286,9 @@ func (r *HelmReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	// Fetch the HelmRelease
 	obj := &v2.HelmRelease{}
 	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forgetExplicitSpec(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
@@ -166,6 +296,14 @@ func (r *HelmReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, reconcile.TerminalError(fmt.Errorf("invalid Chart reference"))
 	}
 
+	// Merge the HelmReleaseDefaults into the in-memory spec. This is done
+	// before the patch helper is initialized, so that the defaults are never
+	// written to the spec of the object in the cluster.
+	defaults, err := r.applyDefaults(ctx, obj)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Initialize the patch helper with the current version of the object.
 	patchHelper := patch.NewSerialPatcher(obj, r.Client)
 
@@ -189,7 +327,7 @@ func (r *HelmReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		// However, not returning an error will cause the patch helper to
 		// patch the observed generation, which we do not want. So we ignore
 		// these errors here after patching.
-		retErr = interrors.Ignore(retErr, errWaitForDependency, errWaitForChart)
+		retErr = interrors.Ignore(retErr, errWaitForDependency, errWaitForChart, errWaitForWindow)
 
 		if err := patchHelper.Patch(ctx, obj, patchOpts...); err != nil {
 			if !obj.DeletionTimestamp.IsZero() {
@@ -235,10 +373,11 @@ func (r *HelmReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	return r.reconcileRelease(ctx, patchHelper, obj)
+	return r.reconcileRelease(ctx, patchHelper, obj, defaults)
 }
 
-func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelper *patch.SerialPatcher, obj *v2.HelmRelease) (ctrl.Result, error) {
+func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelper *patch.SerialPatcher, obj *v2.HelmRelease,
+	defaults []v2.HelmReleaseDefaults) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 
 	// Mark the resource as under reconciliation.
@@ -247,26 +386,86 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelpe
 		return ctrl.Result{}, err
 	}
 
-	// Confirm dependencies are Ready before proceeding.
+	// Resolve the dependency graph and confirm dependencies are Ready before
+	// proceeding.
+	obj.Status.DependencyOrder = nil
 	if c := len(obj.Spec.DependsOn); c > 0 {
 		log.Info(fmt.Sprintf("checking %d dependencies", c))
 
+		order, err := r.resolveDependencies(ctx, obj)
+		obj.Status.DependencyOrder = order
+		if err != nil {
+			var cycleErr *dependency.CycleError
+			if errors.As(err, &cycleErr) {
+				conditions.MarkStalled(obj, v2.DependencyCycleReason, "%s", err)
+				conditions.MarkFalse(obj, meta.ReadyCondition, v2.DependencyCycleReason, "%s", err)
+				conditions.Delete(obj, meta.ReconcilingCondition)
+				r.Eventf(obj, corev1.EventTypeWarning, v2.DependencyCycleReason, err.Error())
+				log.Error(err, "unable to resolve dependencies")
+
+				// The cycle can be broken by a change to any of the objects
+				// in the cycle, which does not necessarily trigger a
+				// reconciliation of this object. Retry on the configured
+				// interval instead of giving up.
+				return jitter.JitteredRequeueInterval(ctrl.Result{RequeueAfter: obj.GetRequeueAfter()}), nil
+			}
+
+			var missingErr *dependency.MissingError
+			if !errors.As(err, &missingErr) {
+				conditions.MarkFalse(obj, meta.ReadyCondition, v2.DependencyNotReadyReason, "unable to resolve dependencies: %s", err)
+				return ctrl.Result{}, err
+			}
+
+			msg := fmt.Sprintf("dependencies do not meet ready condition (%s): retrying in %s",
+				err.Error(), r.requeueDependency.String())
+			conditions.MarkFalse(obj, meta.ReadyCondition, v2.DependencyNotReadyReason, "%s", err)
+			r.Eventf(obj, corev1.EventTypeNormal, v2.DependencyNotReadyReason, err.Error())
+			log.Info(msg)
+
+			// The creation of the missing dependencies results in them
+			// becoming Ready eventually, which triggers a reconciliation
+			// through the watch on the dependencies.
+			return ctrl.Result{RequeueAfter: r.requeueDependency}, errWaitForDependency
+		}
+
 		if err := r.checkDependencies(ctx, obj); err != nil {
+			if acl.IsAccessDenied(err) || errors.Is(err, errInvalidReadyExpr) {
+				reason := aclv1.AccessDeniedReason
+				if errors.Is(err, errInvalidReadyExpr) {
+					reason = v2.InvalidCELExpressionReason
+				}
+				conditions.MarkStalled(obj, reason, "%s", err)
+				conditions.MarkFalse(obj, meta.ReadyCondition, reason, "%s", err)
+				conditions.Delete(obj, meta.ReconcilingCondition)
+				r.Eventf(obj, corev1.EventTypeWarning, reason, err.Error())
+
+				// Recovering from this is not possible without a restart of
+				// the controller or a change of spec, both triggering a new
+				// reconciliation.
+				return ctrl.Result{}, reconcile.TerminalError(err)
+			}
+
 			msg := fmt.Sprintf("dependencies do not meet ready condition (%s): retrying in %s",
 				err.Error(), r.requeueDependency.String())
 			conditions.MarkFalse(obj, meta.ReadyCondition, v2.DependencyNotReadyReason, "%s", err)
 			r.Eventf(obj, corev1.EventTypeNormal, v2.DependencyNotReadyReason, err.Error())
 			log.Info(msg)
 
-			// Exponential backoff would cause execution to be prolonged too much,
-			// instead we requeue on a fixed interval.
+			// The watch on the dependencies triggers a reconciliation as soon
+			// as they become Ready. Exponential backoff would cause execution
+			// to be prolonged too much, instead we requeue on a fixed interval
+			// as a safety net.
 			return ctrl.Result{RequeueAfter: r.requeueDependency}, errWaitForDependency
 		}
 
 		log.Info("all dependencies are ready")
 	}
+	// Remove any stale Stalled condition caused by the dependencies.
+	if conditions.HasAnyReason(obj, meta.StalledCondition, v2.DependencyCycleReason, v2.InvalidCELExpressionReason) {
+		conditions.Delete(obj, meta.StalledCondition)
+	}
 	// Remove any stale corresponding Ready=False condition with Unknown.
-	if conditions.HasAnyReason(obj, meta.ReadyCondition, v2.DependencyNotReadyReason) {
+	if conditions.HasAnyReason(obj, meta.ReadyCondition, v2.DependencyNotReadyReason, v2.DependencyCycleReason, v2.InvalidCELExpressionReason) {
 		conditions.MarkUnknown(obj, meta.ReadyCondition, meta.ProgressingReason, "reconciliation in progress")
 	}
 
@@ -308,12 +507,7 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelpe
 	}
 
 	// Compose values based from the spec and references.
-	values, err := chartutil.ChartValuesFromReferences(ctx,
-		log,
-		r.Client,
-		obj.Namespace,
-		obj.GetValues(),
-		obj.Spec.ValuesFrom...)
+	values, err := r.composeValues(ctx, obj)
 	if err != nil {
 		conditions.MarkFalse(obj, meta.ReadyCondition, "ValuesError", "%s", err)
 		r.Eventf(obj, corev1.EventTypeWarning, "ValuesError", err.Error())
@@ -324,6 +518,18 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelpe
 		conditions.MarkUnknown(obj, meta.ReadyCondition, meta.ProgressingReason, "reconciliation in progress")
 	}
 
+	// Build the maintenance schedule.
+	sched, err := r.buildSchedule(ctx, obj)
+	if err != nil {
+		conditions.MarkFalse(obj, meta.ReadyCondition, v2.InvalidScheduleReason, "%s", err)
+		r.Eventf(obj, corev1.EventTypeWarning, v2.InvalidScheduleReason, err.Error())
+		return ctrl.Result{}, err
+	}
+	// Remove any stale corresponding Ready=False condition with Unknown.
+	if conditions.HasAnyReason(obj, meta.ReadyCondition, v2.InvalidScheduleReason, v2.WaitingForWindowReason) {
+		conditions.MarkUnknown(obj, meta.ReadyCondition, meta.ProgressingReason, "reconciliation in progress")
+	}
+
 	// Load chart from artifact.
 	loadedChart, err := loader.SecureLoadChartFromURL(loader.NewRetryableHTTPClient(ctx, r.artifactFetchRetries), source.GetArtifact().URL, source.GetArtifact().Digest)
 	if err != nil {
@@ -378,7 +584,11 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelpe
 	// fail due to resources already existing.
 	if reason, changed := action.ReleaseTargetChanged(obj, loadedChart.Name()); changed {
 		log.Info(fmt.Sprintf("release target configuration changed (%s): running uninstall for current release", reason))
-		if err = r.reconcileUninstall(ctx, getter, obj); err != nil && !errors.Is(err, intreconcile.ErrNoLatest) {
+		if err = r.reconcileUninstall(ctx, getter, obj, sched); err != nil && !errors.Is(err, intreconcile.ErrNoLatest) {
+			var windowErr *intreconcile.WaitingForWindowError
+			if errors.As(err, &windowErr) {
+				return requeueForWindow(obj, windowErr), errWaitForWindow
+			}
 			return ctrl.Result{}, err
 		}
 		obj.Status.ClearHistory()
@@ -390,8 +600,8 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelpe
 	// Set current storage namespace.
 	obj.Status.StorageNamespace = obj.GetStorageNamespace()
 
-	// Reset the failure count if the chart or values have changed.
-	if reason, ok := action.MustResetFailures(obj, loadedChart.Metadata, values); ok {
+	// Reset the failure count if the chart, values or defaults have changed.
+	if reason, ok := action.MustResetFailures(obj, loadedChart.Metadata, values, defaults); ok {
 		log.V(logger.DebugLevel).Info(fmt.Sprintf("resetting failure count (%s)", reason))
 		obj.Status.ClearFailures()
 	}
@@ -400,7 +610,8 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelpe
 	obj.Status.LastAttemptedGeneration = obj.Generation
 	obj.Status.LastAttemptedRevision = loadedChart.Metadata.Version
 	obj.Status.LastAttemptedRevisionDigest = ociDigest
-	obj.Status.LastAttemptedConfigDigest = chartutil.DigestValues(digest.Canonical, values).String()
+	obj.Status.LastAttemptedConfigDigest = intdefaults.ConfigDigest(digest.Canonical, values, obj.Spec.ValuesFrom, defaults).String()
+	obj.Status.AppliedDefaults = intdefaults.Names(defaults)
 	obj.Status.LastAttemptedValuesChecksum = ""
 	obj.Status.LastReleaseRevision = 0
 
@@ -419,29 +630,154 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, patchHelpe
 	}
 
 	// Off we go!
-	if err = intreconcile.NewAtomicRelease(patchHelper, cfg, r.EventRecorder, r.FieldManager).Reconcile(ctx, &intreconcile.Request{
-		Object: obj,
-		Chart:  loadedChart,
-		Values: values,
+	if err = intreconcile.NewAtomicRelease(patchHelper, r.Client, cfg, r.EventRecorder, r.FieldManager).Reconcile(ctx, &intreconcile.Request{
+		Object:   obj,
+		Chart:    loadedChart,
+		Values:   values,
+		Schedule: sched,
 	}); err != nil {
 		if errors.Is(err, intreconcile.ErrMustRequeue) {
 			return ctrl.Result{Requeue: true}, nil
 		}
+		var windowErr *intreconcile.WaitingForWindowError
+		if errors.As(err, &windowErr) {
+			return requeueForWindow(obj, windowErr), errWaitForWindow
+		}
 		if interrors.IsOneOf(err, intreconcile.ErrExceededMaxRetries, intreconcile.ErrMissingRollbackTarget) {
 			err = reconcile.TerminalError(err)
 		}
 		return ctrl.Result{}, err
 	}
+
+	// Write the outputs of the release.
+	if err = intreconcile.NewReleaseOutputs(r.Client, cfg, r.EventRecorder, r.FieldManager).Reconcile(ctx, &intreconcile.Request{
+		Object: obj,
+	}); err != nil {
+		conditions.MarkFalse(obj, meta.ReadyCondition, v2.OutputsFailedReason, "%s", err)
+		r.Eventf(obj, corev1.EventTypeWarning, v2.OutputsFailedReason, err.Error())
+		return ctrl.Result{}, err
+	}
 	return jitter.JitteredRequeueInterval(ctrl.Result{RequeueAfter: obj.GetRequeueAfter()}), nil
 }
 
+// composeValues composes the values of the HelmRelease from the spec and
+// references, including files from source artifacts. If decryption is
+// configured, SOPS encrypted documents in the references and inline values
+// are decrypted before they are merged. If
+// post build substitutions are configured, the variables in the string
+// values are substituted after they have been merged.
+func (r *HelmReleaseReconciler) composeValues(ctx context.Context, obj *v2.HelmRelease) (helmchartutil.Values, error) {
+	var (
+		valuesClient = r.Client
+		values       = obj.GetValues()
+		d            *decryptor.Decryptor
+	)
+
+	if obj.Spec.Decryption != nil {
+		var err error
+		if d, err = r.buildDecryptor(ctx, obj); err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = d.Cleanup()
+		}()
+		if values, err = d.DecryptValues(values); err != nil {
+			return nil, fmt.Errorf("failed to decrypt inline values: %w", err)
+		}
+		valuesClient = decryptor.NewDecryptingClient(r.Client, d)
+	}
+
+	fetch := func(ctx context.Context, artifact *sourcev1.Artifact, path string) ([]byte, error) {
+		b, err := loader.SecureLoadFileFromArtifactURL(loader.NewRetryableHTTPClient(ctx, r.artifactFetchRetries),
+			artifact.URL, artifact.Digest, path)
+		if err != nil || d == nil || !decryptor.IsEncrypted(b) {
+			return b, err
+		}
+		return d.DecryptToYAML(b)
+	}
+
+	composed, err := intvalues.ChartValuesFromReferences(ctx,
+		ctrl.LoggerFrom(ctx),
+		valuesClient,
+		fetch,
+		obj.Namespace,
+		values,
+		obj.Spec.ValuesFrom...)
+	if err != nil || obj.Spec.PostBuild == nil {
+		return composed, err
+	}
+
+	vars, err := postbuild.LoadVariables(ctx, valuesClient, obj.Namespace, obj.Spec.PostBuild)
+	if err != nil {
+		return nil, fmt.Errorf("post build substitutions failed: %w", err)
+	}
+	strict, _ := features.Enabled(features.StrictPostBuildSubstitutions)
+	if composed, err = postbuild.SubstituteVariables(composed, vars, strict); err != nil {
+		return nil, fmt.Errorf("post build substitutions failed: %w", err)
+	}
+	return composed, nil
+}
+
+// buildDecryptor returns a decryptor.Decryptor configured with the keys
+// from the Secret referenced in the decryption spec of the HelmRelease.
+func (r *HelmReleaseReconciler) buildDecryptor(ctx context.Context, obj *v2.HelmRelease) (*decryptor.Decryptor, error) {
+	if provider := obj.Spec.Decryption.Provider; provider != v2.DecryptionProviderSOPS {
+		return nil, fmt.Errorf("unsupported decryption provider '%s'", provider)
+	}
+	if obj.Spec.Decryption.SecretRef == nil {
+		return decryptor.NewDecryptor(), nil
+	}
+
+	secretName := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.Spec.Decryption.SecretRef.Name}
+	var secret corev1.Secret
+	if err := r.Client.Get(ctx, secretName, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get decryption Secret '%s': %w", secretName, err)
+	}
+	return decryptor.NewDecryptorFromSecret(&secret)
+}
+
 // reconcileDelete deletes the v1beta2.HelmChart of the v2.HelmRelease,
 // and uninstalls the Helm release if the resource has not been suspended.
 func (r *HelmReleaseReconciler) reconcileDelete(ctx context.Context, obj *v2.HelmRelease) (ctrl.Result, error) {
 	// Only uninstall the release and delete the HelmChart resource if the
 	// resource is not suspended.
 	if !obj.Spec.Suspend {
+		// Wait for the HelmReleases depending on this HelmRelease to be
+		// deleted first, to uninstall the releases in reverse dependency
+		// order.
+		if !obj.GetUninstall().DisableWaitForDependants {
+			dependants, err := r.listDependants(ctx, obj)
+			if err != nil {
+				var cycleErr *dependency.CycleError
+				if errors.As(err, &cycleErr) {
+					// The cycle can be broken by a change to any of the
+					// objects in the cycle, or waiting can be disabled.
+					// Retry on the fixed interval until then.
+					msg := fmt.Sprintf("unable to determine order of deletion: %s", err)
+					conditions.MarkFalse(obj, meta.ReadyCondition, v2.DependencyCycleReason, "%s", msg)
+					r.Eventf(obj, corev1.EventTypeWarning, v2.DependencyCycleReason, msg)
+					ctrl.LoggerFrom(ctx).Error(err, "unable to determine order of deletion")
+					return ctrl.Result{RequeueAfter: r.requeueDependency}, errWaitForDependency
+				}
+				return ctrl.Result{}, err
+			}
+			if len(dependants) > 0 {
+				msg := fmt.Sprintf("waiting for dependants to be deleted: %s", strings.Join(dependants, ", "))
+				conditions.MarkFalse(obj, meta.ReadyCondition, v2.DependantsNotDeletedReason, "%s", msg)
+				ctrl.LoggerFrom(ctx).Info(msg)
+
+				// The watch on the dependants triggers a reconciliation as
+				// soon as they are deleted. The fixed interval acts as a
+				// safety net.
+				return ctrl.Result{RequeueAfter: r.requeueDependency}, errWaitForDependency
+			}
+		}
+
 		if err := r.reconcileReleaseDeletion(ctx, obj); err != nil {
+			var windowErr *intreconcile.WaitingForWindowError
+			if errors.As(err, &windowErr) {
+				return requeueForWindow(obj, windowErr), errWaitForWindow
+			}
 			return ctrl.Result{}, err
 		}
 
@@ -461,6 +797,44 @@ func (r *HelmReleaseReconciler) reconcileDelete(ctx context.Context, obj *v2.Hel
 	return ctrl.Result{Requeue: true}, nil
 }
 
+// listDependants returns the namespaced names of the HelmReleases in the
+// cache which depend on the given v2.HelmRelease.
+//
+// Dependants which are being deleted themselves are included until they are
+// gone, as their release may not have been uninstalled yet. When all the
+// HelmReleases in e.g. a namespace are deleted at once, the releases are thus
+// uninstalled in reverse dependency order, starting with the ones nothing
+// depends on.
+//
+// If the dependencies of the v2.HelmRelease contain a cycle, the order of
+// deletion can not be determined and a dependency.CycleError is returned.
+func (r *HelmReleaseReconciler) listDependants(ctx context.Context, obj *v2.HelmRelease) ([]string, error) {
+	var list v2.HelmReleaseList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		v2.DependsOnIndexKey: client.ObjectKeyFromObject(obj).String(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list dependants: %w", err)
+	}
+
+	var dependants []string
+	for i := range list.Items {
+		key := client.ObjectKeyFromObject(&list.Items[i])
+		if key == client.ObjectKeyFromObject(obj) {
+			continue
+		}
+		dependants = append(dependants, key.String())
+	}
+	if len(dependants) == 0 {
+		return nil, nil
+	}
+
+	var cycleErr *dependency.CycleError
+	if _, err := r.resolveDependencies(ctx, obj); errors.As(err, &cycleErr) {
+		return nil, err
+	}
+	return dependants, nil
+}
+
 // handleReleaseDeletion handles the deletion of a HelmRelease resource.
 //
 // Before uninstalling the release, it will check if the current configuration
@@ -538,8 +912,15 @@ func (r *HelmReleaseReconciler) reconcileReleaseDeletion(ctx context.Context, ob
 		}
 	}
 
+	// Build the maintenance schedule, as the uninstall may be subject to it.
+	sched, err := r.buildSchedule(ctx, obj)
+	if err != nil {
+		conditions.MarkFalse(obj, meta.ReadyCondition, v2.InvalidScheduleReason, "%s", err)
+		return err
+	}
+
 	// Attempt to uninstall the release.
-	if err = r.reconcileUninstall(ctx, getter, obj); err != nil && !errors.Is(err, intreconcile.ErrNoLatest) {
+	if err = r.reconcileUninstall(ctx, getter, obj, sched); err != nil && !errors.Is(err, intreconcile.ErrNoLatest) {
 		return err
 	}
 	if err == nil {
@@ -562,7 +943,10 @@ func (r *HelmReleaseReconciler) reconcileChartTemplate(ctx context.Context, obj
 	})
 }
 
-func (r *HelmReleaseReconciler) reconcileUninstall(ctx context.Context, getter genericclioptions.RESTClientGetter, obj *v2.HelmRelease) error {
+// reconcileUninstall uninstalls the current release of the given
+// v2.HelmRelease. If the uninstall is not allowed by the given schedule at
+// this time, it returns an intreconcile.WaitingForWindowError.
+func (r *HelmReleaseReconciler) reconcileUninstall(ctx context.Context, getter genericclioptions.RESTClientGetter, obj *v2.HelmRelease, sched *schedule.Schedule) error {
 	// Construct config factory for current release.
 	cfg, err := action.NewConfigFactory(getter,
 		action.WithStorage(action.DefaultStorageDriver, obj.Status.StorageNamespace),
@@ -573,22 +957,164 @@ func (r *HelmReleaseReconciler) reconcileUninstall(ctx context.Context, getter g
 		return err
 	}
 
-	// Run uninstall.
-	return intreconcile.NewUninstall(cfg, r.EventRecorder).Reconcile(ctx, &intreconcile.Request{Object: obj})
+	// Run uninstall, if allowed by the maintenance schedule.
+	uninstall := intreconcile.NewUninstall(cfg, r.EventRecorder)
+	if err = intreconcile.WaitForWindow(obj, sched, uninstall, time.Now()); err != nil {
+		ctrl.LoggerFrom(ctx).Info(err.Error())
+		return err
+	}
+	return uninstall.Reconcile(ctx, &intreconcile.Request{Object: obj})
+}
+
+// applyDefaults merges the v2.HelmReleaseDefaults which apply to the
+// namespace of the v2.HelmRelease, and the v2.HelmReleaseNamespaceDefaults
+// in its namespace, into its spec, and returns them in order of increasing
+// precedence.
+func (r *HelmReleaseReconciler) applyDefaults(ctx context.Context, obj *v2.HelmRelease) ([]v2.HelmReleaseDefaults, error) {
+	var list v2.HelmReleaseDefaultsList
+	if err := r.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to list HelmReleaseDefaults: %w", err)
+	}
+	var namespaced v2.HelmReleaseNamespaceDefaultsList
+	if err := r.List(ctx, &namespaced, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("failed to list HelmReleaseNamespaceDefaults: %w", err)
+	}
+	defaults := intdefaults.Select(obj.GetNamespace(), list.Items, namespaced.Items)
+	if len(defaults) == 0 {
+		return nil, nil
+	}
+
+	spec, err := r.getExplicitSpec(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := intdefaults.Apply(obj, spec, defaults); err != nil {
+		return nil, err
+	}
+	return defaults, nil
+}
+
+// explicitSpec holds the explicitly set fields of the spec of a
+// v2.HelmRelease, for the generation of the object they were read for.
+type explicitSpec struct {
+	uid        types.UID
+	generation int64
+	fields     map[string]interface{}
+}
+
+// getExplicitSpec returns the explicitly set fields of the spec of the given
+// v2.HelmRelease, which are needed to tell the fields explicitly set to their
+// zero value apart from unset fields. As the typed object does not hold this
+// information, the object is read from the API server once per generation.
+// It returns an error if the generation of the object in the API server does
+// not match the generation of the given object, as the fields would then not
+// belong to the spec being reconciled.
+func (r *HelmReleaseReconciler) getExplicitSpec(ctx context.Context, obj *v2.HelmRelease) (map[string]interface{}, error) {
+	key := client.ObjectKeyFromObject(obj)
+
+	r.explicitSpecsMu.Lock()
+	cached, ok := r.explicitSpecs[key]
+	r.explicitSpecsMu.Unlock()
+	if ok && cached.uid == obj.GetUID() && cached.generation == obj.GetGeneration() {
+		return cached.fields, nil
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(v2.GroupVersion.WithKind(v2.HelmReleaseKind))
+	if err := r.APIReader.Get(ctx, key, u); err != nil {
+		return nil, fmt.Errorf("failed to get HelmRelease spec: %w", err)
+	}
+	if u.GetUID() != obj.GetUID() || u.GetGeneration() != obj.GetGeneration() {
+		return nil, fmt.Errorf("failed to get HelmRelease spec: generation %d does not match the observed generation %d",
+			u.GetGeneration(), obj.GetGeneration())
+	}
+	spec, _, err := unstructured.NestedMap(u.Object, "spec")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HelmRelease spec: %w", err)
+	}
+	fields := intdefaults.ExplicitFields(spec)
+
+	r.explicitSpecsMu.Lock()
+	defer r.explicitSpecsMu.Unlock()
+	if r.explicitSpecs == nil {
+		r.explicitSpecs = make(map[types.NamespacedName]explicitSpec)
+	}
+	r.explicitSpecs[key] = explicitSpec{uid: u.GetUID(), generation: u.GetGeneration(), fields: fields}
+	return fields, nil
+}
+
+// forgetExplicitSpec removes the explicitly set fields of the spec of the
+// v2.HelmRelease with the given key, if any.
+func (r *HelmReleaseReconciler) forgetExplicitSpec(key types.NamespacedName) {
+	r.explicitSpecsMu.Lock()
+	defer r.explicitSpecsMu.Unlock()
+	delete(r.explicitSpecs, key)
+}
+
+// buildSchedule returns the maintenance schedule for the given
+// v2.HelmRelease, composed of its own windows and the cluster-wide freeze
+// windows. It returns nil if there are no windows.
+func (r *HelmReleaseReconciler) buildSchedule(ctx context.Context, obj *v2.HelmRelease) (*schedule.Schedule, error) {
+	freeze := r.FreezeWindows
+	if r.FreezeConfigMap != nil {
+		var reader client.Reader = r.Client
+		if r.FreezeConfigMapReader != nil {
+			reader = r.FreezeConfigMapReader
+		}
+		// Reading from a cache blocks until it has synced, which never
+		// happens when e.g. the controller lacks permission to watch the
+		// ConfigMap.
+		getCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		cm := &corev1.ConfigMap{}
+		if err := reader.Get(getCtx, *r.FreezeConfigMap, cm); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("failed to get freeze windows ConfigMap '%s': %w", r.FreezeConfigMap, err)
+		}
+		if data, ok := cm.Data[schedule.FreezeWindowsKey]; ok {
+			windows, err := schedule.ParseWindows([]byte(data))
+			if err != nil {
+				return nil, fmt.Errorf("invalid freeze windows in ConfigMap '%s': %w", r.FreezeConfigMap, err)
+			}
+			freeze = append(append([]schedule.Window{}, freeze...), windows...)
+		}
+	}
+
+	sched, err := schedule.New(obj.Spec.Schedule, freeze)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
+	return sched, nil
+}
+
+// requeueForWindow returns the result to requeue the given v2.HelmRelease at
+// the start of the next maintenance window, or at its interval when this is
+// sooner or the start of the next window is unknown.
+func requeueForWindow(obj *v2.HelmRelease, err *intreconcile.WaitingForWindowError) ctrl.Result {
+	after := obj.GetRequeueAfter()
+	if !err.Next.IsZero() {
+		if until := time.Until(err.Next); until < after {
+			after = max(until, time.Second)
+		}
+	}
+	return ctrl.Result{RequeueAfter: after}
 }
 
 // checkDependencies checks if the dependencies of the given v2.HelmRelease
 // are Ready.
-// It returns an error if a dependency can not be retrieved or is not Ready,
-// otherwise nil.
+// It returns an error if a dependency can not be accessed, retrieved or is
+// not Ready, otherwise nil.
 func (r *HelmReleaseReconciler) checkDependencies(ctx context.Context, obj *v2.HelmRelease) error {
 	for _, d := range obj.Spec.DependsOn {
-		ref := types.NamespacedName{
-			Namespace: d.Namespace,
-			Name:      d.Name,
+		ref := dependencyNamespacedName(obj, d)
+		if err := intacl.AllowsAccessTo(obj, d.GetKind(), ref); err != nil {
+			return err
 		}
-		if ref.Namespace == "" {
-			ref.Namespace = obj.GetNamespace()
+
+		if !d.IsHelmRelease() || d.ReadyExpr != "" {
+			if err := r.checkDependencyReadiness(ctx, obj, d, ref); err != nil {
+				return err
+			}
+			continue
 		}
 
 		dHr := &v2.HelmRelease{}
@@ -603,6 +1129,148 @@ func (r *HelmReleaseReconciler) checkDependencies(ctx context.Context, obj *v2.H
 	return nil
 }
 
+// checkDependencyReadiness checks if the given dependency of the
+// v2.HelmRelease is ready, using the ReadyExpr of the dependency if set, or
+// kstatus otherwise.
+// If the dependency is not a HelmRelease, it ensures the kind of the
+// dependency is watched.
+func (r *HelmReleaseReconciler) checkDependencyReadiness(ctx context.Context, obj *v2.HelmRelease,
+	d v2.DependencyReference, ref types.NamespacedName) error {
+	// Compile the expression first, as a retry will not fix an invalid
+	// expression.
+	var expr *intcel.Expression
+	if d.ReadyExpr != "" {
+		var err error
+		if expr, err = intcel.NewExpression(d.ReadyExpr, "self", "dep"); err != nil {
+			return fmt.Errorf("%w for %s '%s' dependency: %w", errInvalidReadyExpr, d.GetKind(), ref, err)
+		}
+	}
+
+	gvk := schema.FromAPIVersionAndKind(d.GetAPIVersion(), d.GetKind())
+	dep := &unstructured.Unstructured{}
+	dep.SetGroupVersionKind(gvk)
+	if err := r.APIReader.Get(ctx, ref, dep); err != nil {
+		return fmt.Errorf("unable to get %s '%s' dependency: %w", d.GetKind(), ref, err)
+	}
+
+	if !d.IsHelmRelease() {
+		if err := r.watchDependencyKind(gvk); err != nil {
+			// The dependency is checked again on a fixed interval, which
+			// acts as a safety net.
+			ctrl.LoggerFrom(ctx).Error(err, "failed to watch dependency kind")
+		}
+	}
+
+	if expr != nil {
+		self, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		ready, err := expr.EvaluateBoolean(ctx, map[string]any{
+			"self": self,
+			"dep":  dep.UnstructuredContent(),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to determine readiness of %s '%s' dependency: %w", d.GetKind(), ref, err)
+		}
+		if !ready {
+			return fmt.Errorf("dependency %s '%s' is not ready: readyExpr evaluated to false", d.GetKind(), ref)
+		}
+		return nil
+	}
+
+	res, err := kstatus.Compute(dep)
+	if err != nil {
+		return fmt.Errorf("unable to determine readiness of %s '%s' dependency: %w", d.GetKind(), ref, err)
+	}
+	if res.Status != kstatus.CurrentStatus {
+		return fmt.Errorf("dependency %s '%s' is not ready: %s", d.GetKind(), ref, res.Message)
+	}
+	return nil
+}
+
+// watchDependencyKind ensures objects of the given kind are watched, to
+// requeue the HelmReleases depending on them when they change. Only the
+// metadata of the objects is watched, as the readiness of a dependency is
+// determined using the APIReader.
+func (r *HelmReleaseReconciler) watchDependencyKind(gvk schema.GroupVersionKind) error {
+	r.dependencyKindsMu.Lock()
+	defer r.dependencyKindsMu.Unlock()
+
+	gk := gvk.GroupKind()
+	if _, ok := r.dependencyKinds[gk]; ok || r.controller == nil {
+		return nil
+	}
+
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(gvk)
+	if err := r.controller.Watch(source.Kind[client.Object](r.cache, obj,
+		handler.EnqueueRequestsFromMapFunc(r.requestsForDependencyKindChange(gk)),
+		predicate.ResourceVersionChangedPredicate{},
+	)); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", gk, err)
+	}
+
+	if r.dependencyKinds == nil {
+		r.dependencyKinds = make(map[schema.GroupKind]struct{})
+	}
+	r.dependencyKinds[gk] = struct{}{}
+	return nil
+}
+
+// resolveDependencies resolves the transitive HelmRelease dependencies of the
+// given v2.HelmRelease, based on the HelmReleases in the cache. Dependencies which
+// are not in the cache (e.g. because they are handled by another shard) are
+// retrieved from the API server.
+// It returns the dependencies in the order in which they have to become
+// Ready, and a dependency.CycleError or dependency.MissingError if the graph
+// contains a cycle or dependencies which do not exist.
+func (r *HelmReleaseReconciler) resolveDependencies(ctx context.Context, obj *v2.HelmRelease) ([]meta.NamespacedObjectReference, error) {
+	var list v2.HelmReleaseList
+	if err := r.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to list HelmReleases: %w", err)
+	}
+
+	objs := make(map[types.NamespacedName]*v2.HelmRelease, len(list.Items)+1)
+	for i := range list.Items {
+		objs[client.ObjectKeyFromObject(&list.Items[i])] = &list.Items[i]
+	}
+	// Always use the object under reconciliation, as the cache may not
+	// reflect its latest state.
+	objs[client.ObjectKeyFromObject(obj)] = obj
+
+	order, err := dependency.Resolve(client.ObjectKeyFromObject(obj), func(key types.NamespacedName) ([]types.NamespacedName, bool, error) {
+		hr, ok := objs[key]
+		if !ok {
+			hr = &v2.HelmRelease{}
+			if err := r.APIReader.Get(ctx, key, hr); err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil, false, nil
+				}
+				return nil, false, fmt.Errorf("unable to get '%s' dependency: %w", key, err)
+			}
+			objs[key] = hr
+		}
+
+		deps := make([]types.NamespacedName, 0, len(hr.Spec.DependsOn))
+		for _, d := range hr.Spec.DependsOn {
+			// Objects of other kinds can not depend on HelmReleases, and
+			// are therefore leaves of the graph.
+			if !d.IsHelmRelease() {
+				continue
+			}
+			deps = append(deps, dependencyNamespacedName(hr, d))
+		}
+		return deps, true, nil
+	})
+
+	var refs []meta.NamespacedObjectReference
+	for _, key := range order {
+		refs = append(refs, meta.NamespacedObjectReference{Namespace: key.Namespace, Name: key.Name})
+	}
+	return refs, err
+}
+
 // adoptLegacyRelease attempts to adopt a v2beta1 release into a v2
 // release.
 // This is done by retrieving the last successful release from the Helm storage
@@ -843,6 +1511,268 @@ func (r *HelmReleaseReconciler) requestsForOCIRrepositoryChange(ctx context.Cont
 	return reqs
 }
 
+// requestsForValuesSourceChange enqueues a request for every v2.HelmRelease
+// referencing values from the changed source of the given kind.
+func (r *HelmReleaseReconciler) requestsForValuesSourceChange(kind string) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		var list v2.HelmReleaseList
+		if err := r.List(ctx, &list, client.MatchingFields{
+			v2.ValuesSourceIndexKey: valuesSourceIndexValue(kind, client.ObjectKeyFromObject(o)),
+		}); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, fmt.Sprintf("failed to list HelmReleases for %s change", kind))
+			return nil
+		}
+
+		reqs := make([]reconcile.Request, len(list.Items))
+		for i := range list.Items {
+			reqs[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])}
+		}
+		return reqs
+	}
+}
+
+// requestsForDependencyChange enqueues a request for every v2.HelmRelease
+// depending on the changed v2.HelmRelease, which is not yet Ready.
+func (r *HelmReleaseReconciler) requestsForDependencyChange(ctx context.Context, o client.Object) []reconcile.Request {
+	var list v2.HelmReleaseList
+	if err := r.List(ctx, &list, client.MatchingFields{
+		v2.DependsOnIndexKey: client.ObjectKeyFromObject(o).String(),
+	}); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list HelmReleases for dependency change")
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i := range list.Items {
+		// If the dependant is already Ready, the change of the dependency
+		// does not need to be acted on.
+		if conditions.IsReady(&list.Items[i]) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+	}
+	return reqs
+}
+
+// requestsForDependencyKindChange returns a handler.MapFunc which enqueues a
+// request for every v2.HelmRelease which is not Ready, and depends on the
+// changed object of the given GroupKind.
+func (r *HelmReleaseReconciler) requestsForDependencyKindChange(gk schema.GroupKind) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		var list v2.HelmReleaseList
+		if err := r.List(ctx, &list, client.MatchingFields{
+			v2.DependsOnIndexKey: fmt.Sprintf("%s/%s", gk, o.GetName()),
+		}); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, fmt.Sprintf("failed to list HelmReleases for %s change", gk))
+			return nil
+		}
+
+		var reqs []reconcile.Request
+		for i := range list.Items {
+			hr := &list.Items[i]
+			if conditions.IsReady(hr) {
+				continue
+			}
+			for _, d := range hr.Spec.DependsOn {
+				if d.IsHelmRelease() || dependencyGroupKind(d) != gk || d.Name != o.GetName() {
+					continue
+				}
+				// The namespace of cluster-scoped objects is empty, in
+				// which case any reference with a matching name applies.
+				if ns := o.GetNamespace(); ns != "" && dependencyNamespacedName(hr, d).Namespace != ns {
+					continue
+				}
+				reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(hr)})
+				break
+			}
+		}
+		return reqs
+	}
+}
+
+// requestsForDependantDeletion enqueues a request for every dependency of the
+// deleted v2.HelmRelease which is being deleted, as its uninstall may be
+// waiting for the deleted v2.HelmRelease to disappear.
+func (r *HelmReleaseReconciler) requestsForDependantDeletion(ctx context.Context, o client.Object) []reconcile.Request {
+	obj, ok := o.(*v2.HelmRelease)
+	if !ok {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, d := range obj.Spec.DependsOn {
+		if !d.IsHelmRelease() {
+			continue
+		}
+		ref := dependencyNamespacedName(obj, d)
+
+		var dep v2.HelmRelease
+		if err := r.Get(ctx, ref, &dep); err != nil {
+			if !apierrors.IsNotFound(err) {
+				ctrl.LoggerFrom(ctx).Error(err, "failed to get HelmRelease for dependant deletion")
+			}
+			continue
+		}
+		if dep.DeletionTimestamp.IsZero() {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: ref})
+	}
+	return reqs
+}
+
+// requestsForDefaultsChange enqueues a request for every v2.HelmRelease the
+// changed v2.HelmReleaseDefaults apply to.
+func (r *HelmReleaseReconciler) requestsForDefaultsChange(ctx context.Context, o client.Object) []reconcile.Request {
+	obj, ok := o.(*v2.HelmReleaseDefaults)
+	if !ok {
+		return nil
+	}
+
+	var list v2.HelmReleaseList
+	if err := r.List(ctx, &list); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list HelmReleases for HelmReleaseDefaults change")
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for i := range list.Items {
+		if !obj.AppliesTo(list.Items[i].GetNamespace()) {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+	}
+	return reqs
+}
+
+// requestsForNamespaceDefaultsChange enqueues a request for every
+// v2.HelmRelease in the namespace of the changed
+// v2.HelmReleaseNamespaceDefaults.
+func (r *HelmReleaseReconciler) requestsForNamespaceDefaultsChange(ctx context.Context, o client.Object) []reconcile.Request {
+	var list v2.HelmReleaseList
+	if err := r.List(ctx, &list, client.InNamespace(o.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list HelmReleases for HelmReleaseNamespaceDefaults change")
+		return nil
+	}
+
+	reqs := make([]reconcile.Request, 0, len(list.Items))
+	for i := range list.Items {
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])})
+	}
+	return reqs
+}
+
+// requestsForConfigChange returns a handler.MapFunc which enqueues a request
+// for every v2.HelmRelease referencing the changed ConfigMap or Secret, as
+// looked up using the given index key.
+func (r *HelmReleaseReconciler) requestsForConfigChange(indexKey string) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		var list v2.HelmReleaseList
+		if err := r.List(ctx, &list, client.MatchingFields{
+			indexKey: client.ObjectKeyFromObject(o).String(),
+		}); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, fmt.Sprintf("failed to list HelmReleases for %T change", o))
+			return nil
+		}
+
+		reqs := make([]reconcile.Request, len(list.Items))
+		for i := range list.Items {
+			reqs[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&list.Items[i])}
+		}
+		return reqs
+	}
+}
+
+// configChangeHandler returns a handler.EventHandler which enqueues the
+// HelmReleases referencing a ConfigMap or Secret using the given index key,
+// when the object is created or deleted, or its data changed.
+//
+// As only the metadata of the objects is watched, updates are told apart
+// from metadata-only updates by reading the object referenced by any
+// HelmRelease using the APIReader, and comparing the digest of its data to
+// the one last observed. The first update of an object observed after a
+// restart of the controller is always considered a change.
+func (r *HelmReleaseReconciler) configChangeHandler(indexKey string, newObj func() client.Object) handler.EventHandler {
+	requestsForConfigChange := r.requestsForConfigChange(indexKey)
+	enqueue := func(q workqueue.TypedRateLimitingInterface[reconcile.Request], reqs []reconcile.Request) {
+		for _, req := range reqs {
+			q.Add(req)
+		}
+	}
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(q, requestsForConfigChange(ctx, e.Object))
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			key := configDigestKey(indexKey, e.ObjectNew)
+			reqs := requestsForConfigChange(ctx, e.ObjectNew)
+			if len(reqs) == 0 {
+				r.setConfigDigest(key, "")
+				return
+			}
+
+			obj := newObj()
+			if err := r.APIReader.Get(ctx, client.ObjectKeyFromObject(e.ObjectNew), obj); err != nil {
+				if !apierrors.IsNotFound(err) {
+					ctrl.LoggerFrom(ctx).Error(err, fmt.Sprintf("failed to get %T to determine data change", obj))
+				}
+				r.setConfigDigest(key, "")
+				enqueue(q, reqs)
+				return
+			}
+			if r.setConfigDigest(key, configDataDigest(obj)) {
+				enqueue(q, reqs)
+			}
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			r.setConfigDigest(configDigestKey(indexKey, e.Object), "")
+			enqueue(q, requestsForConfigChange(ctx, e.Object))
+		},
+	}
+}
+
+// setConfigDigest records the given digest of the data of the object with the
+// given key, and returns true if it differs from the digest recorded before.
+// An empty digest removes the record.
+func (r *HelmReleaseReconciler) setConfigDigest(key, d string) bool {
+	r.configDigestsMu.Lock()
+	defer r.configDigestsMu.Unlock()
+
+	prev, ok := r.configDigests[key]
+	if d == "" {
+		delete(r.configDigests, key)
+		return ok
+	}
+	if r.configDigests == nil {
+		r.configDigests = make(map[string]string)
+	}
+	r.configDigests[key] = d
+	return !ok || prev != d
+}
+
+// configDigestKey returns the key of the given object in the configDigests of
+// the HelmReleaseReconciler.
+func configDigestKey(indexKey string, obj client.Object) string {
+	return indexKey + "/" + client.ObjectKeyFromObject(obj).String()
+}
+
+// configDataDigest returns the digest of the data of the given ConfigMap or
+// Secret.
+func configDataDigest(obj client.Object) string {
+	var data any
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		data = []any{o.Data, o.BinaryData}
+	case *corev1.Secret:
+		data = []any{o.Type, o.Data}
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	return digest.Canonical.FromBytes(b).String()
+}
+
 func isSourceReady(obj sourcev1.Source) (bool, string) {
 	if o, ok := obj.(conditions.Getter); ok {
 		return isReady(o, obj.GetArtifact())
@@ -902,6 +1832,116 @@ func getNamespacedName(obj *v2.HelmRelease) (types.NamespacedName, error) {
 	return namespacedName, nil
 }
 
+// indexDependsOn returns the index values of the dependencies of the given
+// v2.HelmRelease. HelmRelease dependencies are indexed by their namespaced
+// name, dependencies of other kinds by their GroupKind and name, as the scope
+// of the kind is unknown.
+func indexDependsOn(o client.Object) []string {
+	obj, ok := o.(*v2.HelmRelease)
+	if !ok {
+		return nil
+	}
+
+	var refs []string
+	for _, d := range obj.Spec.DependsOn {
+		if d.IsHelmRelease() {
+			refs = append(refs, dependencyNamespacedName(obj, d).String())
+			continue
+		}
+		refs = append(refs, fmt.Sprintf("%s/%s", dependencyGroupKind(d), d.Name))
+	}
+	return refs
+}
+
+// dependencyNamespacedName returns the namespaced name of the given
+// dependency, defaulting the namespace to the namespace of the v2.HelmRelease.
+func dependencyNamespacedName(obj *v2.HelmRelease, d v2.DependencyReference) types.NamespacedName {
+	ref := types.NamespacedName{Namespace: d.Namespace, Name: d.Name}
+	if ref.Namespace == "" {
+		ref.Namespace = obj.GetNamespace()
+	}
+	return ref
+}
+
+// dependencyGroupKind returns the GroupKind of the given dependency.
+func dependencyGroupKind(d v2.DependencyReference) schema.GroupKind {
+	return schema.FromAPIVersionAndKind(d.GetAPIVersion(), d.GetKind()).GroupKind()
+}
+
+// indexValuesSources returns the kinds and namespaced names of the sources
+// the given v2.HelmRelease references values from.
+func indexValuesSources(o client.Object) []string {
+	obj, ok := o.(*v2.HelmRelease)
+	if !ok {
+		return nil
+	}
+
+	var refs []string
+	for _, v := range obj.Spec.ValuesFrom {
+		if v.IsSource() {
+			refs = append(refs, valuesSourceIndexValue(v.Kind, types.NamespacedName{Namespace: obj.GetNamespace(), Name: v.Name}))
+		}
+	}
+	return refs
+}
+
+// valuesSourceIndexValue returns the value of the v2.ValuesSourceIndexKey
+// index for the source of the given kind and namespaced name.
+func valuesSourceIndexValue(kind string, name types.NamespacedName) string {
+	return kind + "/" + name.String()
+}
+
+// indexConfigMaps returns the namespaced names of the ConfigMaps referenced
+// by the given v2.HelmRelease.
+func indexConfigMaps(o client.Object) []string {
+	obj, ok := o.(*v2.HelmRelease)
+	if !ok {
+		return nil
+	}
+
+	var refs []string
+	for _, v := range obj.Spec.ValuesFrom {
+		if v.Kind == "ConfigMap" {
+			refs = append(refs, types.NamespacedName{Namespace: obj.GetNamespace(), Name: v.Name}.String())
+		}
+	}
+	if obj.Spec.PostBuild != nil {
+		for _, v := range obj.Spec.PostBuild.SubstituteFrom {
+			if v.Kind == "ConfigMap" {
+				refs = append(refs, types.NamespacedName{Namespace: obj.GetNamespace(), Name: v.Name}.String())
+			}
+		}
+	}
+	return refs
+}
+
+// indexSecrets returns the namespaced names of the Secrets referenced by the
+// given v2.HelmRelease.
+func indexSecrets(o client.Object) []string {
+	obj, ok := o.(*v2.HelmRelease)
+	if !ok {
+		return nil
+	}
+
+	var refs []string
+	for _, v := range obj.Spec.ValuesFrom {
+		if v.Kind == "Secret" {
+			refs = append(refs, types.NamespacedName{Namespace: obj.GetNamespace(), Name: v.Name}.String())
+		}
+	}
+	if obj.Spec.PostBuild != nil {
+		for _, v := range obj.Spec.PostBuild.SubstituteFrom {
+			if v.Kind == "Secret" {
+				refs = append(refs, types.NamespacedName{Namespace: obj.GetNamespace(), Name: v.Name}.String())
+			}
+		}
+	}
+	if obj.Spec.KubeConfig != nil && obj.Spec.KubeConfig.SecretRef.Name != "" {
+		refs = append(refs, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.Spec.KubeConfig.SecretRef.Name}.String())
+	}
+	return refs
+}
+
 func mutateChartWithSourceRevision(chart *chart.Chart, source sourcev1.Source) (string, error) {
 	// If the source is an OCIRepository, we can try to mutate the chart version
 	// with the artifact revision. The revision is either a <tag>@<digest> or
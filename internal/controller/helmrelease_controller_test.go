@@ -34,14 +34,18 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/yaml"
 
@@ -50,6 +54,7 @@ import (
 	"github.com/fluxcd/pkg/apis/kustomize"
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/chartutil"
+	runtimeacl "github.com/fluxcd/pkg/runtime/acl"
 	"github.com/fluxcd/pkg/runtime/conditions"
 	feathelper "github.com/fluxcd/pkg/runtime/features"
 	"github.com/fluxcd/pkg/runtime/patch"
@@ -59,11 +64,13 @@ import (
 	v2 "github.com/fluxcd/helm-controller/api/v2"
 	intacl "github.com/fluxcd/helm-controller/internal/acl"
 	"github.com/fluxcd/helm-controller/internal/action"
+	intdefaults "github.com/fluxcd/helm-controller/internal/defaults"
 	"github.com/fluxcd/helm-controller/internal/features"
 	"github.com/fluxcd/helm-controller/internal/kube"
 	"github.com/fluxcd/helm-controller/internal/postrender"
 	intreconcile "github.com/fluxcd/helm-controller/internal/reconcile"
 	"github.com/fluxcd/helm-controller/internal/release"
+	"github.com/fluxcd/helm-controller/internal/schedule"
 	"github.com/fluxcd/helm-controller/internal/testutil"
 )
 
@@ -94,7 +101,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 				Namespace: "mock",
 			},
 			Spec: v2.HelmReleaseSpec{
-				DependsOn: []meta.NamespacedObjectReference{
+				DependsOn: []v2.DependencyReference{
 					{
 						Name: "dependency",
 					},
@@ -113,7 +120,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		}
 		r.APIReader = r.Client
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForDependency))
 		g.Expect(res.RequeueAfter).To(Equal(r.requeueDependency))
 
@@ -123,6 +130,61 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		}))
 	})
 
+	t.Run("detects dependency cycles", func(t *testing.T) {
+		g := NewWithT(t)
+
+		dependency := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dependency",
+				Namespace: "mock",
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{
+						Name: "dependant",
+					},
+				},
+			},
+		}
+
+		obj := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dependant",
+				Namespace: "mock",
+			},
+			Spec: v2.HelmReleaseSpec{
+				Interval: metav1.Duration{Duration: time.Minute},
+				DependsOn: []v2.DependencyReference{
+					{
+						Name: "dependency",
+					},
+				},
+			},
+		}
+
+		r := &HelmReleaseReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(NewTestScheme()).
+				WithStatusSubresource(&v2.HelmRelease{}).
+				WithObjects(dependency, obj).
+				Build(),
+			EventRecorder:     record.NewFakeRecorder(32),
+			requeueDependency: 5 * time.Second,
+		}
+		r.APIReader = r.Client
+
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res.RequeueAfter).ToNot(BeZero())
+
+		msg := "dependency cycle detected: mock/dependant -> mock/dependency -> mock/dependant"
+		g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+			*conditions.TrueCondition(meta.StalledCondition, v2.DependencyCycleReason, msg),
+			*conditions.FalseCondition(meta.ReadyCondition, v2.DependencyCycleReason, msg),
+		}))
+		g.Expect(obj.Status.DependencyOrder).To(BeEmpty())
+	})
+
 	t.Run("handles HelmChart get failure", func(t *testing.T) {
 		g := NewWithT(t)
 
@@ -145,7 +207,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		}
 		r.APIReader = r.Client
 
-		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 
 		g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
@@ -177,7 +239,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		}
 		r.APIReader = r.Client
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeTrue())
 		g.Expect(res.IsZero()).To(BeTrue())
@@ -234,7 +296,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 				Build(),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForChart))
 		g.Expect(res.RequeueAfter).To(Equal(obj.Spec.Interval.Duration))
 
@@ -291,7 +353,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		}
 		r.APIReader = r.Client
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForChart))
 		g.Expect(res.RequeueAfter).To(Equal(obj.Spec.Interval.Duration))
 
@@ -331,7 +393,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 				Namespace: "mock",
 			},
 			Spec: v2.HelmReleaseSpec{
-				ValuesFrom: []meta.ValuesReference{
+				ValuesFrom: []v2.ValuesReference{
 					{
 						Kind: "Secret",
 						Name: "missing",
@@ -353,7 +415,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		}
 		r.APIReader = r.Client
 
-		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 
 		g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
@@ -405,7 +467,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		}
 		r.APIReader = r.Client
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForDependency))
 		g.Expect(res.RequeueAfter).To(Equal(r.requeueDependency))
 
@@ -508,7 +570,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		g.Expect(store.Create(rls)).To(Succeed())
 
 		// Reconcile the Helm release.
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// Assert that the Helm release has been adopted.
@@ -578,7 +640,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 			EventRecorder:    record.NewFakeRecorder(32),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, c), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, c), obj, nil)
 		g.Expect(err).ToNot(HaveOccurred())
 		g.Expect(res.Requeue).To(BeTrue())
 
@@ -654,7 +716,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 			EventRecorder:    record.NewFakeRecorder(32),
 		}
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, c), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, c), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(err.Error()).To(ContainSubstring("namespaces \"not-exist\" not found"))
 
@@ -726,7 +788,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 			EventRecorder:    record.NewFakeRecorder(32),
 		}
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, c), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, c), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(err.Error()).To(ContainSubstring("namespaces \"not-exist\" not found"))
 
@@ -832,6 +894,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 		// List of failure reasons to test.
 		prereqFailures := []string{
 			v2.DependencyNotReadyReason,
+			v2.DependencyCycleReason,
 			aclv1.AccessDeniedReason,
 			v2.ArtifactFailedReason,
 			"SourceNotReady",
@@ -850,7 +913,7 @@ func TestHelmReleaseReconciler_reconcileRelease(t *testing.T) {
 			)
 			g.Expect(err).ToNot(HaveOccurred())
 
-			_, err = r.reconcileRelease(context.TODO(), sp, obj)
+			_, err = r.reconcileRelease(context.TODO(), sp, obj, nil)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			ready := conditions.Get(obj, meta.ReadyCondition)
@@ -932,7 +995,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromHelmChartSource(t *testing.T)
 			EventRecorder: record.NewFakeRecorder(32),
 		}
 
-		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 
 		g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
@@ -967,7 +1030,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromHelmChartSource(t *testing.T)
 			EventRecorder: record.NewFakeRecorder(32),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeTrue())
 		g.Expect(res.IsZero()).To(BeTrue())
@@ -1025,7 +1088,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromHelmChartSource(t *testing.T)
 				Build(),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForChart))
 		g.Expect(res.RequeueAfter).To(Equal(obj.Spec.Interval.Duration))
 
@@ -1085,7 +1148,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromHelmChartSource(t *testing.T)
 			EventRecorder:     record.NewFakeRecorder(32),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForDependency))
 		g.Expect(res.RequeueAfter).To(Equal(r.requeueDependency))
 
@@ -1165,7 +1228,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromHelmChartSource(t *testing.T)
 			EventRecorder:     record.NewFakeRecorder(32),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForDependency))
 		g.Expect(res.RequeueAfter).To(Equal(r.requeueDependency))
 
@@ -1307,7 +1370,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromHelmChartSource(t *testing.T)
 		g.Expect(err).ToNot(HaveOccurred())
 		obj.Spec.PostRenderers[0].Kustomize.Patches = targeted
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// Verify attempted values are set.
@@ -1399,7 +1462,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder: record.NewFakeRecorder(32),
 		}
 
-		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 
 		g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
@@ -1434,7 +1497,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder: record.NewFakeRecorder(32),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeTrue())
 		g.Expect(res.IsZero()).To(BeTrue())
@@ -1492,7 +1555,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 				Build(),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForChart))
 		g.Expect(res.RequeueAfter).To(Equal(obj.Spec.Interval.Duration))
 
@@ -1537,7 +1600,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 					Name:      "ocirepo",
 					Namespace: "mock",
 				},
-				ValuesFrom: []meta.ValuesReference{
+				ValuesFrom: []v2.ValuesReference{
 					{
 						Kind: "Secret",
 						Name: "missing",
@@ -1555,7 +1618,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder: record.NewFakeRecorder(32),
 		}
 
-		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 
 		g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
@@ -1614,7 +1677,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder:     record.NewFakeRecorder(32),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForDependency))
 		g.Expect(res.RequeueAfter).To(Equal(r.requeueDependency))
 
@@ -1694,7 +1757,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder:     record.NewFakeRecorder(32),
 		}
 
-		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		res, err := r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(Equal(errWaitForDependency))
 		g.Expect(res.RequeueAfter).To(Equal(r.requeueDependency))
 
@@ -1758,7 +1821,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder:    record.NewFakeRecorder(32),
 		}
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(err.Error()).To(ContainSubstring("namespaces \"mock\" not found"))
 
@@ -1774,7 +1837,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 		ocirepo.Status.Artifact = chartArtifact
 		r.Client.Update(context.Background(), ocirepo)
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(err.Error()).To(ContainSubstring("namespaces \"mock\" not found"))
 
@@ -1852,7 +1915,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder:    record.NewFakeRecorder(32),
 		}
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// Verify attempted values are set.
@@ -1868,7 +1931,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 		chartArtifact.Revision = "0.1.0_20241102104025" + "@" + "sha256:adebc5e3cbcd6a0918bd470f3a6c9855bfe95d506c74726bc0f2edb0aecb1f4e"
 		ocirepo.Status.Artifact = chartArtifact
 		r.Client.Update(context.Background(), ocirepo)
-		r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 
 		// Verify attempted values are set.
 		g.Expect(obj.Status.LastAttemptedGeneration).To(Equal(obj.Generation))
@@ -1945,7 +2008,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder:    record.NewFakeRecorder(32),
 		}
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// Verify attempted values are set.
@@ -2022,7 +2085,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 			EventRecorder:    record.NewFakeRecorder(32),
 		}
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// Verify attempted values are set.
@@ -2151,7 +2214,7 @@ func TestHelmReleaseReconciler_reconcileReleaseFromOCIRepositorySource(t *testin
 		store := helmstorage.Init(cfg.Driver)
 		g.Expect(store.Create(rls)).To(Succeed())
 
-		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj)
+		_, err = r.reconcileRelease(context.TODO(), patch.NewSerialPatcher(obj, r.Client), obj, nil)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		// Verify attempted values are set.
@@ -2266,6 +2329,206 @@ func TestHelmReleaseReconciler_reconcileDelete(t *testing.T) {
 		}).Should(Succeed())
 	})
 
+	t.Run("waits for dependants to be deleted", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "dependency",
+				Namespace:         "mock",
+				Finalizers:        []string{v2.HelmReleaseFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+		}
+
+		dependant := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dependant",
+				Namespace: "mock",
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{
+						Name: "dependency",
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().
+			WithScheme(NewTestScheme()).
+			WithIndex(&v2.HelmRelease{}, v2.DependsOnIndexKey, indexDependsOn).
+			WithObjects(obj, dependant).
+			Build()
+
+		r := &HelmReleaseReconciler{
+			Client:            c,
+			APIReader:         c,
+			EventRecorder:     record.NewFakeRecorder(32),
+			requeueDependency: 5 * time.Second,
+		}
+
+		res, err := r.reconcileDelete(context.TODO(), obj)
+		g.Expect(err).To(Equal(errWaitForDependency))
+		g.Expect(res.RequeueAfter).To(Equal(r.requeueDependency))
+
+		g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+			*conditions.FalseCondition(meta.ReadyCondition, v2.DependantsNotDeletedReason, "waiting for dependants to be deleted: mock/dependant"),
+		}))
+		g.Expect(obj.GetFinalizers()).To(ConsistOf(v2.HelmReleaseFinalizer))
+	})
+
+	t.Run("waits for dependants which are being deleted", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "dependency",
+				Namespace:         "mock",
+				Finalizers:        []string{v2.HelmReleaseFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+		}
+
+		dependant := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "dependant",
+				Namespace:         "mock",
+				Finalizers:        []string{v2.HelmReleaseFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{
+						Name: "dependency",
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().
+			WithScheme(NewTestScheme()).
+			WithIndex(&v2.HelmRelease{}, v2.DependsOnIndexKey, indexDependsOn).
+			WithObjects(obj, dependant).
+			Build()
+
+		r := &HelmReleaseReconciler{
+			Client:            c,
+			APIReader:         c,
+			EventRecorder:     record.NewFakeRecorder(32),
+			requeueDependency: 5 * time.Second,
+		}
+
+		_, err := r.reconcileDelete(context.TODO(), obj)
+		g.Expect(err).To(Equal(errWaitForDependency))
+		g.Expect(conditions.GetReason(obj, meta.ReadyCondition)).To(Equal(v2.DependantsNotDeletedReason))
+	})
+
+	t.Run("does not wait for dependants when disabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "dependency",
+				Namespace:         "mock",
+				Finalizers:        []string{v2.HelmReleaseFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: v2.HelmReleaseSpec{
+				Uninstall: &v2.Uninstall{
+					DisableWaitForDependants: true,
+				},
+			},
+		}
+
+		dependant := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dependant",
+				Namespace: "mock",
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{
+						Name: "dependency",
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().
+			WithScheme(NewTestScheme()).
+			WithIndex(&v2.HelmRelease{}, v2.DependsOnIndexKey, indexDependsOn).
+			WithObjects(obj, dependant).
+			Build()
+
+		r := &HelmReleaseReconciler{
+			Client:        c,
+			APIReader:     c,
+			EventRecorder: record.NewFakeRecorder(32),
+		}
+
+		res, err := r.reconcileDelete(context.TODO(), obj)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(res.IsZero()).To(BeTrue())
+
+		g.Expect(obj.GetFinalizers()).To(BeEmpty())
+	})
+
+	t.Run("waits with dependency cycle reason", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "dependency",
+				Namespace:         "mock",
+				Finalizers:        []string{v2.HelmReleaseFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{
+						Name: "dependant",
+					},
+				},
+			},
+		}
+
+		dependant := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dependant",
+				Namespace: "mock",
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{
+						Name: "dependency",
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().
+			WithScheme(NewTestScheme()).
+			WithIndex(&v2.HelmRelease{}, v2.DependsOnIndexKey, indexDependsOn).
+			WithObjects(obj, dependant).
+			Build()
+
+		r := &HelmReleaseReconciler{
+			Client:            c,
+			APIReader:         c,
+			EventRecorder:     record.NewFakeRecorder(32),
+			requeueDependency: 5 * time.Second,
+		}
+
+		res, err := r.reconcileDelete(context.TODO(), obj)
+		g.Expect(err).To(Equal(errWaitForDependency))
+		g.Expect(res.RequeueAfter).To(Equal(r.requeueDependency))
+
+		g.Expect(conditions.GetReason(obj, meta.ReadyCondition)).To(Equal(v2.DependencyCycleReason))
+		g.Expect(conditions.GetMessage(obj, meta.ReadyCondition)).To(HavePrefix("unable to determine order of deletion: "))
+		g.Expect(obj.GetFinalizers()).To(ConsistOf(v2.HelmReleaseFinalizer))
+	})
+
 	t.Run("removes finalizer for suspended resource with DeletionTimestamp", func(t *testing.T) {
 		g := NewWithT(t)
 
@@ -2715,7 +2978,7 @@ func TestHelmReleaseReconciler_reconcileUninstall(t *testing.T) {
 		}
 
 		// We do not care about the result of the uninstall, only that it was attempted.
-		err := (&HelmReleaseReconciler{}).reconcileUninstall(context.TODO(), getter, obj)
+		err := (&HelmReleaseReconciler{}).reconcileUninstall(context.TODO(), getter, obj, nil)
 		g.Expect(err).To(HaveOccurred())
 		g.Expect(errors.Is(err, intreconcile.ErrNoLatest)).To(BeTrue())
 	})
@@ -2729,7 +2992,7 @@ func TestHelmReleaseReconciler_reconcileUninstall(t *testing.T) {
 			},
 		}
 
-		err := (&HelmReleaseReconciler{}).reconcileUninstall(context.TODO(), nil, obj)
+		err := (&HelmReleaseReconciler{}).reconcileUninstall(context.TODO(), nil, obj, nil)
 		g.Expect(err).To(HaveOccurred())
 
 		g.Expect(conditions.IsFalse(obj, meta.ReadyCondition)).To(BeTrue())
@@ -2792,7 +3055,7 @@ func TestHelmReleaseReconciler_reconcileUninstall(t *testing.T) {
 		store := helmstorage.Init(cfg.Driver)
 		g.Expect(store.Create(rls)).To(Succeed())
 
-		err = r.reconcileUninstall(context.TODO(), getter, obj)
+		err = r.reconcileUninstall(context.TODO(), getter, obj, nil)
 		g.Expect(err).To(HaveOccurred())
 
 		// Verify status of Helm release has not been updated.
@@ -2806,10 +3069,11 @@ func TestHelmReleaseReconciler_reconcileUninstall(t *testing.T) {
 
 func TestHelmReleaseReconciler_checkDependencies(t *testing.T) {
 	tests := []struct {
-		name    string
-		obj     *v2.HelmRelease
-		objects []client.Object
-		expect  func(g *WithT, err error)
+		name            string
+		obj             *v2.HelmRelease
+		objects         []client.Object
+		disallowCrossNS bool
+		expect          func(g *WithT, err error)
 	}{
 		{
 			name: "all dependencies ready",
@@ -2819,7 +3083,7 @@ func TestHelmReleaseReconciler_checkDependencies(t *testing.T) {
 					Namespace: "some-namespace",
 				},
 				Spec: v2.HelmReleaseSpec{
-					DependsOn: []meta.NamespacedObjectReference{
+					DependsOn: []v2.DependencyReference{
 						{
 							Name: "dependency-1",
 						},
@@ -2870,7 +3134,7 @@ func TestHelmReleaseReconciler_checkDependencies(t *testing.T) {
 					Namespace: "some-namespace",
 				},
 				Spec: v2.HelmReleaseSpec{
-					DependsOn: []meta.NamespacedObjectReference{
+					DependsOn: []v2.DependencyReference{
 						{
 							Name: "dependency-1",
 						},
@@ -2905,7 +3169,7 @@ func TestHelmReleaseReconciler_checkDependencies(t *testing.T) {
 					Namespace: "some-namespace",
 				},
 				Spec: v2.HelmReleaseSpec{
-					DependsOn: []meta.NamespacedObjectReference{
+					DependsOn: []v2.DependencyReference{
 						{
 							Name: "dependency-1",
 						},
@@ -2940,7 +3204,7 @@ func TestHelmReleaseReconciler_checkDependencies(t *testing.T) {
 					Namespace: "some-namespace",
 				},
 				Spec: v2.HelmReleaseSpec{
-					DependsOn: []meta.NamespacedObjectReference{
+					DependsOn: []v2.DependencyReference{
 						{
 							Name: "dependency-1",
 						},
@@ -2964,6 +3228,28 @@ func TestHelmReleaseReconciler_checkDependencies(t *testing.T) {
 				g.Expect(err.Error()).To(ContainSubstring("is not ready"))
 			},
 		},
+		{
+			name: "error on cross-namespace dependency not allowed",
+			obj: &v2.HelmRelease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dependant",
+					Namespace: "some-namespace",
+				},
+				Spec: v2.HelmReleaseSpec{
+					DependsOn: []v2.DependencyReference{
+						{
+							Name:      "dependency-1",
+							Namespace: "some-other-namespace",
+						},
+					},
+				},
+			},
+			disallowCrossNS: true,
+			expect: func(g *WithT, err error) {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(runtimeacl.IsAccessDenied(err)).To(BeTrue())
+			},
+		},
 		{
 			name: "error on missing dependency",
 			obj: &v2.HelmRelease{
@@ -2972,7 +3258,7 @@ func TestHelmReleaseReconciler_checkDependencies(t *testing.T) {
 					Namespace: "some-namespace",
 				},
 				Spec: v2.HelmReleaseSpec{
-					DependsOn: []meta.NamespacedObjectReference{
+					DependsOn: []v2.DependencyReference{
 						{
 							Name: "dependency-1",
 						},
@@ -2990,6 +3276,10 @@ func TestHelmReleaseReconciler_checkDependencies(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
+			curAllow := intacl.AllowCrossNamespaceRef
+			intacl.AllowCrossNamespaceRef = !tt.disallowCrossNS
+			t.Cleanup(func() { intacl.AllowCrossNamespaceRef = curAllow })
+
 			c := fake.NewClientBuilder().WithScheme(NewTestScheme())
 			if len(tt.objects) > 0 {
 				c.WithObjects(tt.objects...)
@@ -3523,37 +3813,1047 @@ func Test_waitForHistoryCacheSync(t *testing.T) {
 	}
 }
 
-func TestValuesReferenceValidation(t *testing.T) {
-	tests := []struct {
-		name       string
-		references []meta.ValuesReference
-		wantErr    bool
-	}{
-		{
-			name: "valid ValuesKey",
-			references: []meta.ValuesReference{
-				{
-					Kind:      "Secret",
-					Name:      "values",
-					ValuesKey: "any-key_na.me",
-				},
-			},
-			wantErr: false,
+func TestHelmReleaseReconciler_requestsForDependencyChange(t *testing.T) {
+	dependency := &v2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dependency",
+			Namespace: "some-namespace",
 		},
-		{
-			name: "valid ValuesKey: empty",
-			references: []meta.ValuesReference{
-				{
-					Kind:      "Secret",
-					Name:      "values",
-					ValuesKey: "",
+	}
+
+	objects := []client.Object{
+		dependency,
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dependant",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{Name: "dependency"},
 				},
 			},
-			wantErr: false,
+			Status: v2.HelmReleaseStatus{
+				Conditions: []metav1.Condition{
+					{Type: meta.ReadyCondition, Status: metav1.ConditionFalse, Reason: v2.DependencyNotReadyReason},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cross-namespace-dependant",
+				Namespace: "some-other-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{Name: "dependency", Namespace: "some-namespace"},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ready-dependant",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{Name: "dependency"},
+				},
+			},
+			Status: v2.HelmReleaseStatus{
+				Conditions: []metav1.Condition{
+					{Type: meta.ReadyCondition, Status: metav1.ConditionTrue},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other-dependant",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: []v2.DependencyReference{
+					{Name: "other-dependency"},
+				},
+			},
+		},
+	}
+
+	g := NewWithT(t)
+
+	c := fake.NewClientBuilder().
+		WithScheme(NewTestScheme()).
+		WithIndex(&v2.HelmRelease{}, v2.DependsOnIndexKey, indexDependsOn).
+		WithObjects(objects...).
+		Build()
+
+	r := &HelmReleaseReconciler{
+		Client: c,
+	}
+
+	got := r.requestsForDependencyChange(context.TODO(), dependency)
+	g.Expect(got).To(ConsistOf(
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "some-namespace", Name: "dependant"}},
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "some-other-namespace", Name: "cross-namespace-dependant"}},
+	))
+}
+
+func TestHelmReleaseReconciler_requestsForDependantDeletion(t *testing.T) {
+	g := NewWithT(t)
+
+	objects := []client.Object{
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "deleting-dependency",
+				Namespace:         "some-namespace",
+				Finalizers:        []string{v2.HelmReleaseFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "cross-namespace-dependency",
+				Namespace:         "some-other-namespace",
+				Finalizers:        []string{v2.HelmReleaseFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dependency",
+				Namespace: "some-namespace",
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(NewTestScheme()).
+		WithObjects(objects...).
+		Build()
+
+	r := &HelmReleaseReconciler{
+		Client: c,
+	}
+
+	dependant := &v2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dependant",
+			Namespace: "some-namespace",
+		},
+		Spec: v2.HelmReleaseSpec{
+			DependsOn: []v2.DependencyReference{
+				{Name: "deleting-dependency"},
+				{Name: "cross-namespace-dependency", Namespace: "some-other-namespace"},
+				{Name: "dependency"},
+				{Name: "missing-dependency"},
+			},
+		},
+	}
+
+	got := r.requestsForDependantDeletion(context.TODO(), dependant)
+	g.Expect(got).To(ConsistOf(
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "some-namespace", Name: "deleting-dependency"}},
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "some-other-namespace", Name: "cross-namespace-dependency"}},
+	))
+}
+
+func TestHelmReleaseReconciler_requestsForConfigChange(t *testing.T) {
+	objects := []client.Object{
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "values-from-configmap",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				ValuesFrom: []v2.ValuesReference{
+					{Kind: "ConfigMap", Name: "values"},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "values-from-secret",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				ValuesFrom: []v2.ValuesReference{
+					{Kind: "Secret", Name: "values"},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "values-from-both",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				ValuesFrom: []v2.ValuesReference{
+					{Kind: "ConfigMap", Name: "values"},
+					{Kind: "Secret", Name: "values"},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "substitute-from",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				PostBuild: &v2.PostBuild{
+					SubstituteFrom: []v2.SubstituteReference{
+						{Kind: "ConfigMap", Name: "vars"},
+						{Kind: "Secret", Name: "vars"},
+					},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kubeconfig",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				KubeConfig: &meta.KubeConfigReference{
+					SecretRef: meta.SecretKeyReference{Name: "kubeconfig"},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other-namespace",
+				Namespace: "some-other-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				ValuesFrom: []v2.ValuesReference{
+					{Kind: "ConfigMap", Name: "values"},
+					{Kind: "Secret", Name: "values"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		indexKey string
+		obj      client.Object
+		want     []string
+	}{
+		{
+			name:     "ConfigMap referenced in values",
+			indexKey: v2.ConfigMapIndexKey,
+			obj: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "some-namespace"},
+			},
+			want: []string{"values-from-configmap", "values-from-both"},
+		},
+		{
+			name:     "Secret referenced in values",
+			indexKey: v2.SecretIndexKey,
+			obj: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "some-namespace"},
+			},
+			want: []string{"values-from-secret", "values-from-both"},
+		},
+		{
+			name:     "ConfigMap referenced in substitutions",
+			indexKey: v2.ConfigMapIndexKey,
+			obj: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "vars", Namespace: "some-namespace"},
+			},
+			want: []string{"substitute-from"},
+		},
+		{
+			name:     "Secret referenced in substitutions",
+			indexKey: v2.SecretIndexKey,
+			obj: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "vars", Namespace: "some-namespace"},
+			},
+			want: []string{"substitute-from"},
+		},
+		{
+			name:     "Secret referenced as KubeConfig",
+			indexKey: v2.SecretIndexKey,
+			obj: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig", Namespace: "some-namespace"},
+			},
+			want: []string{"kubeconfig"},
+		},
+		{
+			name:     "unreferenced ConfigMap",
+			indexKey: v2.ConfigMapIndexKey,
+			obj: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig", Namespace: "some-namespace"},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().
+				WithScheme(NewTestScheme()).
+				WithIndex(&v2.HelmRelease{}, v2.ConfigMapIndexKey, indexConfigMaps).
+				WithIndex(&v2.HelmRelease{}, v2.SecretIndexKey, indexSecrets).
+				WithObjects(objects...).
+				Build()
+
+			r := &HelmReleaseReconciler{
+				Client: c,
+			}
+
+			var got []string
+			for _, req := range r.requestsForConfigChange(tt.indexKey)(context.TODO(), tt.obj) {
+				g.Expect(req.Namespace).To(Equal("some-namespace"))
+				got = append(got, req.Name)
+			}
+			g.Expect(got).To(ConsistOf(tt.want))
+		})
+	}
+}
+
+func TestHelmReleaseReconciler_configChangeHandler(t *testing.T) {
+	g := NewWithT(t)
+
+	hr := &v2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "values-from-configmap",
+			Namespace: "some-namespace",
+		},
+		Spec: v2.HelmReleaseSpec{
+			ValuesFrom: []v2.ValuesReference{
+				{Kind: "ConfigMap", Name: "values"},
+			},
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "some-namespace"},
+		Data:       map[string]string{"values.yaml": "foo: bar"},
+	}
+	unreferenced := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "some-namespace"},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(NewTestScheme()).
+		WithIndex(&v2.HelmRelease{}, v2.ConfigMapIndexKey, indexConfigMaps).
+		WithObjects(hr, cm, unreferenced).
+		Build()
+
+	r := &HelmReleaseReconciler{
+		Client:    c,
+		APIReader: c,
+	}
+	h := r.configChangeHandler(v2.ConfigMapIndexKey, func() client.Object { return &corev1.ConfigMap{} })
+
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer q.ShutDown()
+
+	// drain returns the number of requests in the queue, and empties it.
+	drain := func() int {
+		n := q.Len()
+		for i := 0; i < n; i++ {
+			req, _ := q.Get()
+			q.Done(req)
+			q.Forget(req)
+		}
+		return n
+	}
+	update := func(obj client.Object) {
+		h.Update(context.TODO(), event.UpdateEvent{ObjectOld: obj, ObjectNew: obj}, q)
+	}
+
+	h.Create(context.TODO(), event.CreateEvent{Object: cm}, q)
+	g.Expect(drain()).To(Equal(1))
+
+	// The first update can not be compared to earlier data.
+	update(cm)
+	g.Expect(drain()).To(Equal(1))
+
+	// Metadata-only updates are ignored.
+	cm.Labels = map[string]string{"foo": "bar"}
+	g.Expect(c.Update(context.TODO(), cm)).To(Succeed())
+	update(cm)
+	g.Expect(drain()).To(Equal(0))
+
+	cm.Data["values.yaml"] = "foo: baz"
+	g.Expect(c.Update(context.TODO(), cm)).To(Succeed())
+	update(cm)
+	g.Expect(drain()).To(Equal(1))
+
+	update(unreferenced)
+	g.Expect(drain()).To(Equal(0))
+
+	h.Delete(context.TODO(), event.DeleteEvent{Object: cm}, q)
+	g.Expect(drain()).To(Equal(1))
+}
+
+func TestHelmReleaseReconciler_requestsForValuesSourceChange(t *testing.T) {
+	objects := []client.Object{
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "values-from-git",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				ValuesFrom: []v2.ValuesReference{
+					{Kind: sourcev1.GitRepositoryKind, Name: "values", Path: "envs/prod/values.yaml"},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "values-from-oci",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				ValuesFrom: []v2.ValuesReference{
+					{Kind: sourcev1beta2.OCIRepositoryKind, Name: "values"},
+				},
+			},
+		},
+		&v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "values-from-configmap",
+				Namespace: "some-namespace",
+			},
+			Spec: v2.HelmReleaseSpec{
+				ValuesFrom: []v2.ValuesReference{
+					{Kind: "ConfigMap", Name: "values"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		kind string
+		obj  client.Object
+		want []string
+	}{
+		{
+			name: "GitRepository referenced in values",
+			kind: sourcev1.GitRepositoryKind,
+			obj: &sourcev1.GitRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "some-namespace"},
+			},
+			want: []string{"values-from-git"},
+		},
+		{
+			name: "OCIRepository referenced in values",
+			kind: sourcev1beta2.OCIRepositoryKind,
+			obj: &sourcev1beta2.OCIRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "some-namespace"},
+			},
+			want: []string{"values-from-oci"},
+		},
+		{
+			name: "unreferenced Bucket",
+			kind: sourcev1.BucketKind,
+			obj: &sourcev1.Bucket{
+				ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "some-namespace"},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().
+				WithScheme(NewTestScheme()).
+				WithIndex(&v2.HelmRelease{}, v2.ValuesSourceIndexKey, indexValuesSources).
+				WithObjects(objects...).
+				Build()
+
+			r := &HelmReleaseReconciler{
+				Client: c,
+			}
+
+			var got []string
+			for _, req := range r.requestsForValuesSourceChange(tt.kind)(context.TODO(), tt.obj) {
+				g.Expect(req.Namespace).To(Equal("some-namespace"))
+				got = append(got, req.Name)
+			}
+			g.Expect(got).To(ConsistOf(tt.want))
+		})
+	}
+}
+
+func TestHelmReleaseReconciler_resolveDependencies(t *testing.T) {
+	newHelmRelease := func(namespace, name string, dependsOn ...v2.DependencyReference) *v2.HelmRelease {
+		return &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: dependsOn,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		obj     *v2.HelmRelease
+		want    []meta.NamespacedObjectReference
+		wantErr string
+	}{
+		{
+			name: "transitive dependencies",
+			objects: []client.Object{
+				newHelmRelease("mock", "a", v2.DependencyReference{Name: "b", Namespace: "other"}),
+				newHelmRelease("other", "b", v2.DependencyReference{Name: "c"}),
+				newHelmRelease("other", "c"),
+			},
+			obj: newHelmRelease("mock", "root", v2.DependencyReference{Name: "a"}, v2.DependencyReference{Name: "c", Namespace: "other"}),
+			want: []meta.NamespacedObjectReference{
+				{Namespace: "other", Name: "c"},
+				{Namespace: "other", Name: "b"},
+				{Namespace: "mock", Name: "a"},
+			},
+		},
+		{
+			name: "cycle",
+			objects: []client.Object{
+				newHelmRelease("mock", "a", v2.DependencyReference{Name: "b"}),
+				newHelmRelease("mock", "b", v2.DependencyReference{Name: "a"}),
+			},
+			obj:     newHelmRelease("mock", "root", v2.DependencyReference{Name: "a"}),
+			wantErr: "dependency cycle detected: mock/a -> mock/b -> mock/a",
+		},
+		{
+			name: "missing dependency",
+			objects: []client.Object{
+				newHelmRelease("mock", "a", v2.DependencyReference{Name: "b"}),
+			},
+			obj: newHelmRelease("mock", "root", v2.DependencyReference{Name: "a"}),
+			want: []meta.NamespacedObjectReference{
+				{Namespace: "mock", Name: "a"},
+			},
+			wantErr: "dependencies not found: mock/b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().
+				WithScheme(NewTestScheme()).
+				WithObjects(append(tt.objects, tt.obj)...).
+				Build()
+
+			r := &HelmReleaseReconciler{
+				Client:    c,
+				APIReader: c,
+			}
+
+			got, err := r.resolveDependencies(context.TODO(), tt.obj)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(tt.wantErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHelmReleaseReconciler_buildSchedule(t *testing.T) {
+	flagWindow, err := schedule.ParseWindowFlag("0 0 * * fri;24h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freezeRef := &types.NamespacedName{Namespace: "flux-system", Name: "freeze"}
+
+	tests := []struct {
+		name        string
+		objects     []client.Object
+		freeze      []schedule.Window
+		spec        *v2.Schedule
+		wantWindows int
+		wantFreeze  int
+		wantErr     string
+	}{
+		{
+			name: "no windows",
+		},
+		{
+			name: "spec and flag windows",
+			spec: &v2.Schedule{Windows: []v2.ScheduleWindow{
+				{Start: "0 22 * * *", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			}},
+			freeze:      []schedule.Window{flagWindow},
+			wantWindows: 1,
+			wantFreeze:  1,
+		},
+		{
+			name: "ConfigMap windows",
+			objects: []client.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: freezeRef.Namespace, Name: freezeRef.Name},
+					Data: map[string]string{
+						schedule.FreezeWindowsKey: "- start: \"0 9 * * 1-5\"\n  duration: 8h\n",
+					},
+				},
+			},
+			freeze:     []schedule.Window{flagWindow},
+			wantFreeze: 2,
+		},
+		{
+			name: "invalid ConfigMap windows",
+			objects: []client.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: freezeRef.Namespace, Name: freezeRef.Name},
+					Data: map[string]string{
+						schedule.FreezeWindowsKey: "- start: \"0 9 * *\"\n  duration: 8h\n",
+					},
+				},
+			},
+			wantErr: "invalid freeze windows in ConfigMap 'flux-system/freeze'",
+		},
+		{
+			name: "invalid spec windows",
+			spec: &v2.Schedule{Windows: []v2.ScheduleWindow{
+				{Start: "0 22 * * *", Duration: metav1.Duration{Duration: 4 * time.Hour}, TimeZone: "Invalid/Zone"},
+			}},
+			wantErr: "invalid schedule",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &HelmReleaseReconciler{
+				Client: fake.NewClientBuilder().WithScheme(NewTestScheme()).Build(),
+				FreezeConfigMapReader: fake.NewClientBuilder().
+					WithScheme(NewTestScheme()).
+					WithObjects(tt.objects...).
+					Build(),
+				FreezeWindows:   tt.freeze,
+				FreezeConfigMap: freezeRef,
+			}
+
+			got, err := r.buildSchedule(context.TODO(), &v2.HelmRelease{
+				Spec: v2.HelmReleaseSpec{Schedule: tt.spec},
+			})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			if tt.wantWindows == 0 && tt.wantFreeze == 0 {
+				g.Expect(got).To(BeNil())
+				return
+			}
+			g.Expect(got.Windows).To(HaveLen(tt.wantWindows))
+			g.Expect(got.FreezeWindows).To(HaveLen(tt.wantFreeze))
+		})
+	}
+}
+
+func Test_requeueForWindow(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &v2.HelmRelease{Spec: v2.HelmReleaseSpec{Interval: metav1.Duration{Duration: time.Hour}}}
+
+	got := requeueForWindow(obj, &intreconcile.WaitingForWindowError{Next: time.Now().Add(10 * time.Minute)})
+	g.Expect(got.RequeueAfter).To(BeNumerically("~", 10*time.Minute, time.Second))
+
+	got = requeueForWindow(obj, &intreconcile.WaitingForWindowError{Next: time.Now().Add(24 * time.Hour)})
+	g.Expect(got.RequeueAfter).To(Equal(time.Hour))
+
+	got = requeueForWindow(obj, &intreconcile.WaitingForWindowError{})
+	g.Expect(got.RequeueAfter).To(Equal(time.Hour))
+}
+
+func TestHelmReleaseReconciler_applyDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	team := &v2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "release"},
+		Spec: v2.HelmReleaseSpec{
+			Timeout: &metav1.Duration{Duration: time.Minute},
+			Upgrade: &v2.Upgrade{
+				Remediation: &v2.UpgradeRemediation{Retries: 0},
+			},
+		},
+	}
+	other := &v2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "release"},
+	}
+
+	// The fake client drops the fields set to their zero value, while the
+	// API server stores them as they were set.
+	var apiReads int
+	apiReader := fake.NewClientBuilder().
+		WithScheme(NewTestScheme()).
+		WithObjects(team.DeepCopy(), other.DeepCopy()).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				apiReads++
+				if err := c.Get(ctx, key, obj, opts...); err != nil {
+					return err
+				}
+				if u, ok := obj.(*unstructured.Unstructured); ok && key == client.ObjectKeyFromObject(team) {
+					return unstructured.SetNestedField(u.Object, int64(0), "spec", "upgrade", "remediation", "retries")
+				}
+				return nil
+			},
+		}).
+		Build()
+
+	r := &HelmReleaseReconciler{
+		APIReader: apiReader,
+		Client: fake.NewClientBuilder().
+			WithScheme(NewTestScheme()).
+			WithObjects(
+				&v2.HelmReleaseDefaults{
+					ObjectMeta: metav1.ObjectMeta{Name: "global"},
+					Spec: v2.HelmReleaseDefaultsSpec{
+						Timeout:    &metav1.Duration{Duration: 10 * time.Minute},
+						MaxHistory: ptr.To(10),
+						Upgrade: &v2.UpgradeDefaults{
+							Remediation: &v2.UpgradeRemediationDefaults{Retries: ptr.To(3)},
+						},
+					},
+				},
+				&v2.HelmReleaseDefaults{
+					ObjectMeta: metav1.ObjectMeta{Name: "team"},
+					Spec: v2.HelmReleaseDefaultsSpec{
+						Namespaces: []string{"team"},
+						MaxHistory: ptr.To(3),
+						DriftDetection: &v2.DriftDetection{
+							Mode: v2.DriftDetectionWarn,
+						},
+					},
+				},
+				&v2.HelmReleaseNamespaceDefaults{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "own"},
+					Spec: v2.HelmReleaseDefaultsSpec{
+						MaxHistory: ptr.To(4),
+					},
+				},
+			).
+			Build(),
+	}
+
+	// The explicit zero value of the upgrade remediation retries takes
+	// precedence over the defaults, and the namespace defaults over the
+	// defaults of the cluster.
+	obj := team.DeepCopy()
+	defaults, err := r.applyDefaults(context.TODO(), obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(intdefaults.Names(defaults)).To(Equal([]string{"global", "team", "team/own"}))
+	g.Expect(obj.GetTimeout().Duration).To(Equal(time.Minute))
+	g.Expect(obj.GetMaxHistory()).To(Equal(4))
+	g.Expect(obj.GetDriftDetection().GetMode()).To(Equal(v2.DriftDetectionWarn))
+	g.Expect(obj.GetUpgrade().GetRemediation().GetRetries()).To(Equal(0))
+	g.Expect(apiReads).To(Equal(1))
+
+	// The explicit fields are only read once per generation.
+	obj = team.DeepCopy()
+	_, err = r.applyDefaults(context.TODO(), obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(obj.GetUpgrade().GetRemediation().GetRetries()).To(Equal(0))
+	g.Expect(apiReads).To(Equal(1))
+
+	// The explicit fields of another generation are never applied.
+	obj = team.DeepCopy()
+	obj.Generation = 2
+	_, err = r.applyDefaults(context.TODO(), obj)
+	g.Expect(err).To(MatchError(ContainSubstring("does not match the observed generation 2")))
+	g.Expect(apiReads).To(Equal(2))
+
+	obj = other.DeepCopy()
+	defaults, err = r.applyDefaults(context.TODO(), obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(intdefaults.Names(defaults)).To(Equal([]string{"global"}))
+	g.Expect(obj.GetTimeout().Duration).To(Equal(10 * time.Minute))
+	g.Expect(obj.GetMaxHistory()).To(Equal(10))
+	g.Expect(obj.GetUpgrade().GetRemediation().GetRetries()).To(Equal(3))
+}
+
+func TestHelmReleaseReconciler_requestsForDefaultsChange(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &HelmReleaseReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(NewTestScheme()).
+			WithObjects(
+				&v2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "a"}},
+				&v2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}},
+			).
+			Build(),
+	}
+
+	got := r.requestsForDefaultsChange(context.TODO(), &v2.HelmReleaseDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "team"},
+		Spec:       v2.HelmReleaseDefaultsSpec{Namespaces: []string{"team"}},
+	})
+	g.Expect(got).To(ConsistOf(
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "a"}},
+	))
+
+	got = r.requestsForDefaultsChange(context.TODO(), &v2.HelmReleaseDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "global"},
+	})
+	g.Expect(got).To(HaveLen(2))
+}
+
+func TestHelmReleaseReconciler_requestsForNamespaceDefaultsChange(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &HelmReleaseReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(NewTestScheme()).
+			WithObjects(
+				&v2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "a"}},
+				&v2.HelmRelease{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}},
+			).
+			Build(),
+	}
+
+	got := r.requestsForNamespaceDefaultsChange(context.TODO(), &v2.HelmReleaseNamespaceDefaults{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team", Name: "own"},
+	})
+	g.Expect(got).To(ConsistOf(
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "team", Name: "a"}},
+	))
+}
+
+func TestHelmReleaseReconciler_checkDependenciesOfOtherKinds(t *testing.T) {
+	newHelmChart := func(namespace string, ready metav1.ConditionStatus) *sourcev1.HelmChart {
+		return &sourcev1.HelmChart{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "chart",
+				Namespace:  namespace,
+				Generation: 1,
+			},
+			Status: sourcev1.HelmChartStatus{
+				ObservedGeneration: 1,
+				Conditions: []metav1.Condition{
+					{Type: meta.ReadyCondition, Status: ready, Reason: "Mock", Message: "mock"},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		objects         []client.Object
+		dependsOn       []v2.DependencyReference
+		allowCrossNS    bool
+		wantErr         string
+		wantAccessDeny  bool
+		wantInvalidExpr bool
+	}{
+		{
+			name:    "ready dependency of other kind",
+			objects: []client.Object{newHelmChart("mock", metav1.ConditionTrue)},
+			dependsOn: []v2.DependencyReference{
+				{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.HelmChartKind, Name: "chart"},
+			},
+		},
+		{
+			name:    "not ready dependency of other kind",
+			objects: []client.Object{newHelmChart("mock", metav1.ConditionFalse)},
+			dependsOn: []v2.DependencyReference{
+				{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.HelmChartKind, Name: "chart"},
+			},
+			wantErr: "dependency HelmChart 'mock/chart' is not ready",
+		},
+		{
+			name: "missing dependency of other kind",
+			dependsOn: []v2.DependencyReference{
+				{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.HelmChartKind, Name: "chart"},
+			},
+			wantErr: "unable to get HelmChart 'mock/chart' dependency",
+		},
+		{
+			name:    "readyExpr evaluating to true",
+			objects: []client.Object{newHelmChart("mock", metav1.ConditionFalse)},
+			dependsOn: []v2.DependencyReference{
+				{
+					APIVersion: sourcev1.GroupVersion.String(),
+					Kind:       sourcev1.HelmChartKind,
+					Name:       "chart",
+					ReadyExpr:  "dep.metadata.name == 'chart' && self.metadata.name == 'dependant'",
+				},
+			},
+		},
+		{
+			name:    "readyExpr evaluating to false",
+			objects: []client.Object{newHelmChart("mock", metav1.ConditionTrue)},
+			dependsOn: []v2.DependencyReference{
+				{
+					APIVersion: sourcev1.GroupVersion.String(),
+					Kind:       sourcev1.HelmChartKind,
+					Name:       "chart",
+					ReadyExpr:  "dep.status.observedGeneration > 1",
+				},
+			},
+			wantErr: "dependency HelmChart 'mock/chart' is not ready: readyExpr evaluated to false",
+		},
+		{
+			name:    "invalid readyExpr",
+			objects: []client.Object{newHelmChart("mock", metav1.ConditionTrue)},
+			dependsOn: []v2.DependencyReference{
+				{
+					APIVersion: sourcev1.GroupVersion.String(),
+					Kind:       sourcev1.HelmChartKind,
+					Name:       "chart",
+					ReadyExpr:  "dep.status.ready ==",
+				},
+			},
+			wantErr:         "invalid readyExpr for HelmChart 'mock/chart' dependency",
+			wantInvalidExpr: true,
+		},
+		{
+			name:    "cross-namespace dependency not allowed",
+			objects: []client.Object{newHelmChart("other", metav1.ConditionTrue)},
+			dependsOn: []v2.DependencyReference{
+				{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.HelmChartKind, Name: "chart", Namespace: "other"},
+			},
+			wantErr:        "cross-namespace references are not allowed: cannot access HelmChart other/chart",
+			wantAccessDeny: true,
+		},
+		{
+			name:    "cross-namespace dependency allowed",
+			objects: []client.Object{newHelmChart("other", metav1.ConditionTrue)},
+			dependsOn: []v2.DependencyReference{
+				{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.HelmChartKind, Name: "chart", Namespace: "other"},
+			},
+			allowCrossNS: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			curAllow := intacl.AllowCrossNamespaceRef
+			intacl.AllowCrossNamespaceRef = tt.allowCrossNS
+			t.Cleanup(func() { intacl.AllowCrossNamespaceRef = curAllow })
+
+			c := fake.NewClientBuilder().
+				WithScheme(NewTestScheme()).
+				WithObjects(tt.objects...).
+				Build()
+
+			r := &HelmReleaseReconciler{
+				Client:    c,
+				APIReader: c,
+			}
+
+			obj := &v2.HelmRelease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dependant",
+					Namespace: "mock",
+				},
+				Spec: v2.HelmReleaseSpec{
+					DependsOn: tt.dependsOn,
+				},
+			}
+
+			err := r.checkDependencies(context.TODO(), obj)
+			if tt.wantErr == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+			g.Expect(runtimeacl.IsAccessDenied(err)).To(Equal(tt.wantAccessDeny))
+			g.Expect(errors.Is(err, errInvalidReadyExpr)).To(Equal(tt.wantInvalidExpr))
+		})
+	}
+}
+
+func TestHelmReleaseReconciler_requestsForDependencyKindChange(t *testing.T) {
+	g := NewWithT(t)
+
+	newHelmRelease := func(namespace, name string, dependsOn ...v2.DependencyReference) *v2.HelmRelease {
+		return &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: v2.HelmReleaseSpec{
+				DependsOn: dependsOn,
+			},
+		}
+	}
+
+	chartDep := v2.DependencyReference{APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.HelmChartKind, Name: "chart"}
+	ready := newHelmRelease("some-namespace", "ready-dependant", chartDep)
+	ready.Status.Conditions = []metav1.Condition{{Type: meta.ReadyCondition, Status: metav1.ConditionTrue}}
+
+	objects := []client.Object{
+		newHelmRelease("some-namespace", "dependant", chartDep),
+		newHelmRelease("some-other-namespace", "cross-namespace-dependant", v2.DependencyReference{
+			APIVersion: sourcev1.GroupVersion.String(), Kind: sourcev1.HelmChartKind, Name: "chart", Namespace: "some-namespace",
+		}),
+		newHelmRelease("some-other-namespace", "other-namespace-dependant", chartDep),
+		newHelmRelease("some-namespace", "helmrelease-dependant", v2.DependencyReference{Name: "chart"}),
+		ready,
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(NewTestScheme()).
+		WithIndex(&v2.HelmRelease{}, v2.DependsOnIndexKey, indexDependsOn).
+		WithObjects(objects...).
+		Build()
+
+	r := &HelmReleaseReconciler{
+		Client: c,
+	}
+
+	chart := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "chart",
+			Namespace: "some-namespace",
+		},
+	}
+
+	got := r.requestsForDependencyKindChange(sourcev1.GroupVersion.WithKind(sourcev1.HelmChartKind).GroupKind())(context.TODO(), chart)
+	g.Expect(got).To(ConsistOf(
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "some-namespace", Name: "dependant"}},
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "some-other-namespace", Name: "cross-namespace-dependant"}},
+	))
+}
+
+func TestValuesReferenceValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		references []v2.ValuesReference
+		wantErr    bool
+	}{
+		{
+			name: "valid ValuesKey",
+			references: []v2.ValuesReference{
+				{
+					Kind:      "Secret",
+					Name:      "values",
+					ValuesKey: "any-key_na.me",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid ValuesKey: empty",
+			references: []v2.ValuesReference{
+				{
+					Kind:      "Secret",
+					Name:      "values",
+					ValuesKey: "",
+				},
+			},
+			wantErr: false,
 		},
 		{
 			name: "valid ValuesKey: long",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:      "Secret",
 					Name:      "values",
@@ -3564,7 +4864,7 @@ func TestValuesReferenceValidation(t *testing.T) {
 		},
 		{
 			name: "invalid ValuesKey",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:      "Secret",
 					Name:      "values",
@@ -3575,7 +4875,7 @@ func TestValuesReferenceValidation(t *testing.T) {
 		},
 		{
 			name: "invalid ValuesKey: too long",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:      "Secret",
 					Name:      "values",
@@ -3584,9 +4884,42 @@ func TestValuesReferenceValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid Path",
+			references: []v2.ValuesReference{
+				{
+					Kind: "GitRepository",
+					Name: "values",
+					Path: "envs/prod/values.yaml",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid Path: ConfigMap reference",
+			references: []v2.ValuesReference{
+				{
+					Kind: "ConfigMap",
+					Name: "values",
+					Path: "values.yaml",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ValuesKey: OCIRepository reference",
+			references: []v2.ValuesReference{
+				{
+					Kind:      "OCIRepository",
+					Name:      "values",
+					ValuesKey: "values.yaml",
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid target path: empty",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:       "Secret",
 					Name:       "values",
@@ -3597,7 +4930,7 @@ func TestValuesReferenceValidation(t *testing.T) {
 		},
 		{
 			name: "valid target path",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:       "Secret",
 					Name:       "values",
@@ -3608,7 +4941,7 @@ func TestValuesReferenceValidation(t *testing.T) {
 		},
 		{
 			name: "valid target path: long",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:       "Secret",
 					Name:       "values",
@@ -3619,7 +4952,7 @@ func TestValuesReferenceValidation(t *testing.T) {
 		},
 		{
 			name: "invalid target path: too long",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:       "Secret",
 					Name:       "values",
@@ -3630,7 +4963,7 @@ func TestValuesReferenceValidation(t *testing.T) {
 		},
 		{
 			name: "invalid target path: opened index",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:       "Secret",
 					Name:       "values",
@@ -3642,7 +4975,7 @@ func TestValuesReferenceValidation(t *testing.T) {
 		},
 		{
 			name: "invalid target path: incorrect index syntax",
-			references: []meta.ValuesReference{
+			references: []v2.ValuesReference{
 				{
 					Kind:       "Secret",
 					Name:       "values",
@@ -64,6 +64,10 @@ func TestMain(m *testing.M) {
 		testenv.WithScheme(NewTestScheme()),
 	)
 
+	if err := testEnv.GetFieldIndexer().IndexField(testCtx, &v2.HelmRelease{}, v2.DependsOnIndexKey, indexDependsOn); err != nil {
+		panic(fmt.Sprintf("Failed to setup the dependsOn index: %v", err))
+	}
+
 	var err error
 	if testServer, err = testserver.NewTempHTTPServer(); err != nil {
 		panic(fmt.Sprintf("Failed to create a temporary storage server: %v", err))
@@ -0,0 +1,334 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decryptor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/cmd/sops/common"
+	"github.com/getsops/sops/v3/cmd/sops/formats"
+	"github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/keyservice"
+	"github.com/getsops/sops/v3/pgp"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DecryptionPGPExt is the extension of the Secret data keys holding
+	// OpenPGP private keys.
+	DecryptionPGPExt = ".asc"
+	// DecryptionAgeExt is the extension of the Secret data keys holding age
+	// identities.
+	DecryptionAgeExt = ".agekey"
+
+	// sopsMetadataKey is the key of the SOPS metadata in an encrypted
+	// document.
+	sopsMetadataKey = "sops"
+)
+
+// Decryptor decrypts SOPS encrypted values documents using age identities
+// and OpenPGP private keys. Documents encrypted with multiple key groups
+// and Shamir secret sharing are supported.
+//
+// Errors returned by the Decryptor never contain decrypted data, and are
+// safe to be used in events and conditions.
+type Decryptor struct {
+	ageIdentities age.ParsedIdentities
+	gnuPGHome     pgp.GnuPGHome
+}
+
+// NewDecryptor returns a new Decryptor without any keys.
+func NewDecryptor() *Decryptor {
+	return &Decryptor{}
+}
+
+// NewDecryptorFromSecret returns a new Decryptor configured with the age
+// identities and OpenPGP private keys from the data of the given Secret.
+// Keys ending with DecryptionAgeExt are read as age identities, keys ending
+// with DecryptionPGPExt as armored OpenPGP private keys, which are imported
+// into a temporary GnuPG home directory.
+//
+// The caller must call Cleanup when done with the Decryptor.
+func NewDecryptorFromSecret(secret *corev1.Secret) (_ *Decryptor, err error) {
+	d := &Decryptor{}
+	defer func() {
+		if err != nil {
+			_ = d.Cleanup()
+		}
+	}()
+
+	for name, value := range secret.Data {
+		switch {
+		case strings.HasSuffix(name, DecryptionAgeExt):
+			if err = d.ageIdentities.Import(string(value)); err != nil {
+				return nil, fmt.Errorf("failed to parse age identities from Secret key '%s'", name)
+			}
+		case strings.HasSuffix(name, DecryptionPGPExt):
+			if d.gnuPGHome == "" {
+				if d.gnuPGHome, err = pgp.NewGnuPGHome(); err != nil {
+					return nil, fmt.Errorf("failed to create GnuPG home directory: %w", err)
+				}
+			}
+			if err = d.gnuPGHome.Import(value); err != nil {
+				return nil, fmt.Errorf("failed to import OpenPGP keys from Secret key '%s'", name)
+			}
+		}
+	}
+	return d, nil
+}
+
+// Cleanup removes the temporary GnuPG home directory of the Decryptor, if
+// any.
+func (d *Decryptor) Cleanup() error {
+	if d.gnuPGHome == "" {
+		return nil
+	}
+	if err := os.RemoveAll(string(d.gnuPGHome)); err != nil {
+		return err
+	}
+	d.gnuPGHome = ""
+	return nil
+}
+
+// IsEncrypted returns true if the given YAML or JSON data is a SOPS
+// encrypted document.
+func IsEncrypted(data []byte) bool {
+	if !bytes.Contains(data, []byte(sopsMetadataKey)) {
+		return false
+	}
+	_, err := storeForData(data).LoadEncryptedFile(data)
+	return err == nil
+}
+
+// Decrypt decrypts the given SOPS encrypted YAML or JSON document, and
+// returns the decrypted values without the SOPS metadata.
+func (d *Decryptor) Decrypt(data []byte) (map[string]interface{}, error) {
+	b, err := d.DecryptToYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err = yaml.Unmarshal(b, &values); err != nil {
+		return nil, errors.New("failed to decode decrypted values")
+	}
+	return values, nil
+}
+
+// DecryptToYAML decrypts the given SOPS encrypted YAML or JSON document,
+// and returns the decrypted values without the SOPS metadata as YAML.
+func (d *Decryptor) DecryptToYAML(data []byte) (_ []byte, err error) {
+	// The SOPS stores panic on some malformed input, the panic value is
+	// not used as it may contain data of the document.
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("failed to decrypt document")
+		}
+	}()
+
+	tree, err := storeForData(data).LoadEncryptedFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SOPS document: %w", err)
+	}
+
+	// DecryptTree retrieves the data key from the key groups of the
+	// document, and verifies the MAC of the decrypted tree.
+	if _, err = common.DecryptTree(common.DecryptTreeOpts{
+		Tree:            &tree,
+		KeyServices:     []keyservice.KeyServiceClient{keyservice.NewCustomLocalClient(d.keyServer())},
+		DecryptionOrder: sops.DefaultDecryptionOrder,
+		Cipher:          aes.NewCipher(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to decrypt document: %w", err)
+	}
+
+	b, err := common.StoreForFormat(formats.Yaml, config.NewStoresConfig()).EmitPlainFile(tree.Branches)
+	if err != nil {
+		return nil, errors.New("failed to encode decrypted values")
+	}
+	return b, nil
+}
+
+// DecryptValues returns a copy of the given values in which every string
+// leaf holding a SOPS encrypted document is replaced with the decrypted
+// values of the document.
+func (d *Decryptor) DecryptValues(values map[string]interface{}) (map[string]interface{}, error) {
+	out, err := d.decryptLeaves(values, nil)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, nil
+	}
+	return out.(map[string]interface{}), nil
+}
+
+func (d *Decryptor) decryptLeaves(v interface{}, path []string) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v, nil
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			dv, err := d.decryptLeaves(value, append(path[:len(path):len(path)], k))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = dv
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			dv, err := d.decryptLeaves(value, append(path[:len(path):len(path)], fmt.Sprintf("[%d]", i)))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = dv
+		}
+		return out, nil
+	case string:
+		if !IsEncrypted([]byte(v)) {
+			return v, nil
+		}
+		dv, err := d.Decrypt([]byte(v))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt value at path '%s': %w", strings.Join(path, "."), err)
+		}
+		return dv, nil
+	default:
+		return v, nil
+	}
+}
+
+// keyServer returns a keyservice.KeyServiceServer which decrypts data keys
+// with the keys of the Decryptor.
+func (d *Decryptor) keyServer() keyservice.KeyServiceServer {
+	return &keyServer{ageIdentities: d.ageIdentities, gnuPGHome: d.gnuPGHome}
+}
+
+// keyServer is a keyservice.KeyServiceServer which decrypts the data keys
+// of documents with the given age identities and OpenPGP keys only. Unlike
+// the default SOPS key service, it never reads keys or credentials from the
+// environment of the controller.
+type keyServer struct {
+	keyservice.UnimplementedKeyServiceServer
+
+	ageIdentities age.ParsedIdentities
+	gnuPGHome     pgp.GnuPGHome
+}
+
+// Decrypt decrypts the data key in the request with the age identities or
+// OpenPGP keys of the server.
+func (s *keyServer) Decrypt(_ context.Context, req *keyservice.DecryptRequest) (*keyservice.DecryptResponse, error) {
+	var (
+		plaintext []byte
+		err       error
+	)
+	switch k := req.GetKey().GetKeyType().(type) {
+	case *keyservice.Key_AgeKey:
+		if len(s.ageIdentities) == 0 {
+			return nil, errors.New("no age identities configured")
+		}
+		key := &age.MasterKey{Recipient: k.AgeKey.GetRecipient()}
+		s.ageIdentities.ApplyToMasterKey(key)
+		key.EncryptedKey = string(req.GetCiphertext())
+		plaintext, err = key.Decrypt()
+	case *keyservice.Key_PgpKey:
+		if s.gnuPGHome == "" {
+			return nil, errors.New("no OpenPGP keys configured")
+		}
+		key := pgp.NewMasterKeyFromFingerprint(k.PgpKey.GetFingerprint())
+		s.gnuPGHome.ApplyToMasterKey(key)
+		key.EncryptedKey = string(req.GetCiphertext())
+		plaintext, err = key.Decrypt()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", k)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &keyservice.DecryptResponse{Plaintext: plaintext}, nil
+}
+
+// storeForData returns the SOPS store for the format of the given data,
+// which is JSON if the data is valid JSON, and YAML otherwise.
+func storeForData(data []byte) common.Store {
+	format := formats.Yaml
+	if json.Valid(data) {
+		format = formats.Json
+	}
+	return common.StoreForFormat(format, config.NewStoresConfig())
+}
+
+// decryptingClient is a client.Client which decrypts the SOPS encrypted
+// documents in the data of the ConfigMaps and Secrets it gets.
+type decryptingClient struct {
+	client.Client
+	decryptor *Decryptor
+}
+
+// NewDecryptingClient returns a client.Client which decrypts the SOPS
+// encrypted documents in the data of ConfigMaps and Secrets retrieved with
+// Get using the given Decryptor. The decrypted documents are written back
+// to the data of the object as YAML.
+func NewDecryptingClient(c client.Client, d *Decryptor) client.Client {
+	return &decryptingClient{Client: c, decryptor: d}
+}
+
+func (c *decryptingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.Client.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		for k, v := range o.Data {
+			if !IsEncrypted(v) {
+				continue
+			}
+			b, err := c.decryptor.DecryptToYAML(v)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt key '%s' of Secret '%s': %w", k, key.String(), err)
+			}
+			o.Data[k] = b
+		}
+	case *corev1.ConfigMap:
+		for k, v := range o.Data {
+			if !IsEncrypted([]byte(v)) {
+				continue
+			}
+			b, err := c.decryptor.DecryptToYAML([]byte(v))
+			if err != nil {
+				return fmt.Errorf("failed to decrypt key '%s' of ConfigMap '%s': %w", k, key.String(), err)
+			}
+			o.Data[k] = string(b)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package decryptor
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var expectedValues = map[string]interface{}{
+	"image": map[string]interface{}{
+		"repository": "example/app",
+		"tag":        "v1.0.0",
+	},
+	"replicas":         float64(2),
+	"enabled":          true,
+	"hosts":            []interface{}{"a.example.com", "b.example.com"},
+	"name_unencrypted": "plain",
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// newTestDecryptor returns a Decryptor with the keys from the given
+// testdata files.
+func newTestDecryptor(t *testing.T, keys ...string) *Decryptor {
+	t.Helper()
+	secret := &corev1.Secret{Data: map[string][]byte{}}
+	for _, k := range keys {
+		secret.Data[k] = readTestdata(t, k)
+	}
+	d, err := NewDecryptorFromSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = d.Cleanup()
+	})
+	return d
+}
+
+func TestDecryptor_Decrypt(t *testing.T) {
+	doc := string(readTestdata(t, "values.enc.yaml"))
+
+	tests := []struct {
+		name    string
+		keys    []string
+		doc     string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "decrypts with age identity",
+			keys: []string{"age.agekey"},
+			doc:  doc,
+			want: expectedValues,
+		},
+		{
+			name: "decrypts with OpenPGP key",
+			keys: []string{"pgp.asc"},
+			doc:  doc,
+			want: expectedValues,
+		},
+		{
+			name: "decrypts with any matching key",
+			keys: []string{"other.agekey", "pgp.asc"},
+			doc:  doc,
+			want: expectedValues,
+		},
+		{
+			name: "decrypts JSON document",
+			keys: []string{"age.agekey"},
+			doc:  string(readTestdata(t, "values.enc.json")),
+			want: map[string]interface{}{
+				"image": map[string]interface{}{
+					"repository": "example/app",
+					"tag":        "v1.0.0",
+				},
+				"replicas":         float64(2),
+				"name_unencrypted": "plain",
+			},
+		},
+		{
+			name: "decrypts with keys of all key groups",
+			keys: []string{"age.agekey", "pgp.asc"},
+			doc:  string(readTestdata(t, "keygroups.enc.yaml")),
+			want: expectedValues,
+		},
+		{
+			name:    "key groups below threshold",
+			keys:    []string{"age.agekey"},
+			doc:     string(readTestdata(t, "keygroups.enc.yaml")),
+			wantErr: true,
+		},
+		{
+			name:    "no matching key",
+			keys:    []string{"other.agekey"},
+			doc:     doc,
+			wantErr: true,
+		},
+		{
+			name:    "no keys",
+			doc:     doc,
+			wantErr: true,
+		},
+		{
+			name:    "modified unencrypted value",
+			keys:    []string{"age.agekey"},
+			doc:     strings.Replace(doc, "name_unencrypted: plain", "name_unencrypted: modified", 1),
+			wantErr: true,
+		},
+		{
+			name:    "moved encrypted value",
+			keys:    []string{"age.agekey"},
+			doc:     strings.Replace(doc, "    tag:", "    version:", 1),
+			wantErr: true,
+		},
+		{
+			name:    "not encrypted",
+			keys:    []string{"age.agekey"},
+			doc:     "image:\n  tag: v1.0.0\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := newTestDecryptor(t, tt.keys...).Decrypt([]byte(tt.doc))
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(got).To(BeNil())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestDecryptor_DecryptValues(t *testing.T) {
+	g := NewWithT(t)
+
+	doc := string(readTestdata(t, "values.enc.yaml"))
+
+	got, err := newTestDecryptor(t, "age.agekey").DecryptValues(map[string]interface{}{
+		"plain": "value",
+		"nested": map[string]interface{}{
+			"encrypted": doc,
+		},
+		"list": []interface{}{"a", 1},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(map[string]interface{}{
+		"plain": "value",
+		"nested": map[string]interface{}{
+			"encrypted": expectedValues,
+		},
+		"list": []interface{}{"a", 1},
+	}))
+
+	_, err = newTestDecryptor(t, "other.agekey").DecryptValues(map[string]interface{}{
+		"nested": map[string]interface{}{
+			"encrypted": doc,
+		},
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("failed to decrypt value at path 'nested.encrypted'"))
+}
+
+func TestIsEncrypted(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(IsEncrypted(readTestdata(t, "values.enc.yaml"))).To(BeTrue())
+	g.Expect(IsEncrypted(readTestdata(t, "values.enc.json"))).To(BeTrue())
+	g.Expect(IsEncrypted(readTestdata(t, "keygroups.enc.yaml"))).To(BeTrue())
+	g.Expect(IsEncrypted([]byte("sops: enabled"))).To(BeFalse())
+	g.Expect(IsEncrypted([]byte("image:\n  tag: v1.0.0\n"))).To(BeFalse())
+	g.Expect(IsEncrypted([]byte("not: [valid"))).To(BeFalse())
+}
+
+func TestNewDecryptorFromSecret(t *testing.T) {
+	t.Run("imports OpenPGP keys into temporary GnuPG home", func(t *testing.T) {
+		g := NewWithT(t)
+
+		d, err := NewDecryptorFromSecret(&corev1.Secret{
+			Data: map[string][]byte{
+				"private.asc": readTestdata(t, "pgp.asc"),
+				"ignored":     []byte("data"),
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(d.gnuPGHome)).To(BeADirectory())
+
+		g.Expect(d.Cleanup()).To(Succeed())
+		g.Expect(string(d.gnuPGHome)).To(BeEmpty())
+	})
+
+	t.Run("invalid age key", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := NewDecryptorFromSecret(&corev1.Secret{
+			Data: map[string][]byte{
+				"invalid.agekey": []byte("invalid"),
+			},
+		})
+		g.Expect(err).To(MatchError("failed to parse age identities from Secret key 'invalid.agekey'"))
+	})
+
+	t.Run("invalid OpenPGP key", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := NewDecryptorFromSecret(&corev1.Secret{
+			Data: map[string][]byte{
+				"invalid.asc": []byte("invalid"),
+			},
+		})
+		g.Expect(err).To(MatchError("failed to import OpenPGP keys from Secret key 'invalid.asc'"))
+	})
+}
+
+func TestDecryptingClient_Get(t *testing.T) {
+	g := NewWithT(t)
+
+	doc := readTestdata(t, "values.enc.yaml")
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "default"},
+			Data: map[string][]byte{
+				"values.yaml": doc,
+				"plain":       []byte("value"),
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "values", Namespace: "default"},
+			Data: map[string]string{
+				"values.yaml": string(doc),
+			},
+		},
+	).Build()
+
+	key := client.ObjectKey{Namespace: "default", Name: "values"}
+	dc := NewDecryptingClient(c, newTestDecryptor(t, "age.agekey"))
+
+	var secret corev1.Secret
+	g.Expect(dc.Get(context.TODO(), key, &secret)).To(Succeed())
+	g.Expect(string(secret.Data["values.yaml"])).To(ContainSubstring("repository: example/app"))
+	g.Expect(string(secret.Data["values.yaml"])).ToNot(ContainSubstring("sops"))
+	g.Expect(secret.Data["plain"]).To(Equal([]byte("value")))
+
+	var cm corev1.ConfigMap
+	g.Expect(dc.Get(context.TODO(), key, &cm)).To(Succeed())
+	g.Expect(cm.Data["values.yaml"]).To(ContainSubstring("tag: v1.0.0"))
+
+	// The underlying client is not mutated.
+	g.Expect(c.Get(context.TODO(), key, &secret)).To(Succeed())
+	g.Expect(secret.Data["values.yaml"]).To(Equal(doc))
+
+	err := NewDecryptingClient(c, newTestDecryptor(t, "other.agekey")).Get(context.TODO(), key, &secret)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("failed to decrypt key 'values.yaml' of Secret 'default/values'"))
+}
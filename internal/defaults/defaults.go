@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaults
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+	"helm.sh/helm/v3/pkg/chartutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	intchartutil "github.com/fluxcd/pkg/chartutil"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	intvalues "github.com/fluxcd/helm-controller/internal/values"
+)
+
+// fieldNames holds the JSON names of the HelmReleaseSpec fields which can be
+// defaulted by a HelmReleaseDefaults object.
+var fieldNames = []string{"timeout", "maxHistory", "driftDetection", "install", "upgrade"}
+
+// fields holds the HelmReleaseSpec fields which can be defaulted by a
+// HelmReleaseDefaults object.
+type fields struct {
+	Timeout        *metav1.Duration   `json:"timeout,omitempty"`
+	MaxHistory     *int               `json:"maxHistory,omitempty"`
+	DriftDetection *v2.DriftDetection `json:"driftDetection,omitempty"`
+	Install        *v2.Install        `json:"install,omitempty"`
+	Upgrade        *v2.Upgrade        `json:"upgrade,omitempty"`
+}
+
+// Select returns the defaults from the given lists which apply to the given
+// namespace, in order of increasing precedence: defaults for all namespaces
+// before defaults for specific namespaces, followed by the defaults of the
+// namespace itself, each ordered by name. The namespace defaults are
+// returned as their v2.HelmReleaseDefaults equivalent.
+func Select(namespace string, list []v2.HelmReleaseDefaults, namespaced []v2.HelmReleaseNamespaceDefaults) []v2.HelmReleaseDefaults {
+	var result []v2.HelmReleaseDefaults
+	for _, d := range list {
+		if d.AppliesTo(namespace) {
+			result = append(result, d)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].IsGlobal() != result[j].IsGlobal() {
+			return result[i].IsGlobal()
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	var own []v2.HelmReleaseDefaults
+	for i := range namespaced {
+		if namespaced[i].GetNamespace() == namespace {
+			own = append(own, namespaced[i].ToHelmReleaseDefaults())
+		}
+	}
+	sort.SliceStable(own, func(i, j int) bool {
+		return own[i].Name < own[j].Name
+	})
+	return append(result, own...)
+}
+
+// Names returns the names of the given defaults.
+func Names(defaults []v2.HelmReleaseDefaults) []string {
+	if len(defaults) == 0 {
+		return nil
+	}
+	names := make([]string, len(defaults))
+	for i, d := range defaults {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// ExplicitFields returns the fields of the given spec which can be defaulted,
+// as set in the spec of the HelmRelease stored in the cluster.
+func ExplicitFields(spec map[string]interface{}) map[string]interface{} {
+	explicit := make(map[string]interface{}, len(fieldNames))
+	for _, k := range fieldNames {
+		if v, ok := spec[k]; ok {
+			explicit[k] = v
+		}
+	}
+	return explicit
+}
+
+// Apply merges the given defaults into the spec of the HelmRelease, in order
+// of increasing precedence. The given spec is the spec of the HelmRelease as
+// stored in the cluster, as the typed spec does not tell a field set to its
+// zero value apart from an unset field. Fields which are set in it take
+// precedence over all defaults, including fields set to their zero value.
+func Apply(obj *v2.HelmRelease, spec map[string]interface{}, defaults []v2.HelmReleaseDefaults) error {
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	var merged map[string]interface{}
+	for _, d := range defaults {
+		m, err := toMap(d.Spec)
+		if err != nil {
+			return fmt.Errorf("failed to apply defaults from '%s': %w", d.Name, err)
+		}
+		delete(m, "namespaces")
+		merged = intchartutil.MergeMaps(merged, m)
+	}
+
+	merged = intchartutil.MergeMaps(merged, ExplicitFields(spec))
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("failed to apply defaults: %w", err)
+	}
+	var result fields
+	if err = json.Unmarshal(b, &result); err != nil {
+		return fmt.Errorf("failed to apply defaults: %w", err)
+	}
+	obj.Spec.Timeout = result.Timeout
+	obj.Spec.MaxHistory = result.MaxHistory
+	obj.Spec.DriftDetection = result.DriftDetection
+	obj.Spec.Install = result.Install
+	obj.Spec.Upgrade = result.Upgrade
+	return nil
+}
+
+// ConfigDigest returns the digest of the given values, the merge strategies
+// of the given values references and the defaults. Without merge strategies
+// and defaults, this equals the digest of the values.
+func ConfigDigest(algo digest.Algorithm, values chartutil.Values, refs []v2.ValuesReference, defaults []v2.HelmReleaseDefaults) digest.Digest {
+	d := intchartutil.DigestValues(algo, values)
+	strategies := intvalues.Digest(algo, refs)
+	if (len(defaults) == 0 && strategies == "") || d == "" {
+		return d
+	}
+
+	digester := algo.Digester()
+	enc := json.NewEncoder(digester.Hash())
+	if err := enc.Encode(d); err != nil {
+		return ""
+	}
+	if strategies != "" {
+		if err := enc.Encode(strategies); err != nil {
+			return ""
+		}
+	}
+	for _, obj := range defaults {
+		if err := enc.Encode(obj.Name); err != nil {
+			return ""
+		}
+		if err := enc.Encode(obj.Spec); err != nil {
+			return ""
+		}
+	}
+	return digester.Digest()
+}
+
+// VerifyConfig verifies the digest of the given values, merge strategies of
+// the values references and defaults against the provided digest.
+func VerifyConfig(d digest.Digest, values chartutil.Values, refs []v2.ValuesReference, defaults []v2.HelmReleaseDefaults) bool {
+	if len(defaults) == 0 && intvalues.Digest(digest.Canonical, refs) == "" {
+		return intchartutil.VerifyValues(d, values)
+	}
+	if d.Validate() != nil {
+		return false
+	}
+	return ConfigDigest(d.Algorithm(), values, refs, defaults) == d
+}
+
+// toMap returns the JSON representation of the given object as a map.
+func toMap(obj interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
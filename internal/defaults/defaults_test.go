@@ -0,0 +1,298 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaults
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	"helm.sh/helm/v3/pkg/chartutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	intchartutil "github.com/fluxcd/pkg/chartutil"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func TestSelect(t *testing.T) {
+	g := NewWithT(t)
+
+	list := []v2.HelmReleaseDefaults{
+		{ObjectMeta: metav1.ObjectMeta{Name: "b-namespace"}, Spec: v2.HelmReleaseDefaultsSpec{Namespaces: []string{"apps"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b-global"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other"}, Spec: v2.HelmReleaseDefaultsSpec{Namespaces: []string{"other"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "a-namespace"}, Spec: v2.HelmReleaseDefaultsSpec{Namespaces: []string{"other", "apps"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "a-global"}},
+	}
+
+	namespaced := []v2.HelmReleaseNamespaceDefaults{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "b"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "a"}},
+	}
+
+	g.Expect(Names(Select("apps", list, nil))).To(Equal([]string{"a-global", "b-global", "a-namespace", "b-namespace"}))
+	g.Expect(Names(Select("apps", list, namespaced))).To(Equal([]string{"a-global", "b-global", "a-namespace", "b-namespace", "apps/a", "apps/b"}))
+	g.Expect(Names(Select("default", list, namespaced))).To(Equal([]string{"a-global", "b-global"}))
+	g.Expect(Select("default", nil, nil)).To(BeEmpty())
+
+	selected := Select("apps", nil, namespaced)
+	g.Expect(selected).To(HaveLen(2))
+	g.Expect(selected[0].Spec.Namespaces).To(Equal([]string{"apps"}))
+}
+
+func TestApply(t *testing.T) {
+	global := v2.HelmReleaseDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "global"},
+		Spec: v2.HelmReleaseDefaultsSpec{
+			Timeout:    &metav1.Duration{Duration: 10 * time.Minute},
+			MaxHistory: ptr.To(10),
+			DriftDetection: &v2.DriftDetection{
+				Mode: v2.DriftDetectionWarn,
+			},
+			Install: &v2.InstallDefaults{
+				Remediation: &v2.InstallRemediationDefaults{Retries: ptr.To(3)},
+			},
+			Upgrade: &v2.UpgradeDefaults{
+				Remediation: &v2.UpgradeRemediationDefaults{
+					Retries:              ptr.To(3),
+					RemediateLastFailure: ptr.To(true),
+				},
+			},
+		},
+	}
+	namespace := v2.HelmReleaseDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "namespace"},
+		Spec: v2.HelmReleaseDefaultsSpec{
+			Namespaces: []string{"default"},
+			DriftDetection: &v2.DriftDetection{
+				Mode: v2.DriftDetectionEnabled,
+			},
+			Upgrade: &v2.UpgradeDefaults{
+				Remediation: &v2.UpgradeRemediationDefaults{
+					Retries: ptr.To(0),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		spec     v2.HelmReleaseSpec
+		raw      map[string]interface{}
+		defaults []v2.HelmReleaseDefaults
+		want     v2.HelmReleaseSpec
+	}{
+		{
+			name: "without defaults",
+			spec: v2.HelmReleaseSpec{
+				Timeout: &metav1.Duration{Duration: time.Minute},
+			},
+			want: v2.HelmReleaseSpec{
+				Timeout: &metav1.Duration{Duration: time.Minute},
+			},
+		},
+		{
+			name:     "fills unset fields",
+			defaults: []v2.HelmReleaseDefaults{global},
+			want: v2.HelmReleaseSpec{
+				Timeout:    &metav1.Duration{Duration: 10 * time.Minute},
+				MaxHistory: ptr.To(10),
+				DriftDetection: &v2.DriftDetection{
+					Mode: v2.DriftDetectionWarn,
+				},
+				Install: &v2.Install{
+					Remediation: &v2.InstallRemediation{Retries: 3},
+				},
+				Upgrade: &v2.Upgrade{
+					Remediation: &v2.UpgradeRemediation{
+						Retries:              3,
+						RemediateLastFailure: ptr.To(true),
+					},
+				},
+			},
+		},
+		{
+			name: "explicit fields take precedence",
+			spec: v2.HelmReleaseSpec{
+				Interval:   metav1.Duration{Duration: time.Minute},
+				MaxHistory: ptr.To(0),
+				DriftDetection: &v2.DriftDetection{
+					Ignore: []v2.IgnoreRule{{Paths: []string{"/spec/replicas"}}},
+				},
+				Upgrade: &v2.Upgrade{
+					DisableWait: true,
+					Remediation: &v2.UpgradeRemediation{
+						RemediateLastFailure: ptr.To(false),
+					},
+				},
+			},
+			raw: map[string]interface{}{
+				"interval":   "1m",
+				"maxHistory": int64(0),
+				"driftDetection": map[string]interface{}{
+					"ignore": []interface{}{
+						map[string]interface{}{"paths": []interface{}{"/spec/replicas"}},
+					},
+				},
+				"upgrade": map[string]interface{}{
+					"disableWait": true,
+					"remediation": map[string]interface{}{
+						"remediateLastFailure": false,
+					},
+				},
+			},
+			defaults: []v2.HelmReleaseDefaults{global},
+			want: v2.HelmReleaseSpec{
+				Interval:   metav1.Duration{Duration: time.Minute},
+				Timeout:    &metav1.Duration{Duration: 10 * time.Minute},
+				MaxHistory: ptr.To(0),
+				DriftDetection: &v2.DriftDetection{
+					Mode:   v2.DriftDetectionWarn,
+					Ignore: []v2.IgnoreRule{{Paths: []string{"/spec/replicas"}}},
+				},
+				Install: &v2.Install{
+					Remediation: &v2.InstallRemediation{Retries: 3},
+				},
+				Upgrade: &v2.Upgrade{
+					DisableWait: true,
+					Remediation: &v2.UpgradeRemediation{
+						Retries:              3,
+						RemediateLastFailure: ptr.To(false),
+					},
+				},
+			},
+		},
+		{
+			name:     "later defaults take precedence",
+			defaults: []v2.HelmReleaseDefaults{global, namespace},
+			want: v2.HelmReleaseSpec{
+				Timeout:    &metav1.Duration{Duration: 10 * time.Minute},
+				MaxHistory: ptr.To(10),
+				DriftDetection: &v2.DriftDetection{
+					Mode: v2.DriftDetectionEnabled,
+				},
+				Install: &v2.Install{
+					Remediation: &v2.InstallRemediation{Retries: 3},
+				},
+				Upgrade: &v2.Upgrade{
+					Remediation: &v2.UpgradeRemediation{
+						Retries:              0,
+						RemediateLastFailure: ptr.To(true),
+					},
+				},
+			},
+		},
+		{
+			name: "explicit zero values take precedence",
+			spec: v2.HelmReleaseSpec{
+				Install: &v2.Install{
+					Remediation: &v2.InstallRemediation{},
+				},
+				Upgrade: &v2.Upgrade{
+					Remediation: &v2.UpgradeRemediation{},
+				},
+			},
+			raw: map[string]interface{}{
+				"install": map[string]interface{}{
+					"remediation": map[string]interface{}{"retries": int64(0)},
+				},
+				"upgrade": map[string]interface{}{
+					"remediation": map[string]interface{}{
+						"retries":              int64(0),
+						"remediateLastFailure": false,
+					},
+				},
+			},
+			defaults: []v2.HelmReleaseDefaults{global},
+			want: v2.HelmReleaseSpec{
+				Timeout:    &metav1.Duration{Duration: 10 * time.Minute},
+				MaxHistory: ptr.To(10),
+				DriftDetection: &v2.DriftDetection{
+					Mode: v2.DriftDetectionWarn,
+				},
+				Install: &v2.Install{
+					Remediation: &v2.InstallRemediation{Retries: 0},
+				},
+				Upgrade: &v2.Upgrade{
+					Remediation: &v2.UpgradeRemediation{
+						Retries:              0,
+						RemediateLastFailure: ptr.To(false),
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &v2.HelmRelease{Spec: tt.spec}
+			g.Expect(Apply(obj, tt.raw, tt.defaults)).To(Succeed())
+			g.Expect(obj.Spec).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestConfigDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	values := chartutil.Values{"foo": "bar"}
+	defaults := []v2.HelmReleaseDefaults{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "global"},
+			Spec:       v2.HelmReleaseDefaultsSpec{MaxHistory: ptr.To(10)},
+		},
+	}
+
+	g.Expect(ConfigDigest(digest.Canonical, values, nil, nil)).To(Equal(intchartutil.DigestValues(digest.Canonical, values)))
+
+	d := ConfigDigest(digest.Canonical, values, nil, defaults)
+	g.Expect(d).ToNot(BeEmpty())
+	g.Expect(d).ToNot(Equal(intchartutil.DigestValues(digest.Canonical, values)))
+	g.Expect(VerifyConfig(d, values, nil, defaults)).To(BeTrue())
+	g.Expect(VerifyConfig(d, values, nil, nil)).To(BeFalse())
+	g.Expect(VerifyConfig(d, chartutil.Values{"foo": "baz"}, nil, defaults)).To(BeFalse())
+
+	defaults[0].Spec.MaxHistory = ptr.To(5)
+	g.Expect(VerifyConfig(d, values, nil, defaults)).To(BeFalse())
+}
+
+func TestConfigDigest_MergeStrategies(t *testing.T) {
+	g := NewWithT(t)
+
+	values := chartutil.Values{"foo": "bar"}
+	refs := []v2.ValuesReference{
+		{Kind: "ConfigMap", Name: "values"},
+	}
+
+	// References without merge strategies do not change the digest.
+	g.Expect(ConfigDigest(digest.Canonical, values, refs, nil)).To(Equal(intchartutil.DigestValues(digest.Canonical, values)))
+
+	refs[0].MergeStrategy = v2.ValuesMergeStrategyAppendLists
+	d := ConfigDigest(digest.Canonical, values, refs, nil)
+	g.Expect(d).ToNot(BeEmpty())
+	g.Expect(d).ToNot(Equal(intchartutil.DigestValues(digest.Canonical, values)))
+	g.Expect(VerifyConfig(d, values, refs, nil)).To(BeTrue())
+	g.Expect(VerifyConfig(d, values, nil, nil)).To(BeFalse())
+
+	refs[0].MergeStrategy = v2.ValuesMergeStrategyJSONMergePatch
+	g.Expect(VerifyConfig(d, values, refs, nil)).To(BeFalse())
+}
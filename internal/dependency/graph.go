@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dependency
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CycleError is returned by Resolve when the dependency graph contains a
+// cycle.
+type CycleError struct {
+	// Cycle is the path of the cycle, starting and ending with the same node.
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle detected: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// MissingError is returned by Resolve when one or more nodes of the
+// dependency graph do not exist.
+type MissingError struct {
+	// Missing holds the nodes which do not exist, in the order they were
+	// encountered.
+	Missing []string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("dependencies not found: %s", strings.Join(e.Missing, ", "))
+}
+
+// DependenciesFunc returns the direct dependencies of the given node. If the
+// node does not exist, it returns false.
+type DependenciesFunc[T comparable] func(node T) ([]T, bool, error)
+
+// Resolve walks the transitive dependencies of root, and returns them in the
+// order in which they have to become ready: every node is preceded by its own
+// dependencies. The root itself is not included in the result.
+//
+// If a cycle is detected, a *CycleError is returned with the full path of the
+// cycle. If dependencies do not exist, the resolved order of the existing
+// dependencies is returned together with a *MissingError. Any error returned
+// by deps is returned as-is.
+func Resolve[T comparable](root T, deps DependenciesFunc[T]) ([]T, error) {
+	r := &resolver[T]{
+		deps:  deps,
+		state: make(map[T]visitState),
+	}
+	if err := r.visit(root); err != nil {
+		return nil, err
+	}
+	if len(r.missing) > 0 {
+		return r.order, &MissingError{Missing: r.missing}
+	}
+	return r.order, nil
+}
+
+type visitState int
+
+const (
+	visiting visitState = iota + 1
+	visited
+)
+
+type resolver[T comparable] struct {
+	deps    DependenciesFunc[T]
+	state   map[T]visitState
+	path    []T
+	order   []T
+	missing []string
+}
+
+func (r *resolver[T]) visit(node T) error {
+	switch r.state[node] {
+	case visited:
+		return nil
+	case visiting:
+		return r.cycleError(node)
+	}
+
+	deps, ok, err := r.deps(node)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		r.state[node] = visited
+		r.missing = append(r.missing, fmt.Sprint(node))
+		return nil
+	}
+
+	r.state[node] = visiting
+	r.path = append(r.path, node)
+	for _, d := range deps {
+		if err := r.visit(d); err != nil {
+			return err
+		}
+	}
+	r.path = r.path[:len(r.path)-1]
+	r.state[node] = visited
+
+	if len(r.path) > 0 {
+		r.order = append(r.order, node)
+	}
+	return nil
+}
+
+// cycleError returns a CycleError for the path from the first occurrence of
+// node on the current path back to node.
+func (r *resolver[T]) cycleError(node T) error {
+	var cycle []string
+	for i := len(r.path) - 1; i >= 0; i-- {
+		if r.path[i] == node {
+			for _, n := range r.path[i:] {
+				cycle = append(cycle, fmt.Sprint(n))
+			}
+			break
+		}
+	}
+	return &CycleError{Cycle: append(cycle, fmt.Sprint(node))}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dependency
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		graph       map[string][]string
+		root        string
+		want        []string
+		wantCycle   []string
+		wantMissing []string
+	}{
+		{
+			name:  "no dependencies",
+			graph: map[string][]string{"a": nil},
+			root:  "a",
+			want:  nil,
+		},
+		{
+			name: "transitive dependencies",
+			graph: map[string][]string{
+				"a": {"b", "c"},
+				"b": {"d"},
+				"c": {"d"},
+				"d": nil,
+			},
+			root: "a",
+			want: []string{"d", "b", "c"},
+		},
+		{
+			name: "direct cycle",
+			graph: map[string][]string{
+				"a": {"b"},
+				"b": {"a"},
+			},
+			root:      "a",
+			wantCycle: []string{"a", "b", "a"},
+		},
+		{
+			name: "self reference",
+			graph: map[string][]string{
+				"a": {"a"},
+			},
+			root:      "a",
+			wantCycle: []string{"a", "a"},
+		},
+		{
+			name: "cycle in transitive dependencies",
+			graph: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {"d"},
+				"d": {"b"},
+			},
+			root:      "a",
+			wantCycle: []string{"b", "c", "d", "b"},
+		},
+		{
+			name: "missing dependencies",
+			graph: map[string][]string{
+				"a": {"b", "c"},
+				"b": {"d"},
+			},
+			root:        "a",
+			want:        []string{"b"},
+			wantMissing: []string{"d", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := Resolve(tt.root, func(node string) ([]string, bool, error) {
+				deps, ok := tt.graph[node]
+				return deps, ok, nil
+			})
+
+			switch {
+			case tt.wantCycle != nil:
+				var cycleErr *CycleError
+				g.Expect(errors.As(err, &cycleErr)).To(BeTrue())
+				g.Expect(cycleErr.Cycle).To(Equal(tt.wantCycle))
+				g.Expect(got).To(BeNil())
+			case tt.wantMissing != nil:
+				var missingErr *MissingError
+				g.Expect(errors.As(err, &missingErr)).To(BeTrue())
+				g.Expect(missingErr.Missing).To(Equal(tt.wantMissing))
+				g.Expect(got).To(Equal(tt.want))
+			default:
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(got).To(Equal(tt.want))
+			}
+		})
+	}
+
+	t.Run("returns lookup error", func(t *testing.T) {
+		g := NewWithT(t)
+
+		lookupErr := errors.New("lookup error")
+		_, err := Resolve("a", func(node string) ([]string, bool, error) {
+			if node == "a" {
+				return []string{"b"}, true, nil
+			}
+			return nil, false, lookupErr
+		})
+		g.Expect(err).To(MatchError(lookupErr))
+	})
+}
+
+func TestCycleError_Error(t *testing.T) {
+	g := NewWithT(t)
+
+	err := &CycleError{Cycle: []string{"default/a", "default/b", "default/a"}}
+	g.Expect(err.Error()).To(Equal("dependency cycle detected: default/a -> default/b -> default/a"))
+}
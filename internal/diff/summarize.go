@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	extjsondiff "github.com/wI2L/jsondiff"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fluxcd/pkg/ssa/jsondiff"
@@ -128,6 +129,27 @@ func SummarizeDiffSetBrief(set jsondiff.DiffSet, include ...jsondiff.DiffType) s
 	return strings.TrimSuffix(summary.String(), ", ")
 }
 
+// SummarizeOrphans returns a summary of the given orphaned objects.
+//
+// The summary is a string with one line per object, in the format:
+// `Kind/namespace/name orphaned`
+//
+// For example:
+//
+//	ConfigMap/default/hello-world orphaned
+//	ClusterRole/hello-world orphaned
+func SummarizeOrphans(orphans []*unstructured.Unstructured) string {
+	var summary strings.Builder
+	for _, obj := range orphans {
+		if obj == nil {
+			continue
+		}
+		writeResourceName(obj, &summary)
+		summary.WriteString(" orphaned\n")
+	}
+	return strings.TrimSpace(summary.String())
+}
+
 // ResourceName returns the resource name in the format `kind/namespace/name`.
 func ResourceName(obj client.Object) string {
 	var summary strings.Builder
@@ -206,6 +206,38 @@ func TestSummarizeDiffSetBrief(t *testing.T) {
 	}
 }
 
+func TestSummarizeOrphans(t *testing.T) {
+	orphans := []*unstructured.Unstructured{
+		{
+			Object: map[string]interface{}{
+				"kind": "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":      "config",
+					"namespace": "namespace-1",
+				},
+			},
+		},
+		nil,
+		{
+			Object: map[string]interface{}{
+				"kind": "ClusterRole",
+				"metadata": map[string]interface{}{
+					"name": "role",
+				},
+			},
+		},
+	}
+
+	want := `ConfigMap/namespace-1/config orphaned
+ClusterRole/role orphaned`
+	if got := SummarizeOrphans(orphans); got != want {
+		t.Errorf("SummarizeOrphans() = %v, want %v", got, want)
+	}
+	if got := SummarizeOrphans(nil); got != "" {
+		t.Errorf("SummarizeOrphans() = %v, want empty string", got)
+	}
+}
+
 func TestResourceName(t *testing.T) {
 	tests := []struct {
 		name     string
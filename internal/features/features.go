@@ -58,6 +58,11 @@ const (
 	// without the need to upgrade the Helm release. But it can be disabled to
 	// avoid potential abuse of the adoption mechanism.
 	AdoptLegacyReleases = "AdoptLegacyReleases"
+
+	// StrictPostBuildSubstitutions controls whether the post build
+	// substitutions should fail if a variable without a default value is
+	// declared in the values, but is missing from the input vars.
+	StrictPostBuildSubstitutions = "StrictPostBuildSubstitutions"
 )
 
 var features = map[string]bool{
@@ -79,6 +84,9 @@ var features = map[string]bool{
 	// AdoptLegacyReleases
 	// opt-out from v0.37
 	AdoptLegacyReleases: true,
+	// StrictPostBuildSubstitutions
+	// opt-in from v1.2
+	StrictPostBuildSubstitutions: false,
 }
 
 // FeatureGates contains a list of all supported feature gates and
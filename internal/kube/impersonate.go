@@ -18,6 +18,7 @@ package kube
 
 import (
 	"fmt"
+	"strings"
 
 	"k8s.io/client-go/rest"
 )
@@ -30,6 +31,9 @@ var DefaultServiceAccountName string
 // It formats into `system:serviceaccount:namespace:name`.
 const userNameFormat = "system:serviceaccount:%s:%s"
 
+// userNamePrefix is the prefix of a system service account user name string.
+const userNamePrefix = "system:serviceaccount:"
+
 // SetImpersonationConfig configures the provided service account name if
 // given, or the DefaultServiceAccountName as a fallback if set. It returns
 // the configured impersonation username, or an empty string.
@@ -45,3 +49,15 @@ func SetImpersonationConfig(cfg *rest.Config, namespace, serviceAccount string)
 	}
 	return ""
 }
+
+// ServiceAccountGroups returns the groups the Kubernetes API server assigns
+// to the given service account user name, as configured by
+// SetImpersonationConfig. It returns nil if the user name is not a service
+// account user name.
+func ServiceAccountGroups(username string) []string {
+	namespace, name, ok := strings.Cut(strings.TrimPrefix(username, userNamePrefix), ":")
+	if !strings.HasPrefix(username, userNamePrefix) || !ok || namespace == "" || name == "" {
+		return nil
+	}
+	return []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"}
+}
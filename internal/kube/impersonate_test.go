@@ -73,3 +73,14 @@ func TestSetImpersonationConfig(t *testing.T) {
 		g.Expect(cfg.Impersonate.UserName).To(BeEmpty())
 	})
 }
+
+func TestServiceAccountGroups(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(ServiceAccountGroups("system:serviceaccount:test:account")).To(Equal([]string{
+		"system:serviceaccounts", "system:serviceaccounts:test", "system:authenticated",
+	}))
+	g.Expect(ServiceAccountGroups("system:serviceaccount:test")).To(BeNil())
+	g.Expect(ServiceAccountGroups("system:serviceaccount::account")).To(BeNil())
+	g.Expect(ServiceAccountGroups("jane")).To(BeNil())
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package loader
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	_ "crypto/sha256"
 	_ "crypto/sha512"
 	"errors"
@@ -26,6 +28,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/go-retryablehttp"
 	digestlib "github.com/opencontainers/go-digest"
@@ -39,6 +44,10 @@ const (
 	// used to override the hostname of the source-controller from which
 	// the chart is usually downloaded.
 	envSourceControllerLocalhost = "SOURCE_CONTROLLER_LOCALHOST"
+
+	// maxArtifactFileSize is the maximum size of a file read from a source
+	// artifact.
+	maxArtifactFileSize = 5 << 20
 )
 
 var (
@@ -54,6 +63,30 @@ var (
 // digest before loading the chart. It returns the loaded chart.Chart, or an
 // error. The error may be of type ErrIntegrity if the integrity check fails.
 func SecureLoadChartFromURL(client *retryablehttp.Client, URL, digest string) (*chart.Chart, error) {
+	c, err := secureDownload(client, "chart", URL, digest)
+	if err != nil {
+		return nil, err
+	}
+	return loader.LoadArchive(c)
+}
+
+// SecureLoadFileFromArtifactURL attempts to download a source artifact from
+// the given URL using the provided client. The retrieved data is verified
+// against the given digest before the file with the given name is read from
+// the gzipped tarball. It returns the contents of the file, or an error.
+// The error may be of type ErrIntegrity if the integrity check fails, or
+// of type ErrFileNotFound if the artifact or the file does not exist.
+func SecureLoadFileFromArtifactURL(client *retryablehttp.Client, URL, digest, name string) ([]byte, error) {
+	a, err := secureDownload(client, "artifact", URL, digest)
+	if err != nil {
+		return nil, err
+	}
+	return readFileFromTarball(a, name)
+}
+
+// secureDownload downloads the data from the given URL using the provided
+// client, and verifies it against the given digest.
+func secureDownload(client *retryablehttp.Client, what, URL, digest string) (*bytes.Buffer, error) {
 	URL, err := overwriteHostname(URL, os.Getenv(envSourceControllerLocalhost))
 	if err != nil {
 		return nil, err
@@ -71,13 +104,13 @@ func SecureLoadChartFromURL(client *retryablehttp.Client, URL, digest string) (*
 		}
 		_ = resp.Body.Close()
 		if resp.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("failed to download chart from '%s': %w", URL, ErrFileNotFound)
+			return nil, fmt.Errorf("failed to download %s from '%s': %w", what, URL, ErrFileNotFound)
 		}
-		return nil, fmt.Errorf("failed to download chart from '%s' (status: %s)", URL, resp.Status)
+		return nil, fmt.Errorf("failed to download %s from '%s' (status: %s)", what, URL, resp.Status)
 	}
 
-	var c bytes.Buffer
-	if err := copyAndVerify(digest, resp.Body, &c); err != nil {
+	var b bytes.Buffer
+	if err := copyAndVerify(digest, resp.Body, &b); err != nil {
 		_ = resp.Body.Close()
 		return nil, err
 	}
@@ -85,7 +118,44 @@ func SecureLoadChartFromURL(client *retryablehttp.Client, URL, digest string) (*
 	if err := resp.Body.Close(); err != nil {
 		return nil, err
 	}
-	return loader.LoadArchive(&c)
+	return &b, nil
+}
+
+// readFileFromTarball returns the contents of the regular file with the
+// given name in the gzipped tarball.
+func readFileFromTarball(r io.Reader, name string) ([]byte, error) {
+	name = cleanPath(name)
+
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read artifact: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || cleanPath(hdr.Name) != name {
+			continue
+		}
+		if hdr.Size > maxArtifactFileSize {
+			return nil, fmt.Errorf("file '%s' in artifact exceeds the maximum size of %d bytes", name, maxArtifactFileSize)
+		}
+		return io.ReadAll(io.LimitReader(tr, maxArtifactFileSize))
+	}
+	return nil, fmt.Errorf("failed to read file '%s' from artifact: %w", name, ErrFileNotFound)
+}
+
+// cleanPath returns the shortest relative slash-separated path equivalent
+// to the given path.
+func cleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(p)), "/")
 }
 
 // copyAndVerify copies the contents of reader to writer, and verifies the
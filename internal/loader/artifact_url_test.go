@@ -17,7 +17,9 @@ limitations under the License.
 package loader
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"errors"
 	"io"
 	"net/http"
@@ -113,6 +115,90 @@ func TestSecureLoadChartFromURL(t *testing.T) {
 	})
 }
 
+func TestSecureLoadFileFromArtifactURL(t *testing.T) {
+	g := NewWithT(t)
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	for name, content := range map[string]string{
+		"./values.yaml":         "replicaCount: 1\n",
+		"envs/prod/values.yaml": "replicaCount: 3\n",
+	} {
+		g.Expect(tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})).To(Succeed())
+		_, err := tw.Write([]byte(content))
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	g.Expect(tw.Close()).To(Succeed())
+	g.Expect(gzw.Close()).To(Succeed())
+	b := buf.Bytes()
+	digest := digestlib.SHA256.FromBytes(b)
+
+	const artifactPath = "/artifact.tar.gz"
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == artifactPath {
+			res.WriteHeader(http.StatusOK)
+			_, _ = res.Write(b)
+			return
+		}
+		res.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	artifactURL := server.URL + artifactPath
+
+	client := retryablehttp.NewClient()
+	client.Logger = nil
+	client.RetryMax = 2
+
+	t.Run("loads file from artifact", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := SecureLoadFileFromArtifactURL(client, artifactURL, digest.String(), "envs/prod/values.yaml")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(got)).To(Equal("replicaCount: 3\n"))
+	})
+
+	t.Run("loads file with cleaned path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := SecureLoadFileFromArtifactURL(client, artifactURL, digest.String(), "/values.yaml")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(got)).To(Equal("replicaCount: 1\n"))
+	})
+
+	t.Run("file not found error on missing file", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := SecureLoadFileFromArtifactURL(client, artifactURL, digest.String(), "envs/dev/values.yaml")
+		g.Expect(errors.Is(err, ErrFileNotFound)).To(BeTrue())
+		g.Expect(got).To(BeNil())
+	})
+
+	t.Run("error on artifact digest mismatch", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := SecureLoadFileFromArtifactURL(client, artifactURL, digestlib.SHA256.FromString("invalid").String(), "values.yaml")
+		g.Expect(errors.Is(err, ErrIntegrity)).To(BeTrue())
+		g.Expect(got).To(BeNil())
+	})
+
+	t.Run("file not found error on 404", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := SecureLoadFileFromArtifactURL(client, server.URL+"/not-found.tar.gz", digest.String(), "values.yaml")
+		g.Expect(errors.Is(err, ErrFileNotFound)).To(BeTrue())
+		g.Expect(got).To(BeNil())
+	})
+}
+
 func Test_copyAndVerify(t *testing.T) {
 	g := NewWithT(t)
 
@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outputs
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/release"
+)
+
+// Build evaluates the values of the given outputs against the Helm release,
+// and returns the selected data indexed by key.
+//
+// Values with source v2.OutputSourceResource are evaluated against the
+// object as it currently exists in the cluster, which is retrieved using
+// the provided client. The object must be part of the manifest of the
+// release. The data of a Secret is decoded before the expression is
+// evaluated, and may only be written to outputs of kind
+// v2.OutputsKindSecret.
+func Build(ctx context.Context, c client.Client, rls *helmrelease.Release, outputs v2.Outputs) (map[string]string, error) {
+	obs := release.ObserveRelease(rls)
+	values := outputs.Values
+
+	var (
+		computed  map[string]interface{}
+		chartMeta map[string]interface{}
+		manifest  []*unstructured.Unstructured
+	)
+
+	data := make(map[string]string, len(values))
+	for _, v := range values {
+		var (
+			input interface{}
+			err   error
+		)
+		switch v.Source {
+		case v2.OutputSourceValues:
+			if computed == nil {
+				if computed, err = computeValues(rls, obs.Config); err != nil {
+					return nil, err
+				}
+			}
+			input = computed
+		case v2.OutputSourceChart:
+			if chartMeta == nil {
+				if chartMeta, err = toMap(obs.ChartMetadata); err != nil {
+					return nil, fmt.Errorf("failed to convert chart metadata: %w", err)
+				}
+			}
+			input = chartMeta
+		case v2.OutputSourceResource:
+			if v.Resource == nil {
+				return nil, fmt.Errorf("output '%s' has source %s but no resource", v.Key, v.Source)
+			}
+			if isSecret(*v.Resource) && outputs.GetKind() != v2.OutputsKindSecret {
+				return nil, fmt.Errorf("output '%s': data of Secret '%s' can only be written to outputs of kind %s",
+					v.Key, v.Resource.Name, v2.OutputsKindSecret)
+			}
+			if manifest == nil {
+				if manifest, err = ssautil.ReadObjects(strings.NewReader(obs.Manifest)); err != nil {
+					return nil, fmt.Errorf("failed to read objects from release manifest: %w", err)
+				}
+			}
+			obj, err := getResource(ctx, c, obs, manifest, *v.Resource)
+			if err != nil {
+				return nil, fmt.Errorf("output '%s': %w", v.Key, err)
+			}
+			input = obj.Object
+		default:
+			return nil, fmt.Errorf("output '%s' has unsupported source '%s'", v.Key, v.Source)
+		}
+
+		out, err := evaluate(v.Key, v.JSONPath, input)
+		if err != nil {
+			return nil, fmt.Errorf("output '%s': %w", v.Key, err)
+		}
+		data[v.Key] = out
+	}
+	return data, nil
+}
+
+// computeValues returns the values of the release coalesced with the
+// defaults of the chart.
+func computeValues(rls *helmrelease.Release, config map[string]interface{}) (map[string]interface{}, error) {
+	if rls.Chart == nil {
+		return config, nil
+	}
+	values, err := helmchartutil.CoalesceValues(rls.Chart, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute release values: %w", err)
+	}
+	return values, nil
+}
+
+// getResource returns the object matching the reference from the cluster,
+// after confirming it is part of the release manifest.
+func getResource(ctx context.Context, c client.Client, obs release.Observation,
+	manifest []*unstructured.Unstructured, ref v2.OutputResourceReference) (*unstructured.Unstructured, error) {
+
+	gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+
+	namespace := ref.Namespace
+	namespaced, err := c.IsObjectNamespaced(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine if %s is namespace scoped: %w", ref.Kind, err)
+	}
+	switch {
+	case namespaced && namespace == "":
+		namespace = obs.Namespace
+	case !namespaced:
+		namespace = ""
+	}
+
+	if !inManifest(manifest, gvk, ref.Name, namespace, obs.Namespace) {
+		return nil, fmt.Errorf("%s '%s' is not part of the release", ref.Kind,
+			types.NamespacedName{Namespace: namespace, Name: ref.Name}.String())
+	}
+
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, u); err != nil {
+		return nil, fmt.Errorf("failed to get %s '%s': %w", ref.Kind,
+			types.NamespacedName{Namespace: namespace, Name: ref.Name}.String(), err)
+	}
+
+	if isSecret(ref) {
+		if err := decodeSecretData(u); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+// inManifest returns true if an object with the given GroupKind, name and
+// namespace is part of the manifest. Objects without a namespace in the
+// manifest are assumed to be in the release namespace.
+func inManifest(manifest []*unstructured.Unstructured, gvk schema.GroupVersionKind, name, namespace, releaseNamespace string) bool {
+	for _, obj := range manifest {
+		objGVK := obj.GroupVersionKind()
+		if objGVK.GroupKind() != gvk.GroupKind() || obj.GetName() != name {
+			continue
+		}
+		objNamespace := obj.GetNamespace()
+		if objNamespace == "" && namespace != "" {
+			objNamespace = releaseNamespace
+		}
+		if objNamespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecret returns true if the given reference is to a Secret.
+func isSecret(ref v2.OutputResourceReference) bool {
+	gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+	return gvk.Group == "" && gvk.Kind == "Secret"
+}
+
+// decodeSecretData replaces the base64 encoded data of the Secret with the
+// decoded values.
+func decodeSecretData(u *unstructured.Unstructured) error {
+	data, _, err := unstructured.NestedStringMap(u.Object, "data")
+	if err != nil {
+		return fmt.Errorf("failed to read Secret data: %w", err)
+	}
+	decoded := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("failed to decode Secret data key '%s': %w", k, err)
+		}
+		decoded[k] = string(b)
+	}
+	u.Object["data"] = decoded
+	return nil
+}
+
+// evaluate evaluates the JSONPath expression against the input, and
+// returns the result as a string.
+func evaluate(name, expr string, input interface{}) (string, error) {
+	jp := jsonpath.New(name)
+	if err := jp.Parse(expr); err != nil {
+		return "", fmt.Errorf("failed to parse JSONPath '%s': %w", expr, err)
+	}
+	var buf bytes.Buffer
+	if err := jp.Execute(&buf, input); err != nil {
+		return "", fmt.Errorf("failed to evaluate JSONPath '%s': %w", expr, err)
+	}
+	return buf.String(), nil
+}
+
+// toMap converts the given value to a map using its JSON representation.
+func toMap(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
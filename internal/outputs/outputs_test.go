@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outputs
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+const testManifest = `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: release-ns
+data:
+  password: c2VjcmV0
+`
+
+func TestBuild(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	rls := &helmrelease.Release{
+		Name:      "app",
+		Namespace: "release-ns",
+		Version:   1,
+		Info:      &helmrelease.Info{Status: helmrelease.StatusDeployed},
+		Chart: &helmchart.Chart{
+			Metadata: &helmchart.Metadata{
+				Name:       "app",
+				Version:    "1.0.0",
+				AppVersion: "2.3.4",
+			},
+			Values: map[string]interface{}{
+				"replicas": 1,
+				"image": map[string]interface{}{
+					"repository": "example/app",
+					"tag":        "latest",
+				},
+			},
+		},
+		Config: map[string]interface{}{
+			"image": map[string]interface{}{
+				"tag": "v1",
+			},
+		},
+		Manifest: testManifest,
+	}
+
+	objects := []runtime.Object{
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "release-ns"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.0.0.10"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "release-ns"},
+			Data:       map[string][]byte{"password": []byte("secret")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "release-ns"},
+			Data:       map[string]string{"foo": "bar"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		kind    string
+		values  []v2.OutputValue
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "computed values",
+			values: []v2.OutputValue{
+				{Key: "repository", Source: v2.OutputSourceValues, JSONPath: "{.image.repository}"},
+				{Key: "tag", Source: v2.OutputSourceValues, JSONPath: "{.image.tag}"},
+			},
+			want: map[string]string{
+				"repository": "example/app",
+				"tag":        "v1",
+			},
+		},
+		{
+			name: "chart metadata",
+			values: []v2.OutputValue{
+				{Key: "appVersion", Source: v2.OutputSourceChart, JSONPath: "{.appVersion}"},
+			},
+			want: map[string]string{
+				"appVersion": "2.3.4",
+			},
+		},
+		{
+			name: "resources of the release",
+			kind: v2.OutputsKindSecret,
+			values: []v2.OutputValue{
+				{
+					Key:      "clusterIP",
+					Source:   v2.OutputSourceResource,
+					Resource: &v2.OutputResourceReference{APIVersion: "v1", Kind: "Service", Name: "app"},
+					JSONPath: "{.spec.clusterIP}",
+				},
+				{
+					Key:      "password",
+					Source:   v2.OutputSourceResource,
+					Resource: &v2.OutputResourceReference{APIVersion: "v1", Kind: "Secret", Name: "app", Namespace: "release-ns"},
+					JSONPath: "{.data.password}",
+				},
+			},
+			want: map[string]string{
+				"clusterIP": "10.0.0.10",
+				"password":  "secret",
+			},
+		},
+		{
+			name: "Secret data written to ConfigMap",
+			kind: v2.OutputsKindConfigMap,
+			values: []v2.OutputValue{
+				{
+					Key:      "password",
+					Source:   v2.OutputSourceResource,
+					Resource: &v2.OutputResourceReference{APIVersion: "v1", Kind: "Secret", Name: "app", Namespace: "release-ns"},
+					JSONPath: "{.data.password}",
+				},
+			},
+			wantErr: "output 'password': data of Secret 'app' can only be written to outputs of kind Secret",
+		},
+		{
+			name: "resource not part of the release",
+			values: []v2.OutputValue{
+				{
+					Key:      "foo",
+					Source:   v2.OutputSourceResource,
+					Resource: &v2.OutputResourceReference{APIVersion: "v1", Kind: "ConfigMap", Name: "other"},
+					JSONPath: "{.data.foo}",
+				},
+			},
+			wantErr: "output 'foo': ConfigMap 'release-ns/other' is not part of the release",
+		},
+		{
+			name: "missing key",
+			values: []v2.OutputValue{
+				{Key: "missing", Source: v2.OutputSourceValues, JSONPath: "{.does.not.exist}"},
+			},
+			wantErr: "output 'missing': failed to evaluate JSONPath '{.does.not.exist}'",
+		},
+		{
+			name: "invalid expression",
+			values: []v2.OutputValue{
+				{Key: "invalid", Source: v2.OutputSourceChart, JSONPath: "{.name"},
+			},
+			wantErr: "output 'invalid': failed to parse JSONPath '{.name'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).
+				WithRuntimeObjects(objects...).
+				Build()
+
+			got, err := Build(context.TODO(), c, rls, v2.Outputs{Kind: tt.kind, Values: tt.values})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
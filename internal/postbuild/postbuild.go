@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postbuild
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fluxcd/pkg/envsubst"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+// varNameRegex is the regular expression a variable name must match.
+var varNameRegex = regexp.MustCompile("^[_[:alpha:]][_[:alpha:][:digit:]]*$")
+
+// LoadVariables returns the variables declared in the given v2.PostBuild.
+// The variables of the SubstituteFrom references are read in order from the
+// ConfigMaps and Secrets in the given namespace, and the variables of
+// Substitute are applied last.
+func LoadVariables(ctx context.Context, c client.Client, namespace string, postBuild *v2.PostBuild) (map[string]string, error) {
+	vars := make(map[string]string)
+	if postBuild == nil {
+		return vars, nil
+	}
+
+	for _, ref := range postBuild.SubstituteFrom {
+		data, err := referenceData(ctx, c, namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range data {
+			vars[k] = v
+		}
+	}
+	for k, v := range postBuild.Substitute {
+		vars[k] = strings.TrimSpace(v)
+	}
+
+	for k := range vars {
+		if !varNameRegex.MatchString(k) {
+			return nil, fmt.Errorf("'%s' var name is invalid, must match '%s'", k, varNameRegex.String())
+		}
+	}
+	return vars, nil
+}
+
+// referenceData returns the data of the ConfigMap or Secret the given
+// reference points to. If the reference is optional and the object does
+// not exist, it returns nil.
+func referenceData(ctx context.Context, c client.Client, namespace string, ref v2.SubstituteReference) (map[string]string, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+	switch ref.Kind {
+	case "ConfigMap":
+		var cm corev1.ConfigMap
+		if err := c.Get(ctx, key, &cm); err != nil {
+			if ref.Optional && apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("substitute from 'ConfigMap/%s' error: %w", ref.Name, err)
+		}
+		return cm.Data, nil
+	case "Secret":
+		var secret corev1.Secret
+		if err := c.Get(ctx, key, &secret); err != nil {
+			if ref.Optional && apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("substitute from 'Secret/%s' error: %w", ref.Name, err)
+		}
+		data := make(map[string]string, len(secret.Data))
+		for k, v := range secret.Data {
+			data[k] = string(v)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported substitute reference kind '%s'", ref.Kind)
+	}
+}
+
+// SubstituteVariables returns a copy of the given values in which the
+// ${var} expressions in all string values are replaced with the given
+// variables, according to the rules of envsubst.Eval.
+//
+// When strict is true, an error is returned for any variable which is not
+// set and has no default value. Otherwise, the expression is replaced with
+// an empty string.
+func SubstituteVariables(values map[string]interface{}, vars map[string]string, strict bool) (map[string]interface{}, error) {
+	mapping := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok || !strict
+	}
+	out, err := substitute(values, nil, mapping)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, nil
+	}
+	return out.(map[string]interface{}), nil
+}
+
+func substitute(v interface{}, path []string, mapping func(string) (string, bool)) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v, nil
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			sv, err := substitute(value, append(path[:len(path):len(path)], k), mapping)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = sv
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			sv, err := substitute(value, append(path[:len(path):len(path)], fmt.Sprintf("[%d]", i)), mapping)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = sv
+		}
+		return out, nil
+	case string:
+		if !strings.Contains(v, "$") {
+			return v, nil
+		}
+		sv, err := envsubst.Eval(v, mapping)
+		if err != nil {
+			return nil, fmt.Errorf("failed to substitute variables in value at path '%s': %w", strings.Join(path, "."), err)
+		}
+		return sv, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postbuild
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func TestLoadVariables(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+
+	objects := []runtime.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-vars", Namespace: "default"},
+			Data: map[string]string{
+				"cluster": "prod",
+				"region":  "eu-west-1",
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-secrets", Namespace: "default"},
+			Data: map[string][]byte{
+				"region": []byte("us-east-1"),
+				"token":  []byte("secret"),
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid-vars", Namespace: "default"},
+			Data: map[string]string{
+				"not-valid": "value",
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		postBuild *v2.PostBuild
+		want      map[string]string
+		wantErr   string
+	}{
+		{
+			name: "merges references in order and substitute last",
+			postBuild: &v2.PostBuild{
+				Substitute: map[string]string{"cluster": " staging "},
+				SubstituteFrom: []v2.SubstituteReference{
+					{Kind: "ConfigMap", Name: "cluster-vars"},
+					{Kind: "Secret", Name: "cluster-secrets"},
+				},
+			},
+			want: map[string]string{
+				"cluster": "staging",
+				"region":  "us-east-1",
+				"token":   "secret",
+			},
+		},
+		{
+			name: "ignores missing optional reference",
+			postBuild: &v2.PostBuild{
+				SubstituteFrom: []v2.SubstituteReference{
+					{Kind: "Secret", Name: "missing", Optional: true},
+				},
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "missing reference",
+			postBuild: &v2.PostBuild{
+				SubstituteFrom: []v2.SubstituteReference{
+					{Kind: "ConfigMap", Name: "missing"},
+				},
+			},
+			wantErr: "substitute from 'ConfigMap/missing' error",
+		},
+		{
+			name: "invalid variable name",
+			postBuild: &v2.PostBuild{
+				SubstituteFrom: []v2.SubstituteReference{
+					{Kind: "ConfigMap", Name: "invalid-vars"},
+				},
+			},
+			wantErr: "'not-valid' var name is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+			got, err := LoadVariables(context.TODO(), c, "default", tt.postBuild)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestSubstituteVariables(t *testing.T) {
+	vars := map[string]string{
+		"cluster": "prod",
+		"domain":  "example.com",
+	}
+
+	t.Run("substitutes string values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		values := map[string]interface{}{
+			"replicas": 2,
+			"ingress": map[string]interface{}{
+				"enabled": true,
+				"hosts":   []interface{}{"app.${cluster}.${domain}", "${missing:=fallback}.${domain}"},
+			},
+			"clusterName": "${cluster}",
+		}
+		got, err := SubstituteVariables(values, vars, false)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(Equal(map[string]interface{}{
+			"replicas": 2,
+			"ingress": map[string]interface{}{
+				"enabled": true,
+				"hosts":   []interface{}{"app.prod.example.com", "fallback.example.com"},
+			},
+			"clusterName": "prod",
+		}))
+		g.Expect(values["clusterName"]).To(Equal("${cluster}"))
+	})
+
+	t.Run("fails on unset variable in strict mode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		values := map[string]interface{}{
+			"ingress": map[string]interface{}{
+				"hosts": []interface{}{"${region}.${domain}"},
+			},
+		}
+		_, err := SubstituteVariables(values, vars, true)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("path 'ingress.hosts.[0]'"))
+		g.Expect(err.Error()).To(ContainSubstring("variable not set (strict mode)"))
+		g.Expect(err.Error()).To(ContainSubstring("region"))
+	})
+
+	t.Run("nil values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		got, err := SubstituteVariables(nil, vars, true)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(BeNil())
+	})
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+// HelmReleaseReadyPredicate detects a v2.HelmRelease becoming Ready for its
+// current generation.
+type HelmReleaseReadyPredicate struct {
+	predicate.Funcs
+}
+
+func (HelmReleaseReadyPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+
+	oldObj, ok := e.ObjectOld.(*v2.HelmRelease)
+	if !ok {
+		return false
+	}
+
+	newObj, ok := e.ObjectNew.(*v2.HelmRelease)
+	if !ok {
+		return false
+	}
+
+	return !isHelmReleaseReady(oldObj) && isHelmReleaseReady(newObj)
+}
+
+func (HelmReleaseReadyPredicate) Create(e event.CreateEvent) bool {
+	return false
+}
+
+func (HelmReleaseReadyPredicate) Delete(e event.DeleteEvent) bool {
+	return false
+}
+
+// isHelmReleaseReady returns true if the v2.HelmRelease has been reconciled
+// for its current generation, and is marked as Ready.
+func isHelmReleaseReady(obj *v2.HelmRelease) bool {
+	return obj.Generation == obj.Status.ObservedGeneration && conditions.IsReady(obj)
+}
+
+// HelmReleaseDeletedPredicate detects the deletion of a v2.HelmRelease.
+type HelmReleaseDeletedPredicate struct {
+	predicate.Funcs
+}
+
+func (HelmReleaseDeletedPredicate) Create(e event.CreateEvent) bool {
+	return false
+}
+
+func (HelmReleaseDeletedPredicate) Update(e event.UpdateEvent) bool {
+	return false
+}
+
+func (HelmReleaseDeletedPredicate) Delete(e event.DeleteEvent) bool {
+	_, ok := e.Object.(*v2.HelmRelease)
+	return ok
+}
+
+func (HelmReleaseDeletedPredicate) Generic(e event.GenericEvent) bool {
+	return false
+}
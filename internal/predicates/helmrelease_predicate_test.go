@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package predicates
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func TestHelmReleaseReadyPredicate_Update(t *testing.T) {
+	newHelmRelease := func(generation, observedGeneration int64, status metav1.ConditionStatus) *v2.HelmRelease {
+		obj := &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{Generation: generation},
+			Status:     v2.HelmReleaseStatus{ObservedGeneration: observedGeneration},
+		}
+		if status != "" {
+			obj.Status.Conditions = []metav1.Condition{{Type: meta.ReadyCondition, Status: status}}
+		}
+		return obj
+	}
+
+	tests := []struct {
+		name string
+		old  client.Object
+		new  client.Object
+		want bool
+	}{
+		{
+			name: "not ready to ready",
+			old:  newHelmRelease(1, 1, metav1.ConditionFalse),
+			new:  newHelmRelease(1, 1, metav1.ConditionTrue),
+			want: true,
+		},
+		{
+			name: "unknown to ready",
+			old:  newHelmRelease(1, 1, metav1.ConditionUnknown),
+			new:  newHelmRelease(1, 1, metav1.ConditionTrue),
+			want: true,
+		},
+		{
+			name: "no conditions to ready",
+			old:  newHelmRelease(1, 0, ""),
+			new:  newHelmRelease(1, 1, metav1.ConditionTrue),
+			want: true,
+		},
+		{
+			name: "ready for previous generation to ready",
+			old:  newHelmRelease(2, 1, metav1.ConditionTrue),
+			new:  newHelmRelease(2, 2, metav1.ConditionTrue),
+			want: true,
+		},
+		{
+			name: "ready to ready",
+			old:  newHelmRelease(1, 1, metav1.ConditionTrue),
+			new:  newHelmRelease(1, 1, metav1.ConditionTrue),
+			want: false,
+		},
+		{
+			name: "ready to not ready",
+			old:  newHelmRelease(1, 1, metav1.ConditionTrue),
+			new:  newHelmRelease(1, 1, metav1.ConditionFalse),
+			want: false,
+		},
+		{
+			name: "ready for previous generation",
+			old:  newHelmRelease(1, 1, metav1.ConditionFalse),
+			new:  newHelmRelease(2, 1, metav1.ConditionTrue),
+			want: false,
+		},
+		{name: "old not a HelmRelease", old: &unstructured.Unstructured{}, new: newHelmRelease(1, 1, metav1.ConditionTrue), want: false},
+		{name: "new not a HelmRelease", old: newHelmRelease(1, 1, metav1.ConditionFalse), new: &unstructured.Unstructured{}, want: false},
+		{name: "old nil", old: nil, new: newHelmRelease(1, 1, metav1.ConditionTrue), want: false},
+		{name: "new nil", old: newHelmRelease(1, 1, metav1.ConditionFalse), new: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gomega.NewWithT(t)
+
+			so := HelmReleaseReadyPredicate{}
+			e := event.UpdateEvent{
+				ObjectOld: tt.old,
+				ObjectNew: tt.new,
+			}
+			g.Expect(so.Update(e)).To(gomega.Equal(tt.want))
+		})
+	}
+}
+
+func TestHelmReleaseDeletedPredicate(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	so := HelmReleaseDeletedPredicate{}
+	obj := &v2.HelmRelease{}
+
+	g.Expect(so.Create(event.CreateEvent{Object: obj})).To(gomega.BeFalse())
+	g.Expect(so.Update(event.UpdateEvent{ObjectOld: obj, ObjectNew: obj})).To(gomega.BeFalse())
+	g.Expect(so.Generic(event.GenericEvent{Object: obj})).To(gomega.BeFalse())
+	g.Expect(so.Delete(event.DeleteEvent{Object: obj})).To(gomega.BeTrue())
+	g.Expect(so.Delete(event.DeleteEvent{Object: &unstructured.Unstructured{}})).To(gomega.BeFalse())
+}
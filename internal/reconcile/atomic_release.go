@@ -28,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/runtime/conditions"
@@ -41,6 +42,7 @@ import (
 	"github.com/fluxcd/helm-controller/internal/digest"
 	interrors "github.com/fluxcd/helm-controller/internal/errors"
 	"github.com/fluxcd/helm-controller/internal/postrender"
+	intvalues "github.com/fluxcd/helm-controller/internal/values"
 )
 
 // OwnedConditions is a list of Condition types owned by the HelmRelease object.
@@ -48,6 +50,9 @@ var OwnedConditions = []string{
 	v2.ReleasedCondition,
 	v2.RemediatedCondition,
 	v2.TestSuccessCondition,
+	v2.AwaitingApprovalCondition,
+	v2.PendingUpgradeCondition,
+	v2.DeprecatedAPIsCondition,
 	meta.ReconcilingCondition,
 	meta.ReadyCondition,
 	meta.StalledCondition,
@@ -98,7 +103,9 @@ var (
 // Any returned error other than ErrExceededMaxRetries should be retried by the
 // caller as soon as possible, preferably with a backoff strategy. In case of
 // ErrMustRequeue, it is advised to requeue the object outside the interval
-// to ensure continued progress.
+// to ensure continued progress. In case of a WaitingForWindowError, the next
+// action is not allowed to run by the Request.Schedule, and the object should
+// be requeued at the start of the next maintenance window.
 //
 // The caller is expected to patch the object one last time with the
 // Request.Object result to persist the final observation. As there is an
@@ -109,6 +116,7 @@ var (
 // documentation.
 type AtomicRelease struct {
 	patchHelper   *patch.SerialPatcher
+	client        client.Client
 	configFactory *action.ConfigFactory
 	eventRecorder record.EventRecorder
 	strategy      releaseStrategy
@@ -117,9 +125,10 @@ type AtomicRelease struct {
 
 // NewAtomicRelease returns a new AtomicRelease reconciler configured with the
 // provided values.
-func NewAtomicRelease(patchHelper *patch.SerialPatcher, cfg *action.ConfigFactory, recorder record.EventRecorder, fieldManager string) *AtomicRelease {
+func NewAtomicRelease(patchHelper *patch.SerialPatcher, client client.Client, cfg *action.ConfigFactory, recorder record.EventRecorder, fieldManager string) *AtomicRelease {
 	return &AtomicRelease{
 		patchHelper:   patchHelper,
+		client:        client,
 		eventRecorder: recorder,
 		configFactory: cfg,
 		strategy:      &cleanReleaseStrategy{},
@@ -219,6 +228,19 @@ func (r *AtomicRelease) Reconcile(ctx context.Context, req *Request) error {
 						// Update the post-renderers digest if the post-renderers exist.
 						req.Object.Status.ObservedPostRenderersDigest = postrender.Digest(digest.Canonical, req.Object.Spec.PostRenderers).String()
 					}
+					// Update the values merge strategies digest.
+					req.Object.Status.ObservedValuesMergeDigest = intvalues.Digest(digest.Canonical, req.Object.Spec.ValuesFrom).String()
+
+					// Remove any upgrade plan, as the release is in-sync.
+					conditions.Delete(req.Object, v2.AwaitingApprovalCondition)
+					conditions.Delete(req.Object, v2.PendingUpgradeCondition)
+					req.Object.Status.HeldChartVersion = ""
+					req.Object.Status.ApprovedUpgradeDigest = ""
+					if req.Object.Status.UpgradePlan != nil {
+						if err := deleteUpgradePlan(ctx, r.client, req.Object); err != nil {
+							return err
+						}
+					}
 				}
 
 				return nil
@@ -239,6 +261,14 @@ func (r *AtomicRelease) Reconcile(ctx context.Context, req *Request) error {
 				return nil
 			}
 
+			// If the next action must wait for a maintenance window, we are
+			// done for now...
+			if err = WaitForWindow(req.Object, req.Schedule, next, time.Now()); err != nil {
+				log.Info(err.Error())
+				conditions.Delete(req.Object, meta.ReconcilingCondition)
+				return err
+			}
+
 			// Mark the release as reconciling before we attempt to run the action.
 			// This to show continuous progress, as Helm actions can be long-running.
 			reconcilingMsg := fmt.Sprintf("Running '%s' action with timeout of %s",
@@ -267,6 +297,13 @@ func (r *AtomicRelease) Reconcile(ctx context.Context, req *Request) error {
 				return err
 			}
 
+			// A plan does not change the release, and there is nothing left
+			// to do until the planned action is performed.
+			if next.Type() == ReconcilerTypePlan {
+				conditions.Delete(req.Object, meta.ReconcilingCondition)
+				return nil
+			}
+
 			// If we must stop after running the action, we are done for now...
 			if r.strategy.MustStop(next.Type(), previous) {
 				log.V(logger.DebugLevel).Info(fmt.Sprintf(
@@ -353,35 +390,40 @@ func (r *AtomicRelease) actionForState(ctx context.Context, req *Request, state
 		if req.Object.GetInstall().GetRemediation().RetriesExhausted(req.Object) {
 			if forceRequested {
 				log.Info(msgWithReason("forcing install while out of retries", "force requested through annotation"))
-				return NewInstall(r.configFactory, r.eventRecorder), nil
+				return r.pendingInstall(ctx, req, forceRequested), nil
 			}
 
 			return nil, fmt.Errorf("%w: cannot install release", ErrExceededMaxRetries)
 		}
 
-		return NewInstall(r.configFactory, r.eventRecorder), nil
+		return r.pendingInstall(ctx, req, forceRequested), nil
 	case ReleaseStatusUnmanaged:
 		log.Info(msgWithReason("release not managed by controller", state.Reason))
 
-		// Clear the history as we can no longer rely on it.
-		req.Object.Status.ClearHistory()
-
-		return NewUpgrade(r.configFactory, r.eventRecorder), nil
+		next := r.pendingUpgrade(ctx, req, forceRequested)
+		if _, ok := next.(*Upgrade); ok {
+			// Clear the history as we can no longer rely on it. This is
+			// only done once the upgrade is performed, as the upgrade
+			// policy is determined using the latest release in the history.
+			req.Object.Status.ClearHistory()
+		}
+		return next, nil
 	case ReleaseStatusOutOfSync:
 		log.Info(msgWithReason("release out-of-sync with desired state", state.Reason))
 
 		if req.Object.GetUpgrade().GetRemediation().RetriesExhausted(req.Object) {
 			if forceRequested {
 				log.Info(msgWithReason("forcing upgrade while out of retries", "force requested through annotation"))
-				return NewUpgrade(r.configFactory, r.eventRecorder), nil
+				return r.pendingUpgrade(ctx, req, forceRequested), nil
 			}
 
 			return nil, fmt.Errorf("%w: cannot upgrade release", ErrExceededMaxRetries)
 		}
 
-		return NewUpgrade(r.configFactory, r.eventRecorder), nil
+		return r.pendingUpgrade(ctx, req, forceRequested), nil
 	case ReleaseStatusDrifted:
-		log.Info(msgWithReason("detected changes in cluster state", diff.SummarizeDiffSetBrief(state.Diff)))
+		log.Info(msgWithReason("detected changes in cluster state",
+			fmt.Sprintf("%s, orphaned: %d", diff.SummarizeDiffSetBrief(state.Diff), len(state.Orphans))))
 		for _, change := range state.Diff {
 			switch change.Type {
 			case jsondiff.DiffTypeCreate:
@@ -397,14 +439,25 @@ func (r *AtomicRelease) actionForState(ctx context.Context, req *Request, state
 					"patch", patch)
 			}
 		}
+		for _, orphan := range state.Orphans {
+			log.V(logger.DebugLevel).Info("resource orphaned",
+				"resource", diff.ResourceName(orphan))
+		}
 
 		r.eventRecorder.Eventf(req.Object, corev1.EventTypeWarning, "DriftDetected",
 			"Cluster state of release %s has drifted from the desired state:\n%s",
-			req.Object.Status.History.Latest().FullReleaseName(), diff.SummarizeDiffSet(state.Diff),
+			req.Object.Status.History.Latest().FullReleaseName(),
+			strings.TrimSpace(diff.SummarizeDiffSet(state.Diff)+"\n"+diff.SummarizeOrphans(state.Orphans)),
 		)
 
-		if req.Object.GetDriftDetection().GetMode() == v2.DriftDetectionEnabled {
-			return NewCorrectClusterDrift(r.configFactory, r.eventRecorder, state.Diff, kube.ManagedFieldsManager), nil
+		if driftDetection := req.Object.GetDriftDetection(); driftDetection.GetMode() == v2.DriftDetectionEnabled {
+			// Orphaned objects can only be corrected by pruning them, without
+			// pruning they are reported but there is nothing to correct.
+			if !state.Diff.HasChanges() && !driftDetection.Prune {
+				return nil, nil
+			}
+			return NewCorrectClusterDrift(r.configFactory, r.eventRecorder, state.Diff, state.Orphans,
+				kube.ManagedFieldsManager), nil
 		}
 
 		return nil, nil
@@ -435,7 +488,7 @@ func (r *AtomicRelease) actionForState(ctx context.Context, req *Request, state
 		// upgrade the release to see if that fixes the problem.
 		if remediation == nil {
 			log.V(logger.DebugLevel).Info("no active remediation strategy")
-			return NewUpgrade(r.configFactory, r.eventRecorder), nil
+			return r.pendingUpgrade(ctx, req, forceRequested), nil
 		}
 
 		// If there is no failure count, the conditions under which the failure
@@ -445,14 +498,14 @@ func (r *AtomicRelease) actionForState(ctx context.Context, req *Request, state
 		// attempted again.
 		if remediation.GetFailureCount(req.Object) <= 0 {
 			log.Info("release conditions have changed since last failure")
-			return NewUpgrade(r.configFactory, r.eventRecorder), nil
+			return r.pendingUpgrade(ctx, req, forceRequested), nil
 		}
 
 		// If the force annotation is set, we can attempt to upgrade the release
 		// without any further checks.
 		if forceRequested {
 			log.Info(msgWithReason("forcing upgrade for failed release", "force requested through annotation"))
-			return NewUpgrade(r.configFactory, r.eventRecorder), nil
+			return r.pendingUpgrade(ctx, req, forceRequested), nil
 		}
 
 		// We have exhausted the number of retries for the remediation
@@ -481,7 +534,7 @@ func (r *AtomicRelease) actionForState(ctx context.Context, req *Request, state
 					// If the rollback target is in any way corrupt,
 					// the most correct remediation is to reattempt the upgrade.
 					log.Info(msgWithReason("unable to verify previous release in storage to roll back to", err.Error()))
-					return NewUpgrade(r.configFactory, r.eventRecorder), nil
+					return r.pendingUpgrade(ctx, req, forceRequested), nil
 				}
 
 				// This may be a temporary error, return it to retry.
@@ -498,6 +551,74 @@ func (r *AtomicRelease) actionForState(ctx context.Context, req *Request, state
 	}
 }
 
+// pendingUpgrade returns the ActionReconciler for a pending upgrade of the
+// release. The upgrade is planned instead of performed when it is held by
+// the gates of heldRelease.
+func (r *AtomicRelease) pendingUpgrade(ctx context.Context, req *Request, forceRequested bool) ActionReconciler {
+	if plan := r.heldRelease(ctx, req, forceRequested, "upgrade"); plan != nil {
+		return plan
+	}
+	return NewUpgrade(r.configFactory, r.eventRecorder)
+}
+
+// pendingInstall returns the ActionReconciler for a pending Helm install of
+// the given Request. The install is planned instead of performed when it is
+// held by the gates of heldRelease, in the same way as an upgrade.
+func (r *AtomicRelease) pendingInstall(ctx context.Context, req *Request, forceRequested bool) ActionReconciler {
+	if plan := r.heldRelease(ctx, req, forceRequested, "install"); plan != nil {
+		return plan
+	}
+	return NewInstall(r.configFactory, r.eventRecorder)
+}
+
+// heldRelease returns a PlanUpgrade ActionReconciler when the pending Helm
+// action of the release is held, or nil when it can be performed. The action
+// is held by the upgrade policy of the object, when the upgrade mode of the
+// object is v2.UpgradeModePlan, or when the upgrade requires approval and has
+// not been approved. A force request overrides the upgrade policy and mode,
+// but never approves an upgrade.
+func (r *AtomicRelease) heldRelease(ctx context.Context, req *Request, forceRequested bool, action string) ActionReconciler {
+	log := ctrl.LoggerFrom(ctx)
+
+	if policy := req.Object.GetUpgrade().Policy; policy != nil && !forceRequested {
+		if gate := upgradePolicyGate(req, *policy); gate != nil {
+			if gate.Reason == v2.DowngradeRefusedReason {
+				req.Object.Status.HeldChartVersion = ""
+				log.Info(msgWithReason("planning "+action, "downgrade is refused by upgrade policy"))
+				return NewPlanUpgrade(r.client, r.configFactory, r.eventRecorder, r.fieldManager, gate)
+			}
+
+			// The upgrade request only releases the chart version which was
+			// held when the request was made.
+			held, target := req.Object.Status.HeldChartVersion, req.Chart.Metadata.Version
+			req.Object.Status.HeldChartVersion = target
+			if released := v2.ShouldHandleUpgradeRequest(req.Object); !released || held != target {
+				log.Info(msgWithReason("planning "+action, action+" is held by upgrade policy"))
+				return NewPlanUpgrade(r.client, r.configFactory, r.eventRecorder, r.fieldManager, gate)
+			}
+			log.Info(msgWithReason(action+" released", "upgrade requested through annotation"))
+		}
+	}
+	conditions.Delete(req.Object, v2.PendingUpgradeCondition)
+	req.Object.Status.HeldChartVersion = ""
+
+	if req.Object.GetUpgradeMode() == v2.UpgradeModePlan && !forceRequested {
+		log.Info(msgWithReason("planning "+action, "upgrade mode is plan"))
+		return NewPlanUpgrade(r.client, r.configFactory, r.eventRecorder, r.fieldManager, nil)
+	}
+
+	if req.Object.GetUpgrade().RequiresApproval() {
+		if !v2.ShouldHandleApproveRequest(req.Object, upgradeDigest(req)) {
+			log.Info(msgWithReason("planning "+action, action+" requires approval"))
+			return NewPlanUpgrade(r.client, r.configFactory, r.eventRecorder, r.fieldManager, approvalGate(req))
+		}
+		log.Info(msgWithReason(action+" approved", "approve requested through annotation"))
+		conditions.Delete(req.Object, v2.AwaitingApprovalCondition)
+	}
+
+	return nil
+}
+
 func (r *AtomicRelease) Name() string {
 	return "atomic-release"
 }
@@ -177,7 +177,7 @@ func TestAtomicRelease_Reconcile(t *testing.T) {
 			Chart:  testutil.BuildChart(testutil.ChartWithTestHook()),
 			Values: nil,
 		}
-		g.Expect(NewAtomicRelease(patchHelper, cfg, recorder, testFieldManager).Reconcile(context.TODO(), req)).ToNot(HaveOccurred())
+		g.Expect(NewAtomicRelease(patchHelper, client, cfg, recorder, testFieldManager).Reconcile(context.TODO(), req)).ToNot(HaveOccurred())
 
 		g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
 			{
@@ -1052,7 +1052,7 @@ func TestAtomicRelease_Reconcile_Scenarios(t *testing.T) {
 				Values: tt.values,
 			}
 
-			err = NewAtomicRelease(patchHelper, cfg, recorder, testFieldManager).Reconcile(context.TODO(), req)
+			err = NewAtomicRelease(patchHelper, client, cfg, recorder, testFieldManager).Reconcile(context.TODO(), req)
 			wantErr := BeNil()
 			if tt.wantErr != nil {
 				wantErr = MatchError(tt.wantErr)
@@ -1283,7 +1283,7 @@ func TestAtomicRelease_Reconcile_PostRenderers_Scenarios(t *testing.T) {
 				Values: tt.values,
 			}
 
-			err = NewAtomicRelease(patchHelper, cfg, recorder, testFieldManager).Reconcile(context.TODO(), req)
+			err = NewAtomicRelease(patchHelper, client, cfg, recorder, testFieldManager).Reconcile(context.TODO(), req)
 			g.Expect(err).ToNot(HaveOccurred())
 
 			g.Expect(obj.Status.ObservedPostRenderersDigest).To(Equal(tt.wantDigest))
@@ -1386,11 +1386,73 @@ func TestAtomicRelease_actionForState(t *testing.T) {
 			},
 			wantErr: ErrExceededMaxRetries,
 		},
+		{
+			name:  "absent release requiring approval triggers install plan",
+			state: ReleaseState{Status: ReleaseStatusAbsent},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Approval: v2.UpgradeApprovalRequired}
+			},
+			want: &PlanUpgrade{},
+		},
+		{
+			name: "absent release requiring approval with force annotation triggers install plan",
+			annotations: map[string]string{
+				meta.ReconcileRequestAnnotation: "force",
+				v2.ForceRequestAnnotation:       "force",
+			},
+			state: ReleaseState{Status: ReleaseStatusAbsent},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Approval: v2.UpgradeApprovalRequired}
+			},
+			status: func(releases []*helmrelease.Release) v2.HelmReleaseStatus {
+				return v2.HelmReleaseStatus{
+					InstallFailures: 1,
+				}
+			},
+			want: &PlanUpgrade{},
+		},
+		{
+			name:  "absent release in plan mode triggers install plan",
+			state: ReleaseState{Status: ReleaseStatusAbsent},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Mode: v2.UpgradeModePlan}
+			},
+			want: &PlanUpgrade{},
+		},
+		{
+			name:  "absent release held by upgrade policy triggers install plan",
+			state: ReleaseState{Status: ReleaseStatusAbsent},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Policy: &v2.UpgradePolicy{}}
+			},
+			status: func(releases []*helmrelease.Release) v2.HelmReleaseStatus {
+				return v2.HelmReleaseStatus{
+					History: v2.Snapshots{{ChartVersion: "0.0.1"}},
+				}
+			},
+			want: &PlanUpgrade{},
+		},
 		{
 			name:  "unmanaged release triggers upgrade",
 			state: ReleaseState{Status: ReleaseStatusUnmanaged},
 			want:  &Upgrade{},
 		},
+		{
+			name:  "unmanaged release in plan mode triggers upgrade plan",
+			state: ReleaseState{Status: ReleaseStatusUnmanaged},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Mode: v2.UpgradeModePlan}
+			},
+			want: &PlanUpgrade{},
+		},
+		{
+			name:  "unmanaged release requiring approval triggers upgrade plan",
+			state: ReleaseState{Status: ReleaseStatusUnmanaged},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Approval: v2.UpgradeApprovalRequired}
+			},
+			want: &PlanUpgrade{},
+		},
 		{
 			name: "drifted release triggers correction if enabled",
 			state: ReleaseState{Status: ReleaseStatusDrifted, Diff: jsondiff.DiffSet{
@@ -1435,6 +1497,47 @@ func TestAtomicRelease_actionForState(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "drifted release with only orphans triggers event if prune is disabled",
+			state: ReleaseState{Status: ReleaseStatusDrifted, Orphans: []*unstructured.Unstructured{
+				{
+					Object: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "ConfigMap",
+						"metadata": map[string]interface{}{
+							"name":      "orphan",
+							"namespace": "something",
+						},
+					},
+				},
+			}},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.DriftDetection = &v2.DriftDetection{
+					Mode: v2.DriftDetectionEnabled,
+				}
+			},
+			status: func(releases []*helmrelease.Release) v2.HelmReleaseStatus {
+				return v2.HelmReleaseStatus{
+					History: v2.Snapshots{
+						{
+							Name:      mockReleaseName,
+							Namespace: mockReleaseNamespace,
+							Version:   1,
+						},
+					},
+				}
+			},
+			want: nil,
+			wantEvent: &corev1.Event{
+				Reason: "DriftDetected",
+				Type:   corev1.EventTypeWarning,
+				Message: fmt.Sprintf(
+					"Cluster state of release %s has drifted from the desired state:\n%s",
+					mockReleaseNamespace+"/"+mockReleaseName+".v1",
+					"ConfigMap/something/orphan orphaned",
+				),
+			},
+		},
 		{
 			name: "drifted release only triggers event if mode is warn",
 			spec: func(spec *v2.HelmReleaseSpec) {
@@ -1511,6 +1614,55 @@ func TestAtomicRelease_actionForState(t *testing.T) {
 			},
 			want: &Upgrade{},
 		},
+		{
+			name: "out-of-sync release with plan mode triggers upgrade plan",
+			state: ReleaseState{
+				Status: ReleaseStatusOutOfSync,
+			},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Mode: v2.UpgradeModePlan}
+			},
+			want: &PlanUpgrade{},
+		},
+		{
+			name: "out-of-sync release with plan mode annotation triggers upgrade plan",
+			state: ReleaseState{
+				Status: ReleaseStatusOutOfSync,
+			},
+			annotations: map[string]string{
+				v2.UpgradeModeAnnotation: string(v2.UpgradeModePlan),
+			},
+			want: &PlanUpgrade{},
+		},
+		{
+			name: "out-of-sync release with plan mode and force annotation triggers upgrade",
+			state: ReleaseState{
+				Status: ReleaseStatusOutOfSync,
+			},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Mode: v2.UpgradeModePlan}
+			},
+			annotations: map[string]string{
+				meta.ReconcileRequestAnnotation: "force",
+				v2.ForceRequestAnnotation:       "force",
+			},
+			want: &Upgrade{},
+		},
+		{
+			name: "out-of-sync release requiring approval triggers upgrade plan",
+			state: ReleaseState{
+				Status: ReleaseStatusOutOfSync,
+			},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.Upgrade = &v2.Upgrade{Approval: v2.UpgradeApprovalRequired}
+			},
+			annotations: map[string]string{
+				meta.ReconcileRequestAnnotation: "force",
+				v2.ForceRequestAnnotation:       "force",
+				v2.ApproveRequestAnnotation:     "force",
+			},
+			want: &PlanUpgrade{},
+		},
 		{
 			name: "out-of-sync release with no remaining retries and force annotation triggers upgrade",
 			state: ReleaseState{
@@ -1811,7 +1963,7 @@ func TestAtomicRelease_actionForState(t *testing.T) {
 
 			recorder := testutil.NewFakeRecorder(1, false)
 			r := &AtomicRelease{configFactory: cfg, eventRecorder: recorder}
-			got, err := r.actionForState(context.TODO(), &Request{Object: obj}, tt.state)
+			got, err := r.actionForState(context.TODO(), &Request{Object: obj, Chart: testutil.BuildChart()}, tt.state)
 
 			if tt.wantErr != nil {
 				g.Expect(got).To(BeNil())
@@ -1837,6 +1989,138 @@ func TestAtomicRelease_actionForState(t *testing.T) {
 	}
 }
 
+func TestAtomicRelease_pendingUpgrade(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &v2.HelmRelease{
+		Spec: v2.HelmReleaseSpec{
+			Upgrade: &v2.Upgrade{Approval: v2.UpgradeApprovalRequired},
+		},
+		Status: v2.HelmReleaseStatus{
+			Conditions: []metav1.Condition{
+				*conditions.TrueCondition(v2.AwaitingApprovalCondition, v2.ApprovalRequiredReason, "awaiting approval"),
+			},
+		},
+	}
+	req := &Request{Object: obj, Chart: testutil.BuildChart()}
+	r := &AtomicRelease{}
+
+	g.Expect(r.pendingUpgrade(context.TODO(), req, false)).To(BeAssignableToTypeOf(&PlanUpgrade{}))
+	g.Expect(obj.Status.LastHandledApproveAt).To(BeEmpty())
+
+	// The approve request approves the planned upgrade.
+	approved := upgradeDigest(req)
+	obj.Status.UpgradePlan = &v2.UpgradePlan{Digest: approved}
+	obj.SetAnnotations(map[string]string{
+		meta.ReconcileRequestAnnotation: "approve",
+		v2.ApproveRequestAnnotation:     "approve",
+	})
+	g.Expect(r.pendingUpgrade(context.TODO(), req, false)).To(BeAssignableToTypeOf(&Upgrade{}))
+	g.Expect(obj.Status.LastHandledApproveAt).To(Equal("approve"))
+	g.Expect(obj.Status.ApprovedUpgradeDigest).To(Equal(approved))
+	g.Expect(conditions.Has(obj, v2.AwaitingApprovalCondition)).To(BeFalse())
+
+	// The approval remains valid for a retry of the same upgrade, while the
+	// handled annotation does not approve anything else.
+	g.Expect(r.pendingUpgrade(context.TODO(), req, false)).To(BeAssignableToTypeOf(&Upgrade{}))
+
+	// A change to the values results in a different upgrade, which is not
+	// approved by the previous approval.
+	req.Values = map[string]interface{}{"foo": "bar"}
+	g.Expect(upgradeDigest(req)).ToNot(Equal(approved))
+	g.Expect(r.pendingUpgrade(context.TODO(), req, false)).To(BeAssignableToTypeOf(&PlanUpgrade{}))
+
+	// An approve request for a plan of another upgrade does not approve it.
+	obj.SetAnnotations(map[string]string{
+		meta.ReconcileRequestAnnotation: "approve-again",
+		v2.ApproveRequestAnnotation:     "approve-again",
+	})
+	g.Expect(r.pendingUpgrade(context.TODO(), req, false)).To(BeAssignableToTypeOf(&PlanUpgrade{}))
+	g.Expect(obj.Status.LastHandledApproveAt).To(Equal("approve-again"))
+	g.Expect(obj.Status.ApprovedUpgradeDigest).To(Equal(approved))
+}
+
+func Test_upgradeDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &v2.HelmRelease{}
+	req := &Request{Object: obj, Chart: testutil.BuildChart()}
+	d := upgradeDigest(req)
+	g.Expect(d).ToNot(BeEmpty())
+
+	// Settings which do not affect the release do not change the digest.
+	obj.Spec.Upgrade = &v2.Upgrade{
+		Mode:        v2.UpgradeModePlan,
+		Approval:    v2.UpgradeApprovalRequired,
+		Remediation: &v2.UpgradeRemediation{Retries: 3},
+	}
+	obj.Spec.Install = &v2.Install{Remediation: &v2.InstallRemediation{Retries: 3}}
+	g.Expect(upgradeDigest(req)).To(Equal(d))
+
+	for _, mutate := range []func(spec *v2.HelmReleaseSpec){
+		func(spec *v2.HelmReleaseSpec) { spec.Upgrade.Force = true },
+		func(spec *v2.HelmReleaseSpec) { spec.Upgrade.CRDs = v2.CreateReplace },
+		func(spec *v2.HelmReleaseSpec) { spec.Install.DisableHooks = true },
+		func(spec *v2.HelmReleaseSpec) { spec.Install.Timeout = &metav1.Duration{Duration: time.Minute} },
+		func(spec *v2.HelmReleaseSpec) { spec.TargetNamespace = "other" },
+		func(spec *v2.HelmReleaseSpec) { spec.Timeout = &metav1.Duration{Duration: time.Minute} },
+	} {
+		mutated := obj.DeepCopy()
+		mutate(&mutated.Spec)
+		g.Expect(upgradeDigest(&Request{Object: mutated, Chart: req.Chart})).ToNot(Equal(d))
+	}
+}
+
+func TestAtomicRelease_pendingUpgrade_policy(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &v2.HelmRelease{
+		Spec: v2.HelmReleaseSpec{
+			Upgrade: &v2.Upgrade{Policy: &v2.UpgradePolicy{}},
+		},
+		Status: v2.HelmReleaseStatus{
+			History: v2.Snapshots{{ChartVersion: "1.0.0"}},
+		},
+	}
+	req := &Request{Object: obj, Chart: testutil.BuildChart(testutil.ChartWithVersion("2.0.0"))}
+	r := &AtomicRelease{}
+
+	got := r.pendingUpgrade(context.TODO(), req, false)
+	g.Expect(got).To(BeAssignableToTypeOf(&PlanUpgrade{}))
+	g.Expect(got.(*PlanUpgrade).gate.Reason).To(Equal(v2.UpgradeHeldReason))
+	g.Expect(obj.Status.HeldChartVersion).To(Equal("2.0.0"))
+
+	// The upgrade request does not release a chart version which was not
+	// held before the request was made.
+	obj.SetAnnotations(map[string]string{
+		meta.ReconcileRequestAnnotation: "other",
+		v2.UpgradeRequestAnnotation:     "other",
+	})
+	req.Chart = testutil.BuildChart(testutil.ChartWithVersion("3.0.0"))
+	g.Expect(r.pendingUpgrade(context.TODO(), req, false)).To(BeAssignableToTypeOf(&PlanUpgrade{}))
+	g.Expect(obj.Status.LastHandledUpgradeAt).To(Equal("other"))
+	g.Expect(obj.Status.HeldChartVersion).To(Equal("3.0.0"))
+
+	// The upgrade request releases the held upgrade.
+	obj.SetAnnotations(map[string]string{
+		meta.ReconcileRequestAnnotation: "release",
+		v2.UpgradeRequestAnnotation:     "release",
+	})
+	conditions.MarkTrue(obj, v2.PendingUpgradeCondition, v2.UpgradeHeldReason, "held")
+	g.Expect(r.pendingUpgrade(context.TODO(), req, false)).To(BeAssignableToTypeOf(&Upgrade{}))
+	g.Expect(obj.Status.LastHandledUpgradeAt).To(Equal("release"))
+	g.Expect(obj.Status.HeldChartVersion).To(BeEmpty())
+	g.Expect(conditions.Has(obj, v2.PendingUpgradeCondition)).To(BeFalse())
+
+	// A downgrade is not released by the upgrade request, but is forced.
+	obj.Status.LastHandledUpgradeAt = ""
+	req.Chart = testutil.BuildChart(testutil.ChartWithVersion("0.9.0"))
+	got = r.pendingUpgrade(context.TODO(), req, false)
+	g.Expect(got).To(BeAssignableToTypeOf(&PlanUpgrade{}))
+	g.Expect(got.(*PlanUpgrade).gate.Reason).To(Equal(v2.DowngradeRefusedReason))
+	g.Expect(r.pendingUpgrade(context.TODO(), req, true)).To(BeAssignableToTypeOf(&Upgrade{}))
+}
+
 func Test_replaceCondition(t *testing.T) {
 	g := NewWithT(t)
 	timestamp, err := time.Parse(time.UnixDate, "Wed Feb 25 11:06:39 GMT 2015")
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	apierrutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/tools/record"
 
@@ -35,10 +36,13 @@ import (
 
 // CorrectClusterDrift is a reconciler that attempts to correct the cluster state
 // of a Helm release. It does so by applying the Helm release's desired state
-// to the cluster based on a jsondiff.DiffSet.
+// to the cluster based on a jsondiff.DiffSet, by either patching or
+// server-side applying the drifted objects, and deleting any orphaned objects
+// when pruning is enabled.
 //
 // The reconciler will only attempt to correct the cluster state if the Helm
-// release has drift detection enabled and the jsondiff.DiffSet is not empty.
+// release has drift detection enabled and the jsondiff.DiffSet is not empty,
+// or there are orphaned objects to prune.
 //
 // The reconciler will emit a Kubernetes event upon completion indicating
 // whether the cluster state was successfully corrected or not.
@@ -46,20 +50,25 @@ type CorrectClusterDrift struct {
 	configFactory *action.ConfigFactory
 	eventRecorder record.EventRecorder
 	diff          jsondiff.DiffSet
+	orphans       []*unstructured.Unstructured
 	fieldManager  string
 }
 
-func NewCorrectClusterDrift(configFactory *action.ConfigFactory, recorder record.EventRecorder, diff jsondiff.DiffSet, fieldManager string) *CorrectClusterDrift {
+func NewCorrectClusterDrift(configFactory *action.ConfigFactory, recorder record.EventRecorder, diff jsondiff.DiffSet,
+	orphans []*unstructured.Unstructured, fieldManager string) *CorrectClusterDrift {
 	return &CorrectClusterDrift{
 		configFactory: configFactory,
 		eventRecorder: recorder,
 		diff:          diff,
+		orphans:       orphans,
 		fieldManager:  fieldManager,
 	}
 }
 
 func (r *CorrectClusterDrift) Reconcile(ctx context.Context, req *Request) error {
-	if req.Object.GetDriftDetection().GetMode() != v2.DriftDetectionEnabled || len(r.diff) == 0 {
+	driftDetection := req.Object.GetDriftDetection()
+	prune := driftDetection.Prune && len(r.orphans) > 0
+	if driftDetection.GetMode() != v2.DriftDetectionEnabled || (len(r.diff) == 0 && !prune) {
 		return nil
 	}
 
@@ -69,7 +78,22 @@ func (r *CorrectClusterDrift) Reconcile(ctx context.Context, req *Request) error
 	// Update condition to reflect the current status.
 	conditions.MarkUnknown(req.Object, meta.ReadyCondition, meta.ProgressingReason, "correcting cluster drift")
 
-	changeSet, err := action.ApplyDiff(ctx, r.configFactory.Build(nil), r.diff, r.fieldManager)
+	var (
+		changeSet *ssa.ChangeSet
+		err       error
+	)
+	switch driftDetection.GetCorrection() {
+	case v2.DriftCorrectionServerSideApply:
+		changeSet, err = action.ApplyDiffServerSide(ctx, r.configFactory.Build(nil), r.diff, r.fieldManager,
+			driftDetection.ForceConflicts, driftDetection.Ignore...)
+	default:
+		changeSet, err = action.ApplyDiff(ctx, r.configFactory.Build(nil), r.diff, r.fieldManager)
+	}
+	if prune {
+		pruned, pruneErr := action.PruneOrphans(ctx, r.configFactory.Build(nil), r.orphans)
+		changeSet = mergeChangeSets(changeSet, pruned)
+		err = apierrutil.Flatten(apierrutil.NewAggregate([]error{err, pruneErr}))
+	}
 	r.report(req.Object, changeSet, err)
 	return nil
 }
@@ -115,6 +139,18 @@ func (r *CorrectClusterDrift) report(obj *v2.HelmRelease, changeSet *ssa.ChangeS
 	}
 }
 
+// mergeChangeSets returns a ssa.ChangeSet with the entries of the given
+// change sets, which may be nil.
+func mergeChangeSets(changeSets ...*ssa.ChangeSet) *ssa.ChangeSet {
+	merged := ssa.NewChangeSet()
+	for _, cs := range changeSets {
+		if cs != nil {
+			merged.Append(cs.Entries)
+		}
+	}
+	return merged
+}
+
 func (r *CorrectClusterDrift) Name() string {
 	return "correct cluster drift"
 }
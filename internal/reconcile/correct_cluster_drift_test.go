@@ -24,6 +24,7 @@ import (
 	. "github.com/onsi/gomega"
 	extjsondiff "github.com/wI2L/jsondiff"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	apierrutil "k8s.io/apimachinery/pkg/util/errors"
@@ -54,6 +55,7 @@ func TestCorrectClusterDrift_Reconcile(t *testing.T) {
 		name      string
 		obj       *v2.HelmRelease
 		diff      func(namespace string) jsondiff.DiffSet
+		orphans   func(namespace string) []*unstructured.Unstructured
 		wantEvent bool
 	}{
 		{
@@ -120,6 +122,83 @@ func TestCorrectClusterDrift_Reconcile(t *testing.T) {
 			},
 			wantEvent: true,
 		},
+		{
+			name: "corrects cluster drift with server-side apply",
+			obj: &v2.HelmRelease{
+				Spec: v2.HelmReleaseSpec{
+					DriftDetection: &v2.DriftDetection{
+						Mode:           v2.DriftDetectionEnabled,
+						Correction:     v2.DriftCorrectionServerSideApply,
+						ForceConflicts: true,
+					},
+				},
+				Status: *mockStatus.DeepCopy(),
+			},
+			diff: func(namespace string) jsondiff.DiffSet {
+				return jsondiff.DiffSet{
+					{
+						Type: jsondiff.DiffTypeUpdate,
+						DesiredObject: &unstructured.Unstructured{
+							Object: map[string]interface{}{
+								"apiVersion": "v1",
+								"kind":       "ConfigMap",
+								"metadata": map[string]interface{}{
+									"name":      "configmap",
+									"namespace": namespace,
+								},
+								"data": map[string]interface{}{
+									"key": "value",
+								},
+							},
+						},
+						ClusterObject: &unstructured.Unstructured{
+							Object: map[string]interface{}{
+								"apiVersion": "v1",
+								"kind":       "ConfigMap",
+								"metadata": map[string]interface{}{
+									"name":      "configmap",
+									"namespace": namespace,
+								},
+								"data": map[string]interface{}{
+									"key": "changed",
+								},
+							},
+						},
+					},
+				}
+			},
+			wantEvent: true,
+		},
+		{
+			name: "prunes orphaned objects",
+			obj: &v2.HelmRelease{
+				Spec: v2.HelmReleaseSpec{
+					DriftDetection: &v2.DriftDetection{
+						Mode:  v2.DriftDetectionEnabled,
+						Prune: true,
+					},
+				},
+				Status: *mockStatus.DeepCopy(),
+			},
+			diff: func(namespace string) jsondiff.DiffSet {
+				return nil
+			},
+			orphans: func(namespace string) []*unstructured.Unstructured {
+				return []*unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "ConfigMap",
+							"metadata": map[string]interface{}{
+								"name":      "orphan",
+								"namespace": namespace,
+							},
+						},
+					},
+				}
+			},
+			wantEvent: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -139,6 +218,14 @@ func TestCorrectClusterDrift_Reconcile(t *testing.T) {
 				}
 			}
 
+			var orphans []*unstructured.Unstructured
+			if tt.orphans != nil {
+				orphans = tt.orphans(namedNS.Name)
+				for _, orphan := range orphans {
+					g.Expect(testEnv.Create(context.TODO(), orphan.DeepCopy())).To(Succeed())
+				}
+			}
+
 			getter, err := RESTClientGetterFromManager(testEnv.Manager, namedNS.Name)
 			g.Expect(err).ToNot(HaveOccurred())
 
@@ -147,7 +234,7 @@ func TestCorrectClusterDrift_Reconcile(t *testing.T) {
 
 			recorder := testutil.NewFakeRecorder(10, false)
 
-			r := NewCorrectClusterDrift(cfg, recorder, tt.diff(namedNS.Name), testFieldManager)
+			r := NewCorrectClusterDrift(cfg, recorder, tt.diff(namedNS.Name), orphans, testFieldManager)
 			g.Expect(r.Reconcile(context.TODO(), &Request{
 				Object: tt.obj,
 			})).ToNot(HaveOccurred())
@@ -158,6 +245,11 @@ func TestCorrectClusterDrift_Reconcile(t *testing.T) {
 				g.Expect(recorder.GetEvents()).To(BeEmpty())
 			}
 
+			for _, orphan := range orphans {
+				err := testEnv.Get(context.TODO(), client.ObjectKeyFromObject(orphan), orphan.DeepCopy())
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+
 			g.Expect(tt.obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
 				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "correcting cluster drift"),
 			}))
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/fluxcd/pkg/runtime/logger"
+	"helm.sh/helm/v3/pkg/kube"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -51,6 +52,15 @@ import (
 // event. Only an error which resulted in a modification to the Helm storage
 // counts towards a failure for the active remediation strategy.
 //
+// When preflight checks are enabled, the APIs of the objects of the release
+// are verified to be served by the cluster, the permissions of the
+// impersonated service account are verified and/or the objects are first
+// applied using a server-side dry-run. Any APIs used by the release which are
+// deprecated in the Kubernetes version of the cluster are recorded with
+// DeprecatedAPIs=True and a warning event. On preflight failure, the object is
+// marked with Released=False and emits a warning event, without performing
+// the installation or counting the failure.
+//
 // At the end of the reconciliation, the Status.Conditions are summarized and
 // propagated to the Ready condition on the Request.Object.
 //
@@ -76,6 +86,21 @@ func (r *Install) Reconcile(ctx context.Context, req *Request) error {
 
 	defer summarize(req)
 
+	// Verify the release can be made before making the attempt. A failure
+	// does not modify the Helm storage, and does not count towards the
+	// active remediation strategy.
+	if req.Object.GetPreflight().HasChecks() {
+		result, err := action.PreflightInstall(ctx, r.configFactory.Build(logBuf.Log), req.Object, req.Chart, req.Values,
+			kube.ManagedFieldsManager)
+		recordDeprecations(r.eventRecorder, req, v2.ReleaseActionInstall, result)
+		if err != nil {
+			preflightFailure(r.eventRecorder, req, v2.ReleaseActionInstall, logBuf, err)
+			return err
+		}
+	} else {
+		conditions.Delete(req.Object, v2.DeprecatedAPIsCondition)
+	}
+
 	// Mark install attempt on object.
 	req.Object.Status.LastAttemptedReleaseAction = v2.ReleaseActionInstall
 
@@ -113,6 +138,11 @@ func (r *Install) Reconcile(ctx context.Context, req *Request) error {
 		return nil
 	}
 
+	// Record the inventory of the objects in the release manifest.
+	if err := obsReleases.recordInventory(req.Object, r.configFactory.Getter); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to record inventory of release objects")
+	}
+
 	r.success(req)
 	return nil
 }
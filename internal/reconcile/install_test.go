@@ -71,6 +71,9 @@ func TestInstall_Reconcile(t *testing.T) {
 		// expectHistory is the expected History of the HelmRelease after
 		// install.
 		expectHistory func(releases []*helmrelease.Release) v2.Snapshots
+		// expectInventory is the expected Inventory of the HelmRelease after
+		// install.
+		expectInventory func(namespace string) *v2.ResourceInventory
 		// expectFailures is the expected Failures count of the HelmRelease.
 		expectFailures int64
 		// expectInstallFailures is the expected InstallFailures count of the
@@ -94,6 +97,11 @@ func TestInstall_Reconcile(t *testing.T) {
 					release.ObservedToSnapshot(release.ObserveRelease(releases[0])),
 				}
 			},
+			expectInventory: func(namespace string) *v2.ResourceInventory {
+				return &v2.ResourceInventory{Entries: []v2.ResourceRef{
+					{ID: namespace + "_cm__ConfigMap", Version: "v1"},
+				}}
+			},
 		},
 		{
 			name:  "install failure",
@@ -293,6 +301,10 @@ func TestInstall_Reconcile(t *testing.T) {
 				g.Expect(obj.Status.History).To(BeEmpty(), "expected history to be empty")
 			}
 
+			if tt.expectInventory != nil {
+				g.Expect(obj.Status.Inventory).To(Equal(tt.expectInventory(releaseNamespace)))
+			}
+
 			g.Expect(obj.Status.Failures).To(Equal(tt.expectFailures))
 			g.Expect(obj.Status.InstallFailures).To(Equal(tt.expectInstallFailures))
 			g.Expect(obj.Status.UpgradeFailures).To(Equal(tt.expectUpgradeFailures))
@@ -300,6 +312,95 @@ func TestInstall_Reconcile(t *testing.T) {
 	}
 }
 
+func TestInstall_Reconcile_preflight(t *testing.T) {
+	tests := []struct {
+		name             string
+		chart            *chart.Chart
+		wantErr          bool
+		expectConditions []metav1.Condition
+		expectHistory    bool
+	}{
+		{
+			name:  "preflight success",
+			chart: testutil.BuildChart(),
+			expectConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReadyCondition, v2.InstallSucceededReason,
+					"Helm install succeeded"),
+				*conditions.TrueCondition(v2.ReleasedCondition, v2.InstallSucceededReason,
+					"Helm install succeeded"),
+			},
+			expectHistory: true,
+		},
+		{
+			name:    "preflight failure",
+			chart:   testutil.BuildChart(testutil.ChartWithInvalidManifest()),
+			wantErr: true,
+			expectConditions: []metav1.Condition{
+				*conditions.FalseCondition(meta.ReadyCondition, v2.PreflightFailedReason,
+					"Helm install preflight failed"),
+				*conditions.FalseCondition(v2.ReleasedCondition, v2.PreflightFailedReason,
+					"Helm install preflight failed"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			namedNS, err := testEnv.CreateNamespace(context.TODO(), mockReleaseNamespace)
+			g.Expect(err).NotTo(HaveOccurred())
+			t.Cleanup(func() {
+				_ = testEnv.Delete(context.TODO(), namedNS)
+			})
+			releaseNamespace := namedNS.Name
+
+			obj := &v2.HelmRelease{
+				Spec: v2.HelmReleaseSpec{
+					ReleaseName:      mockReleaseName,
+					TargetNamespace:  releaseNamespace,
+					StorageNamespace: releaseNamespace,
+					Timeout:          &metav1.Duration{Duration: 100 * time.Millisecond},
+					Preflight:        &v2.Preflight{Enable: true},
+				},
+			}
+
+			getter, err := RESTClientGetterFromManager(testEnv.Manager, obj.GetReleaseNamespace())
+			g.Expect(err).ToNot(HaveOccurred())
+
+			cfg, err := action.NewConfigFactory(getter,
+				action.WithStorage(action.DefaultStorageDriver, obj.GetStorageNamespace()),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			recorder := new(record.FakeRecorder)
+			got := (NewInstall(cfg, recorder)).Reconcile(context.TODO(), &Request{
+				Object: obj,
+				Chart:  tt.chart,
+			})
+			if tt.wantErr {
+				g.Expect(got).To(HaveOccurred())
+				g.Expect(got.Error()).To(ContainSubstring("Invalid_Name"))
+			} else {
+				g.Expect(got).ToNot(HaveOccurred())
+			}
+
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.expectConditions))
+
+			releases, _ := helmstorage.Init(cfg.Driver).History(mockReleaseName)
+			if tt.expectHistory {
+				g.Expect(releases).To(HaveLen(1))
+				g.Expect(obj.Status.History).To(HaveLen(1))
+			} else {
+				g.Expect(releases).To(BeEmpty())
+				g.Expect(obj.Status.History).To(BeEmpty())
+				g.Expect(obj.Status.LastAttemptedReleaseAction).To(BeEmpty())
+			}
+			g.Expect(obj.Status.Failures).To(BeZero())
+			g.Expect(obj.Status.InstallFailures).To(BeZero())
+		})
+	}
+}
+
 func TestInstall_failure(t *testing.T) {
 	var (
 		obj = &v2.HelmRelease{
@@ -0,0 +1,423 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"helm.sh/helm/v3/pkg/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/chartutil"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	"github.com/fluxcd/pkg/ssa/jsondiff"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/action"
+	"github.com/fluxcd/helm-controller/internal/diff"
+	"github.com/fluxcd/helm-controller/internal/digest"
+	"github.com/fluxcd/helm-controller/internal/postrender"
+	intvalues "github.com/fluxcd/helm-controller/internal/values"
+)
+
+const (
+	// maxUpgradePlanChanges is the maximum number of changes recorded in
+	// the Status.UpgradePlan.
+	maxUpgradePlanChanges = 100
+	// maxUpgradePlanDiffSize is the maximum size of the diff written to the
+	// upgrade plan ConfigMap, well within the limit of a ConfigMap.
+	maxUpgradePlanDiffSize = 512 * 1024
+
+	// upgradePlanSummaryKey is the ConfigMap key the summary is written to.
+	upgradePlanSummaryKey = "summary"
+	// upgradePlanDiffKey is the ConfigMap key the diff is written to.
+	upgradePlanDiffKey = "diff"
+)
+
+// PlanUpgrade is an ActionReconciler which plans a Helm upgrade based on the
+// given Request data, without performing it.
+//
+// It renders the upgrade using a server-side dry-run, and compares the
+// result to the state of the cluster. A summary of the changes is written to
+// the Status.UpgradePlan field, and the full diff to a ConfigMap owned by the
+// v2.HelmRelease. Secret data is masked in both.
+//
+// The object is marked with Ready=False to indicate the release is not
+// in-sync with the desired state, and an event is emitted when the plan
+// differs from the previous plan. When the upgrade is held behind an
+// UpgradeGate, the object is in addition marked with the condition of the
+// gate, for example AwaitingApproval=True with the digest of the upgrade to
+// approve it with. Any error while planning the upgrade is returned to the
+// caller, and indicates they should retry.
+type PlanUpgrade struct {
+	client        client.Client
+	configFactory *action.ConfigFactory
+	eventRecorder record.EventRecorder
+	fieldManager  string
+	gate          *UpgradeGate
+}
+
+// UpgradeGate holds the condition a planned Helm upgrade is held behind.
+type UpgradeGate struct {
+	// Condition is the type of the condition which is marked True while the
+	// upgrade is held.
+	Condition string
+	// Reason is the reason of the condition, and of Ready=False.
+	Reason string
+	// Message is the message of the condition, to which the summary of the
+	// planned changes is appended.
+	Message string
+}
+
+// NewPlanUpgrade returns a new PlanUpgrade reconciler configured with the
+// provided values. If gate is not nil, the planned upgrade is marked as
+// held behind it.
+func NewPlanUpgrade(client client.Client, cfg *action.ConfigFactory, recorder record.EventRecorder, fieldManager string, gate *UpgradeGate) *PlanUpgrade {
+	return &PlanUpgrade{
+		client:        client,
+		configFactory: cfg,
+		eventRecorder: recorder,
+		fieldManager:  fieldManager,
+		gate:          gate,
+	}
+}
+
+func (r *PlanUpgrade) Reconcile(ctx context.Context, req *Request) error {
+	plan, err := action.PlanUpgrade(ctx, r.configFactory.Build(nil), req.Object, req.Chart, req.Values, kube.ManagedFieldsManager)
+	if err != nil {
+		r.failure(req, err)
+		return err
+	}
+
+	changes := upgradePlanChanges(plan)
+	summary := summarizeUpgradePlanChanges(changes)
+
+	data := map[string]string{
+		upgradePlanSummaryKey: summary,
+		upgradePlanDiffKey:    renderUpgradePlanDiff(plan),
+	}
+	if err = r.applyDiffConfigMap(ctx, req.Object, data); err != nil {
+		r.failure(req, err)
+		return err
+	}
+
+	prev := req.Object.Status.UpgradePlan
+	cur := &v2.UpgradePlan{
+		ChartName:     req.Chart.Name(),
+		ChartVersion:  req.Chart.Metadata.Version,
+		ConfigDigest:  chartutil.DigestValues(digest.Canonical, req.Values).String(),
+		Digest:        upgradeDigest(req),
+		PlannedAt:     metav1.Now(),
+		Summary:       summary,
+		DiffConfigMap: &meta.LocalObjectReference{Name: req.Object.GetUpgradePlanName()},
+	}
+	cur.Changes = changes
+	if len(changes) > maxUpgradePlanChanges {
+		cur.Changes = changes[:maxUpgradePlanChanges]
+	}
+	req.Object.Status.UpgradePlan = cur
+
+	reason := v2.UpgradePlannedReason
+	msg := fmt.Sprintf(fmtUpgradePlanned, req.Object.GetReleaseNamespace(), req.Object.GetReleaseName(),
+		cur.ChartName, cur.ChartVersion, summary)
+	if r.gate != nil {
+		reason = r.gate.Reason
+		msg = fmt.Sprintf("%s: %s", r.gate.Message, summary)
+		conditions.MarkTrue(req.Object, r.gate.Condition, reason, "%s", msg)
+	}
+	conditions.MarkFalse(req.Object, meta.ReadyCondition, reason, "%s", msg)
+
+	// Only emit an event for a new plan, to prevent an event for every
+	// reconciliation while the plan is pending.
+	if prev == nil || prev.Digest != cur.Digest || prev.Summary != cur.Summary {
+		var details strings.Builder
+		for _, c := range changes {
+			details.WriteString(fmt.Sprintf("\n%s %s", c.Object, strings.ToLower(c.Action)))
+		}
+		r.eventRecorder.AnnotatedEventf(
+			req.Object,
+			eventMeta(cur.ChartVersion, cur.ConfigDigest, addAppVersion(req.Chart.AppVersion())),
+			corev1.EventTypeNormal,
+			reason,
+			"%s%s", msg, details.String(),
+		)
+	}
+	return nil
+}
+
+func (r *PlanUpgrade) Name() string {
+	return "plan-upgrade"
+}
+
+func (r *PlanUpgrade) Type() ReconcilerType {
+	return ReconcilerTypePlan
+}
+
+const (
+	// fmtUpgradePlanFailure is the message format for an upgrade plan failure.
+	fmtUpgradePlanFailure = "Helm upgrade plan failed for release %s/%s with chart %s@%s: %s"
+	// fmtUpgradePlanned is the message format for a planned upgrade.
+	fmtUpgradePlanned = "Helm upgrade planned for release %s/%s with chart %s@%s: %s"
+	// fmtUpgradeAwaitingApproval is the message format for a planned upgrade
+	// which awaits approval.
+	fmtUpgradeAwaitingApproval = "Helm upgrade for release %s/%s with chart %s@%s and config digest %s awaits approval " +
+		"of plan %s, set annotation '%s' to the value of '%s' to approve"
+)
+
+// approvalGate returns the UpgradeGate for a Helm upgrade of the given
+// Request which awaits approval.
+func approvalGate(req *Request) *UpgradeGate {
+	return &UpgradeGate{
+		Condition: v2.AwaitingApprovalCondition,
+		Reason:    v2.ApprovalRequiredReason,
+		Message: fmt.Sprintf(fmtUpgradeAwaitingApproval, req.Object.GetReleaseNamespace(), req.Object.GetReleaseName(),
+			req.Chart.Name(), req.Chart.Metadata.Version, chartutil.DigestValues(digest.Canonical, req.Values).String(),
+			upgradeDigest(req), v2.ApproveRequestAnnotation, meta.ReconcileRequestAnnotation),
+	}
+}
+
+// upgradeDigest returns the digest of the Helm upgrade for the given
+// Request. It is calculated over the release name and namespace, the chart
+// name, version and OCI digest, the values, the post-renderers, the values
+// merge strategies, and the install and upgrade settings which affect the
+// release, and identifies the exact upgrade which is approved with it.
+func upgradeDigest(req *Request) string {
+	var postRenderersDigest string
+	if req.Object.Spec.PostRenderers != nil {
+		postRenderersDigest = postrender.Digest(digest.Canonical, req.Object.Spec.PostRenderers).String()
+	}
+
+	// The remediation, and the settings which determine whether an upgrade
+	// is held, do not affect the release itself.
+	install := req.Object.GetInstall()
+	install.Remediation = nil
+	upgrade := req.Object.GetUpgrade()
+	upgrade.Remediation, upgrade.Mode, upgrade.Approval, upgrade.Policy = nil, "", "", nil
+
+	digester := digest.Canonical.Digester()
+	enc := json.NewEncoder(digester.Hash())
+	if err := enc.Encode([]string{
+		req.Object.GetReleaseName(),
+		req.Object.GetReleaseNamespace(),
+		req.Chart.Name(),
+		req.Chart.Metadata.Version,
+		req.Object.Status.LastAttemptedRevisionDigest,
+		chartutil.DigestValues(digest.Canonical, req.Values).String(),
+		postRenderersDigest,
+		intvalues.Digest(digest.Canonical, req.Object.Spec.ValuesFrom).String(),
+		req.Object.GetTimeout().Duration.String(),
+	}); err != nil {
+		return ""
+	}
+	if err := enc.Encode(install); err != nil {
+		return ""
+	}
+	if err := enc.Encode(upgrade); err != nil {
+		return ""
+	}
+	return digester.Digest().String()
+}
+
+// failure records the failure to plan a Helm upgrade in the status of the
+// given Request.Object by marking Ready=False, and emits a warning event.
+func (r *PlanUpgrade) failure(req *Request, err error) {
+	msg := fmt.Sprintf(fmtUpgradePlanFailure, req.Object.GetReleaseNamespace(), req.Object.GetReleaseName(),
+		req.Chart.Name(), req.Chart.Metadata.Version, strings.TrimSpace(err.Error()))
+	conditions.MarkFalse(req.Object, meta.ReadyCondition, v2.UpgradePlanFailedReason, "%s", msg)
+	r.eventRecorder.AnnotatedEventf(
+		req.Object,
+		eventMeta(req.Chart.Metadata.Version, chartutil.DigestValues(digest.Canonical, req.Values).String(),
+			addAppVersion(req.Chart.AppVersion())),
+		corev1.EventTypeWarning,
+		v2.UpgradePlanFailedReason,
+		msg,
+	)
+}
+
+// applyDiffConfigMap writes the given data to the upgrade plan ConfigMap of
+// the v2.HelmRelease using a server-side apply. It refuses to overwrite a
+// ConfigMap which is not controlled by the v2.HelmRelease.
+func (r *PlanUpgrade) applyDiffConfigMap(ctx context.Context, obj *v2.HelmRelease, data map[string]string) error {
+	u := buildOwnedObject(obj, meta.NamespacedObjectKindReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       obj.GetUpgradePlanName(),
+		Namespace:  obj.GetNamespace(),
+	}, data)
+
+	if _, err := applyOwnedObject(ctx, r.client, obj, u, r.fieldManager); err != nil {
+		return fmt.Errorf("failed to write upgrade plan to ConfigMap '%s/%s': %w", u.GetNamespace(), u.GetName(), err)
+	}
+	return nil
+}
+
+// deleteUpgradePlan deletes the upgrade plan ConfigMap referenced in the
+// Status.UpgradePlan of the given v2.HelmRelease if it is controlled by the
+// v2.HelmRelease, and removes the plan from the status.
+func deleteUpgradePlan(ctx context.Context, c client.Client, obj *v2.HelmRelease) error {
+	if ref := obj.Status.UpgradePlan.DiffConfigMap; ref != nil {
+		if _, err := deleteOwnedObject(ctx, c, obj, meta.NamespacedObjectKindReference{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       ref.Name,
+			Namespace:  obj.GetNamespace(),
+		}); err != nil {
+			return fmt.Errorf("failed to delete upgrade plan ConfigMap '%s/%s': %w", obj.GetNamespace(), ref.Name, err)
+		}
+	}
+	obj.Status.UpgradePlan = nil
+	return nil
+}
+
+// upgradePlanChanges returns the changes of the given plan, ordered by
+// action.
+func upgradePlanChanges(plan *action.UpgradePlan) []v2.UpgradePlanChange {
+	var creates, updates, deletes []v2.UpgradePlanChange
+	for _, d := range plan.Diff {
+		if d == nil {
+			continue
+		}
+		switch d.Type {
+		case jsondiff.DiffTypeCreate:
+			creates = append(creates, v2.UpgradePlanChange{
+				Action: v2.UpgradePlanActionCreate, Object: diff.ResourceName(d.DesiredObject),
+			})
+		case jsondiff.DiffTypeUpdate:
+			updates = append(updates, v2.UpgradePlanChange{
+				Action: v2.UpgradePlanActionUpdate, Object: diff.ResourceName(d.DesiredObject),
+			})
+		}
+	}
+	for _, obj := range plan.Removed {
+		deletes = append(deletes, v2.UpgradePlanChange{
+			Action: v2.UpgradePlanActionDelete, Object: diff.ResourceName(obj),
+		})
+	}
+	return append(append(creates, updates...), deletes...)
+}
+
+// summarizeUpgradePlanChanges returns a summary of the number of changes
+// per action, in the format `create: x, update: y, delete: z`.
+func summarizeUpgradePlanChanges(changes []v2.UpgradePlanChange) string {
+	var creates, updates, deletes int
+	for _, c := range changes {
+		switch c.Action {
+		case v2.UpgradePlanActionCreate:
+			creates++
+		case v2.UpgradePlanActionUpdate:
+			updates++
+		case v2.UpgradePlanActionDelete:
+			deletes++
+		}
+	}
+	return fmt.Sprintf("create: %d, update: %d, delete: %d", creates, updates, deletes)
+}
+
+// renderUpgradePlanDiff renders the full diff of the given plan. Objects to
+// be created are rendered in full, objects to be updated as a JSON patch
+// against the state of the cluster, and objects to be deleted by name only.
+// The data of Secrets is masked. The result is truncated at a line boundary
+// to maxUpgradePlanDiffSize.
+func renderUpgradePlanDiff(plan *action.UpgradePlan) string {
+	var b strings.Builder
+	for _, d := range plan.Diff {
+		if d == nil {
+			continue
+		}
+		switch d.Type {
+		case jsondiff.DiffTypeCreate:
+			b.WriteString(fmt.Sprintf("+++ %s (create)\n", diff.ResourceName(d.DesiredObject)))
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(d.DesiredObject.DeepCopyObject())
+			if err == nil && isSecret(d.DesiredObject) {
+				maskSecretData(obj)
+			}
+			var out []byte
+			if err == nil {
+				out, err = yaml.Marshal(obj)
+			}
+			if err != nil {
+				out = []byte(fmt.Sprintf("failed to render object: %s\n", err))
+			}
+			b.Write(out)
+		case jsondiff.DiffTypeUpdate:
+			b.WriteString(fmt.Sprintf("~~~ %s (update)\n", diff.ResourceName(d.DesiredObject)))
+			patch := d.Patch
+			if isSecret(d.DesiredObject) {
+				patch = jsondiff.MaskSecretPatchData(patch)
+			}
+			out, err := yaml.Marshal(patch)
+			if err != nil {
+				out = []byte(fmt.Sprintf("failed to render patch: %s\n", err))
+			}
+			b.Write(out)
+		default:
+			continue
+		}
+	}
+	for _, obj := range plan.Removed {
+		b.WriteString(fmt.Sprintf("--- %s (delete)\n", diff.ResourceName(obj)))
+	}
+
+	return truncateUpgradePlanDiff(b.String(), maxUpgradePlanDiffSize)
+}
+
+// truncateUpgradePlanDiff truncates the given diff to at most size bytes at
+// the last line boundary, or at the last rune boundary if the first line
+// exceeds the size, and marks it as truncated.
+func truncateUpgradePlanDiff(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	i := strings.LastIndexByte(s[:size], '\n') + 1
+	if i == 0 {
+		i = size
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+	}
+	return s[:i] + "\n... (truncated)\n"
+}
+
+// isSecret returns true if the given object is a Kubernetes Secret.
+func isSecret(obj client.Object) bool {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "Secret"
+}
+
+// maskSecretData replaces the values of the data and stringData fields of
+// the given unstructured Secret content with a mask.
+func maskSecretData(obj map[string]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		m, ok := obj[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k := range m {
+			m[k] = "***"
+		}
+	}
+}
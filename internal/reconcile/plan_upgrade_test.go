@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/onsi/gomega"
+	extjsondiff "github.com/wI2L/jsondiff"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/ssa/jsondiff"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/action"
+)
+
+func Test_upgradePlanChanges(t *testing.T) {
+	g := NewWithT(t)
+
+	plan := mockUpgradePlan()
+
+	changes := upgradePlanChanges(plan)
+	g.Expect(changes).To(Equal([]v2.UpgradePlanChange{
+		{Action: v2.UpgradePlanActionCreate, Object: "Secret/default/credentials"},
+		{Action: v2.UpgradePlanActionUpdate, Object: "Deployment/default/app"},
+		{Action: v2.UpgradePlanActionDelete, Object: "ConfigMap/default/obsolete"},
+	}))
+	g.Expect(summarizeUpgradePlanChanges(changes)).To(Equal("create: 1, update: 1, delete: 1"))
+}
+
+func Test_renderUpgradePlanDiff(t *testing.T) {
+	g := NewWithT(t)
+
+	got := renderUpgradePlanDiff(mockUpgradePlan())
+	g.Expect(got).To(ContainSubstring("+++ Secret/default/credentials (create)\n"))
+	g.Expect(got).To(ContainSubstring("password: '***'"))
+	g.Expect(got).ToNot(ContainSubstring("c2VjcmV0"))
+	g.Expect(got).To(ContainSubstring("~~~ Deployment/default/app (update)\n"))
+	g.Expect(got).To(ContainSubstring("path: /spec/replicas"))
+	g.Expect(got).To(ContainSubstring("--- ConfigMap/default/obsolete (delete)\n"))
+	g.Expect(got).ToNot(ContainSubstring("unchanged"))
+}
+
+func Test_truncateUpgradePlanDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		size int
+		want string
+	}{
+		{
+			name: "within size",
+			diff: "foo\nbar\n",
+			size: 8,
+			want: "foo\nbar\n",
+		},
+		{
+			name: "at line boundary",
+			diff: "foo\nbar\nbaz\n",
+			size: 10,
+			want: "foo\nbar\n\n... (truncated)\n",
+		},
+		{
+			name: "at rune boundary",
+			diff: "fooébar\n",
+			size: 4,
+			want: "foo\n... (truncated)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got := truncateUpgradePlanDiff(tt.diff, tt.size)
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(utf8.ValidString(got)).To(BeTrue())
+		})
+	}
+}
+
+func mockUpgradePlan() *action.UpgradePlan {
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "credentials",
+			"namespace": "default",
+		},
+		"data": map[string]interface{}{
+			"password": "c2VjcmV0",
+		},
+	}}
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "app",
+			"namespace": "default",
+		},
+	}}
+	unchanged := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "unchanged",
+			"namespace": "default",
+		},
+	}}
+	obsolete := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "obsolete",
+			"namespace": "default",
+		},
+	}}
+
+	return &action.UpgradePlan{
+		Diff: jsondiff.DiffSet{
+			{Type: jsondiff.DiffTypeNone, DesiredObject: unchanged},
+			{Type: jsondiff.DiffTypeUpdate, DesiredObject: deployment, Patch: extjsondiff.Patch{
+				{Type: extjsondiff.OperationReplace, Path: "/spec/replicas", Value: 2, OldValue: 1},
+			}},
+			{Type: jsondiff.DiffTypeCreate, DesiredObject: secret},
+		},
+		Removed: []*unstructured.Unstructured{obsolete},
+	}
+}
+
+func Test_deleteUpgradePlan(t *testing.T) {
+	newObj := func() *v2.HelmRelease {
+		return &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "release",
+				Namespace: "default",
+				UID:       "uid",
+			},
+			Status: v2.HelmReleaseStatus{
+				UpgradePlan: &v2.UpgradePlan{
+					DiffConfigMap: &meta.LocalObjectReference{Name: "release-upgrade-plan"},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		owners      func(obj *v2.HelmRelease) []metav1.OwnerReference
+		wantDeleted bool
+	}{
+		{
+			name: "deletes ConfigMap controlled by HelmRelease",
+			owners: func(obj *v2.HelmRelease) []metav1.OwnerReference {
+				return []metav1.OwnerReference{
+					*metav1.NewControllerRef(obj, v2.GroupVersion.WithKind(v2.HelmReleaseKind)),
+				}
+			},
+			wantDeleted: true,
+		},
+		{
+			name: "keeps ConfigMap not owned by HelmRelease",
+			owners: func(*v2.HelmRelease) []metav1.OwnerReference {
+				return nil
+			},
+		},
+		{
+			name: "keeps ConfigMap controlled by HelmRelease with other UID",
+			owners: func(obj *v2.HelmRelease) []metav1.OwnerReference {
+				other := obj.DeepCopy()
+				other.UID = "other"
+				return []metav1.OwnerReference{
+					*metav1.NewControllerRef(other, v2.GroupVersion.WithKind(v2.HelmReleaseKind)),
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := newObj()
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "release-upgrade-plan",
+					Namespace:       obj.Namespace,
+					OwnerReferences: tt.owners(obj),
+				},
+			}
+			c := fake.NewClientBuilder().WithObjects(cm).Build()
+
+			g.Expect(deleteUpgradePlan(context.TODO(), c, obj)).To(Succeed())
+			g.Expect(obj.Status.UpgradePlan).To(BeNil())
+
+			err := c.Get(context.TODO(), client.ObjectKeyFromObject(cm), &corev1.ConfigMap{})
+			if tt.wantDeleted {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
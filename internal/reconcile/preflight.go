@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/action"
+	"github.com/fluxcd/helm-controller/internal/digest"
+	"github.com/fluxcd/pkg/chartutil"
+)
+
+const (
+	// fmtPreflightFailure is the message format for a preflight failure.
+	fmtPreflightFailure = "Helm %s preflight failed for release %s/%s with chart %s@%s: %s"
+	// fmtDeprecatedAPIs is the message format for deprecated APIs found by
+	// a preflight.
+	fmtDeprecatedAPIs = "Helm %s of release %s/%s with chart %s@%s uses deprecated APIs: %s"
+)
+
+// preflightFailure records the failure of the preflight of a Helm install or
+// upgrade action in the status of the given Request.Object by marking
+// ReleasedCondition=False. In addition, it emits a warning event for the
+// Request.Object.
+//
+// Contrary to a failure of the action itself, it does not increase the
+// failure counter, as the preflight does not modify the Helm storage.
+func preflightFailure(recorder record.EventRecorder, req *Request, releaseAction v2.ReleaseAction,
+	buffer *action.LogBuffer, err error) {
+	// Compose failure message.
+	msg := fmt.Sprintf(fmtPreflightFailure, releaseAction, req.Object.GetReleaseNamespace(), req.Object.GetReleaseName(),
+		req.Chart.Name(), req.Chart.Metadata.Version, strings.TrimSpace(err.Error()))
+
+	// Mark preflight failure on object.
+	conditions.MarkFalse(req.Object, v2.ReleasedCondition, v2.PreflightFailedReason, "%s", msg)
+
+	// Record warning event, this message contains more data than the
+	// Condition summary.
+	recorder.AnnotatedEventf(
+		req.Object,
+		eventMeta(req.Chart.Metadata.Version, chartutil.DigestValues(digest.Canonical, req.Values).String(),
+			addAppVersion(req.Chart.AppVersion())),
+		corev1.EventTypeWarning,
+		v2.PreflightFailedReason,
+		eventMessageWithLog(msg, buffer),
+	)
+}
+
+// recordDeprecations records the deprecated APIs found by the preflight of a
+// Helm install or upgrade action in the status of the given Request.Object by
+// marking DeprecatedAPIsCondition=True, and emits a warning event for the
+// Request.Object. When no deprecated APIs were found, the condition is
+// removed. A nil result, which means the preflight failed before any checks
+// were performed, is ignored.
+func recordDeprecations(recorder record.EventRecorder, req *Request, releaseAction v2.ReleaseAction,
+	result *action.PreflightResult) {
+	if result == nil {
+		return
+	}
+	if len(result.Deprecations) == 0 {
+		conditions.Delete(req.Object, v2.DeprecatedAPIsCondition)
+		return
+	}
+
+	// Compose deprecation message.
+	deprecations := make([]string, 0, len(result.Deprecations))
+	for _, d := range result.Deprecations {
+		deprecations = append(deprecations, d.String())
+	}
+	msg := fmt.Sprintf(fmtDeprecatedAPIs, releaseAction, req.Object.GetReleaseNamespace(), req.Object.GetReleaseName(),
+		req.Chart.Name(), req.Chart.Metadata.Version, strings.Join(deprecations, "; "))
+
+	// Mark deprecated APIs on object.
+	conditions.MarkTrue(req.Object, v2.DeprecatedAPIsCondition, v2.APIDeprecatedReason, "%s", msg)
+
+	// Record warning event.
+	recorder.AnnotatedEventf(
+		req.Object,
+		eventMeta(req.Chart.Metadata.Version, chartutil.DigestValues(digest.Canonical, req.Values).String(),
+			addAppVersion(req.Chart.AppVersion())),
+		corev1.EventTypeWarning,
+		v2.APIDeprecatedReason,
+		msg,
+	)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/action"
+	"github.com/fluxcd/helm-controller/internal/digest"
+	"github.com/fluxcd/helm-controller/internal/testutil"
+	"github.com/fluxcd/pkg/chartutil"
+)
+
+func Test_preflightFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &v2.HelmRelease{
+		Spec: v2.HelmReleaseSpec{
+			ReleaseName:     mockReleaseName,
+			TargetNamespace: mockReleaseNamespace,
+		},
+		Status: v2.HelmReleaseStatus{
+			Failures: 1,
+		},
+	}
+	chrt := testutil.BuildChart()
+	err := errors.New("dry-run error")
+
+	recorder := testutil.NewFakeRecorder(10, false)
+	req := &Request{Object: obj.DeepCopy(), Chart: chrt, Values: map[string]interface{}{"foo": "bar"}}
+	preflightFailure(recorder, req, v2.ReleaseActionUpgrade, nil, err)
+
+	expectMsg := fmt.Sprintf(fmtPreflightFailure, v2.ReleaseActionUpgrade, mockReleaseNamespace, mockReleaseName,
+		chrt.Name(), chrt.Metadata.Version, err.Error())
+
+	g.Expect(req.Object.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+		*conditions.FalseCondition(v2.ReleasedCondition, v2.PreflightFailedReason, expectMsg),
+	}))
+	g.Expect(req.Object.Status.Failures).To(Equal(int64(1)))
+	g.Expect(recorder.GetEvents()).To(ConsistOf([]corev1.Event{
+		{
+			Type:    corev1.EventTypeWarning,
+			Reason:  v2.PreflightFailedReason,
+			Message: expectMsg,
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					eventMetaGroupKey(eventv1.MetaRevisionKey): chrt.Metadata.Version,
+					eventMetaGroupKey(metaAppVersionKey):       chrt.Metadata.AppVersion,
+					eventMetaGroupKey(eventv1.MetaTokenKey):    chartutil.DigestValues(digest.Canonical, req.Values).String(),
+				},
+			},
+		},
+	}))
+}
+
+func Test_recordDeprecations(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &v2.HelmRelease{
+		Spec: v2.HelmReleaseSpec{
+			ReleaseName:     mockReleaseName,
+			TargetNamespace: mockReleaseNamespace,
+		},
+	}
+	chrt := testutil.BuildChart()
+	deprecation := action.APIDeprecation{
+		GroupVersionKind: schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"},
+		DeprecatedIn:     "v1.21",
+		RemovedIn:        "v1.25",
+		Replacement:      "policy/v1",
+	}
+
+	recorder := testutil.NewFakeRecorder(10, false)
+	req := &Request{Object: obj.DeepCopy(), Chart: chrt, Values: map[string]interface{}{"foo": "bar"}}
+	recordDeprecations(recorder, req, v2.ReleaseActionInstall, &action.PreflightResult{
+		Deprecations: []action.APIDeprecation{deprecation},
+	})
+
+	expectMsg := fmt.Sprintf(fmtDeprecatedAPIs, v2.ReleaseActionInstall, mockReleaseNamespace, mockReleaseName,
+		chrt.Name(), chrt.Metadata.Version, deprecation.String())
+
+	g.Expect(req.Object.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+		*conditions.TrueCondition(v2.DeprecatedAPIsCondition, v2.APIDeprecatedReason, expectMsg),
+	}))
+	g.Expect(recorder.GetEvents()).To(ConsistOf([]corev1.Event{
+		{
+			Type:    corev1.EventTypeWarning,
+			Reason:  v2.APIDeprecatedReason,
+			Message: expectMsg,
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					eventMetaGroupKey(eventv1.MetaRevisionKey): chrt.Metadata.Version,
+					eventMetaGroupKey(metaAppVersionKey):       chrt.Metadata.AppVersion,
+					eventMetaGroupKey(eventv1.MetaTokenKey):    chartutil.DigestValues(digest.Canonical, req.Values).String(),
+				},
+			},
+		},
+	}))
+
+	// A nil result leaves the condition untouched.
+	recordDeprecations(recorder, req, v2.ReleaseActionUpgrade, nil)
+	g.Expect(conditions.Has(req.Object, v2.DeprecatedAPIsCondition)).To(BeTrue())
+
+	// A result without deprecations removes the condition.
+	recordDeprecations(recorder, req, v2.ReleaseActionUpgrade, &action.PreflightResult{})
+	g.Expect(conditions.Has(req.Object, v2.DeprecatedAPIsCondition)).To(BeFalse())
+}
@@ -23,6 +23,7 @@ import (
 	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
 
 	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/schedule"
 )
 
 const (
@@ -41,6 +42,9 @@ const (
 	// ReconcilerTypeDriftCorrection is an ActionReconciler which corrects
 	// Helm releases which have drifted from the cluster state.
 	ReconcilerTypeDriftCorrection ReconcilerType = "drift correction"
+	// ReconcilerTypePlan is an ActionReconciler which plans a Helm release
+	// action without performing it.
+	ReconcilerTypePlan ReconcilerType = "plan"
 )
 
 // ReconcilerType is a string which identifies the type of ActionReconciler.
@@ -83,6 +87,9 @@ type Request struct {
 	// Values is the Helm chart values to be used for the installation or
 	// upgrade.
 	Values helmchartutil.Values
+	// Schedule holds the maintenance windows in which changes to the Helm
+	// release are allowed. If nil, changes are allowed at any time.
+	Schedule *schedule.Schedule
 }
 
 // ActionReconciler is an interface which defines the methods that a reconciler
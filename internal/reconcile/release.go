@@ -25,6 +25,7 @@ import (
 	"github.com/fluxcd/pkg/runtime/conditions"
 	helmrelease "helm.sh/helm/v3/pkg/release"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	v2 "github.com/fluxcd/helm-controller/api/v2"
 	"github.com/fluxcd/helm-controller/internal/action"
@@ -99,6 +100,27 @@ func (r observedReleases) recordOnObject(obj *v2.HelmRelease, mutators ...mutate
 	}
 }
 
+// recordInventory records the inventory of the objects in the manifest of
+// the latest observed release on the HelmRelease object, using the
+// RESTMapper of the given getter to determine the scope of the objects.
+// The inventory of the object is left untouched if there are no observed
+// releases, or the inventory can not be composed.
+func (r observedReleases) recordInventory(obj *v2.HelmRelease, getter genericclioptions.RESTClientGetter) error {
+	if len(r) == 0 {
+		return nil
+	}
+	mapper, err := getter.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+	inventory, err := release.ObservedToInventory(r[r.sortedVersions()[0]], mapper)
+	if err != nil {
+		return err
+	}
+	obj.Status.Inventory = inventory
+	return nil
+}
+
 func mutateOCIDigest(obj *v2.HelmRelease, obs release.Observation) release.Observation {
 	obs.OCIDigest = obj.Status.LastAttemptedRevisionDigest
 	return obs
@@ -0,0 +1,285 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/ssa"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/action"
+	"github.com/fluxcd/helm-controller/internal/outputs"
+	"github.com/fluxcd/helm-controller/internal/strings"
+)
+
+// ReleaseOutputs attempts to write the outputs declared in the Spec.Outputs
+// of the v2.HelmRelease to a ConfigMap or Secret, based on the given Request
+// data.
+//
+// It does this by evaluating the declared outputs against the latest
+// release in the Helm storage, and then reconciling the ConfigMap or Secret
+// using a server-side apply. The object is owned by the v2.HelmRelease, and
+// is garbage collected by Kubernetes when the v2.HelmRelease is deleted.
+// An existing object which is not owned by the v2.HelmRelease is never
+// overwritten.
+//
+// The object is written using the (impersonated) client of the
+// ConfigFactory, unless the v2.HelmRelease targets a remote cluster using a
+// KubeConfig. In which case it is written using the client of the
+// reconciler, as the object must exist next to the v2.HelmRelease.
+//
+// The outputs are only written when the latest release in the history of
+// the v2.HelmRelease has been deployed successfully. When the server-side
+// apply succeeds, a reference to the object is written to the
+// Status.Outputs field of the v2.HelmRelease.
+//
+// When the Status.Outputs differs from the object to be applied, or the
+// outputs have been removed from the spec, the existing object is deleted
+// if it is owned by the v2.HelmRelease. If the deletion or the server-side
+// apply fails, the error is returned to the caller and indicates they should
+// retry.
+type ReleaseOutputs struct {
+	client        client.Client
+	configFactory *action.ConfigFactory
+	eventRecorder record.EventRecorder
+	fieldManager  string
+}
+
+// NewReleaseOutputs returns a new ReleaseOutputs reconciler configured with
+// the provided values.
+func NewReleaseOutputs(client client.Client, cfg *action.ConfigFactory, recorder record.EventRecorder, fieldManager string) *ReleaseOutputs {
+	return &ReleaseOutputs{
+		client:        client,
+		configFactory: cfg,
+		eventRecorder: recorder,
+		fieldManager:  fieldManager,
+	}
+}
+
+func (r *ReleaseOutputs) Reconcile(ctx context.Context, req *Request) error {
+	obj := req.Object
+
+	var desired *meta.NamespacedObjectKindReference
+	if obj.Spec.Outputs != nil {
+		desired = &meta.NamespacedObjectKindReference{
+			APIVersion: "v1",
+			Kind:       obj.Spec.Outputs.GetKind(),
+			Name:       obj.GetOutputsName(),
+			Namespace:  obj.GetNamespace(),
+		}
+	}
+	if desired == nil && obj.Status.Outputs == nil {
+		return nil
+	}
+
+	// Construct a client for the target cluster, to retrieve the objects of
+	// the release with.
+	c, err := action.NewClient(r.configFactory.Getter, client.Options{})
+	if err != nil {
+		return err
+	}
+
+	// Write the outputs as the impersonated identity, unless the target is
+	// a remote cluster.
+	writer := c
+	if obj.Spec.KubeConfig != nil {
+		writer = r.client
+	}
+
+	// The outputs have been removed, or the object they are written to has
+	// changed. Delete the previous object.
+	if cur := obj.Status.Outputs; cur != nil && (desired == nil || *cur != *desired) {
+		if err := r.reconcileDelete(ctx, writer, obj); err != nil {
+			return err
+		}
+	}
+
+	if desired == nil {
+		return nil
+	}
+
+	// Only write the outputs for a successfully deployed release.
+	cur := obj.Status.History.Latest()
+	if cur == nil || cur.Status != helmrelease.StatusDeployed.String() {
+		return nil
+	}
+
+	rls, err := action.VerifySnapshot(r.configFactory.Build(nil), cur)
+	if err != nil {
+		return fmt.Errorf("failed to get release to build outputs from: %w", err)
+	}
+
+	data, err := outputs.Build(ctx, c, rls, *obj.Spec.Outputs)
+	if err != nil {
+		return fmt.Errorf("failed to build outputs: %w", err)
+	}
+
+	// Run using server-side apply.
+	entry, err := applyOwnedObject(ctx, writer, obj, buildOwnedObject(obj, *desired, data), r.fieldManager)
+	if err != nil {
+		err = fmt.Errorf("failed to write outputs: %w", err)
+		r.eventRecorder.Eventf(req.Object, eventv1.EventTypeTrace, "OutputsSyncErr", err.Error())
+		return err
+	}
+
+	// Consult the entry result and act accordingly.
+	switch entry.Action {
+	case ssa.CreatedAction, ssa.ConfiguredAction:
+		msg := strings.Normalize(fmt.Sprintf("%s %s with outputs of release %s",
+			entry.Action.String(), entry.Subject, cur.FullReleaseName()))
+
+		ctrl.LoggerFrom(ctx).Info(msg)
+		r.eventRecorder.Eventf(req.Object, eventv1.EventTypeTrace,
+			fmt.Sprintf("Outputs%s", strings.Title(entry.Action.String())), msg)
+	case ssa.UnchangedAction:
+		// Nothing to do.
+	default:
+		return fmt.Errorf("unexpected action '%s' for %s", entry.Action.String(), entry.Subject)
+	}
+
+	obj.Status.Outputs = desired
+
+	return nil
+}
+
+// reconcileDelete deletes the ConfigMap or Secret referenced in the
+// Status.Outputs of the given v2.HelmRelease using the provided client, if
+// it is owned by the v2.HelmRelease.
+func (r *ReleaseOutputs) reconcileDelete(ctx context.Context, c client.Client, obj *v2.HelmRelease) error {
+	ref := obj.Status.Outputs
+
+	deleted, err := deleteOwnedObject(ctx, c, obj, *ref)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s '%s/%s': %w", ref.Kind, ref.Namespace, ref.Name, err)
+	}
+	if deleted {
+		r.eventRecorder.Eventf(obj, eventv1.EventTypeTrace, "OutputsDeleted",
+			"deleted %s '%s/%s'", ref.Kind, ref.Namespace, ref.Name)
+	}
+
+	obj.Status.Outputs = nil
+	return nil
+}
+
+// applyOwnedObject applies the given object owned by the v2.HelmRelease
+// using a server-side apply with the provided client. It returns an error
+// without applying the object if an object with the same name exists which
+// is not controlled by the v2.HelmRelease, to prevent it from being taken
+// over.
+func applyOwnedObject(ctx context.Context, c client.Client, obj *v2.HelmRelease,
+	u *unstructured.Unstructured, fieldManager string) (*ssa.ChangeSetEntry, error) {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(u.GroupVersionKind())
+	if err := c.Get(ctx, client.ObjectKeyFromObject(u), existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	} else if !isControlledBy(existing, obj) {
+		return nil, fmt.Errorf("refusing to overwrite %s '%s/%s': not owned by the HelmRelease",
+			u.GetKind(), u.GetNamespace(), u.GetName())
+	}
+
+	rm := ssa.NewResourceManager(c, nil, ssa.Owner{
+		Group: v2.GroupVersion.Group,
+		Field: fieldManager,
+	})
+
+	// Mark the object as owned by the HelmRelease.
+	rm.SetOwnerLabels([]*unstructured.Unstructured{u}, obj.GetName(), obj.GetNamespace())
+
+	return rm.Apply(ctx, u, ssa.DefaultApplyOptions())
+}
+
+// deleteOwnedObject deletes the object of the given reference using the
+// provided client, if it is controlled by the v2.HelmRelease. It returns
+// true if the object was deleted.
+func deleteOwnedObject(ctx context.Context, c client.Client, obj *v2.HelmRelease,
+	ref meta.NamespacedObjectKindReference) (bool, error) {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(ref.APIVersion)
+	u.SetKind(ref.Kind)
+	if err := c.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, u); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if !isControlledBy(u, obj) {
+		return false, nil
+	}
+
+	uid := u.GetUID()
+	if err := c.Delete(ctx, u, client.Preconditions{UID: &uid}); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return true, nil
+}
+
+// isControlledBy returns true if the given object has a controller reference
+// to the v2.HelmRelease.
+func isControlledBy(o metav1.Object, obj *v2.HelmRelease) bool {
+	ref := metav1.GetControllerOfNoCopy(o)
+	if ref == nil {
+		return false
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	return err == nil && gv.Group == v2.GroupVersion.Group && ref.Kind == v2.HelmReleaseKind &&
+		ref.Name == obj.GetName() && ref.UID == obj.GetUID()
+}
+
+// buildOwnedObject builds the ConfigMap or Secret for the given reference
+// with the provided data, owned by the v2.HelmRelease.
+//
+// The owner reference does not block the deletion of the owner, as this
+// requires the permission to update the finalizers of the HelmRelease when
+// the OwnerReferencesPermissionEnforcement admission plugin is enabled,
+// which the ServiceAccount writing the object typically lacks.
+func buildOwnedObject(obj *v2.HelmRelease, ref meta.NamespacedObjectKindReference, data map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(ref.APIVersion)
+	u.SetKind(ref.Kind)
+	u.SetName(ref.Name)
+	u.SetNamespace(ref.Namespace)
+	ownerRef := metav1.NewControllerRef(obj, v2.GroupVersion.WithKind(v2.HelmReleaseKind))
+	ownerRef.BlockOwnerDeletion = nil
+	u.SetOwnerReferences([]metav1.OwnerReference{*ownerRef})
+
+	content := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		if ref.Kind == v2.OutputsKindSecret {
+			v = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		content[k] = v
+	}
+	u.Object["data"] = content
+	if ref.Kind == v2.OutputsKindSecret {
+		u.Object["type"] = "Opaque"
+	}
+	return u
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	helmstorage "helm.sh/helm/v3/pkg/storage"
+	helmdriver "helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/action"
+	"github.com/fluxcd/helm-controller/internal/release"
+	"github.com/fluxcd/helm-controller/internal/testutil"
+)
+
+func TestReleaseOutputs_Reconcile(t *testing.T) {
+	g := NewWithT(t)
+
+	namespace, err := testEnv.CreateNamespace(context.TODO(), "release-outputs")
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() {
+		_ = testEnv.Delete(context.TODO(), namespace)
+	})
+
+	rls := testutil.BuildRelease(&helmrelease.MockReleaseOptions{
+		Name:      mockReleaseName,
+		Namespace: namespace.Name,
+		Version:   1,
+		Chart:     testutil.BuildChart(),
+		Status:    helmrelease.StatusDeployed,
+	})
+
+	newObj := func() *v2.HelmRelease {
+		return &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "outputs",
+				Namespace: namespace.Name,
+				UID:       "a-uid",
+			},
+			Spec: v2.HelmReleaseSpec{
+				ReleaseName:      mockReleaseName,
+				TargetNamespace:  namespace.Name,
+				StorageNamespace: namespace.Name,
+				Outputs: &v2.Outputs{
+					Values: []v2.OutputValue{
+						{Key: "appVersion", Source: v2.OutputSourceChart, JSONPath: "{.appVersion}"},
+						{Key: "name", Source: v2.OutputSourceValues, JSONPath: "{.name}"},
+					},
+				},
+			},
+			Status: v2.HelmReleaseStatus{
+				History: v2.Snapshots{
+					release.ObservedToSnapshot(release.ObserveRelease(rls)),
+				},
+			},
+		}
+	}
+
+	newConfigFactory := func(g *WithT, obj *v2.HelmRelease) *action.ConfigFactory {
+		getter, err := RESTClientGetterFromManager(testEnv.Manager, obj.GetReleaseNamespace())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		cfg, err := action.NewConfigFactory(getter,
+			action.WithStorage(helmdriver.MemoryDriverName, obj.GetStorageNamespace()),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		store := helmstorage.Init(cfg.Driver)
+		g.Expect(store.Create(rls)).To(Succeed())
+		return cfg
+	}
+
+	t.Run("writes outputs to ConfigMap", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := newObj()
+		r := NewReleaseOutputs(testEnv, newConfigFactory(g, obj), record.NewFakeRecorder(32), testFieldManager)
+		g.Expect(r.Reconcile(context.TODO(), &Request{Object: obj})).To(Succeed())
+
+		g.Expect(obj.Status.Outputs).To(Equal(&meta.NamespacedObjectKindReference{
+			APIVersion: "v1",
+			Kind:       v2.OutputsKindConfigMap,
+			Name:       "outputs-outputs",
+			Namespace:  namespace.Name,
+		}))
+
+		var cm corev1.ConfigMap
+		g.Expect(testEnv.Get(context.TODO(), types.NamespacedName{Namespace: namespace.Name, Name: "outputs-outputs"}, &cm)).To(Succeed())
+		g.Expect(cm.Data).To(Equal(map[string]string{
+			"appVersion": "1.2.3",
+			"name":       "value",
+		}))
+		g.Expect(cm.OwnerReferences).To(HaveLen(1))
+		g.Expect(cm.OwnerReferences[0].Kind).To(Equal(v2.HelmReleaseKind))
+		g.Expect(cm.OwnerReferences[0].BlockOwnerDeletion).To(BeNil())
+		g.Expect(cm.Labels).To(HaveKeyWithValue(v2.GroupVersion.Group+"/name", obj.Name))
+	})
+
+	t.Run("writes outputs to Secret", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := newObj()
+		obj.Spec.Outputs.Kind = v2.OutputsKindSecret
+		obj.Spec.Outputs.Name = "outputs-secret"
+
+		r := NewReleaseOutputs(testEnv, newConfigFactory(g, obj), record.NewFakeRecorder(32), testFieldManager)
+		g.Expect(r.Reconcile(context.TODO(), &Request{Object: obj})).To(Succeed())
+
+		var secret corev1.Secret
+		g.Expect(testEnv.Get(context.TODO(), types.NamespacedName{Namespace: namespace.Name, Name: "outputs-secret"}, &secret)).To(Succeed())
+		g.Expect(secret.Data).To(HaveKeyWithValue("appVersion", []byte("1.2.3")))
+	})
+
+	t.Run("refuses to overwrite object not owned by HelmRelease", func(t *testing.T) {
+		g := NewWithT(t)
+
+		existing := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "existing",
+				Namespace: namespace.Name,
+			},
+			Data: map[string]string{"foo": "bar"},
+		}
+		g.Expect(testEnv.Create(context.TODO(), existing)).To(Succeed())
+
+		obj := newObj()
+		obj.Spec.Outputs.Name = existing.Name
+
+		r := NewReleaseOutputs(testEnv, newConfigFactory(g, obj), record.NewFakeRecorder(32), testFieldManager)
+		err := r.Reconcile(context.TODO(), &Request{Object: obj})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("not owned by the HelmRelease"))
+		g.Expect(obj.Status.Outputs).To(BeNil())
+
+		g.Expect(testEnv.Get(context.TODO(), types.NamespacedName{Namespace: existing.Namespace, Name: existing.Name}, existing)).To(Succeed())
+		g.Expect(existing.Data).To(Equal(map[string]string{"foo": "bar"}))
+		g.Expect(existing.OwnerReferences).To(BeEmpty())
+	})
+
+	t.Run("deletes previous outputs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := newObj()
+		obj.Spec.Outputs = nil
+
+		previous := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "previous-outputs",
+				Namespace: namespace.Name,
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(obj, v2.GroupVersion.WithKind(v2.HelmReleaseKind)),
+				},
+			},
+		}
+		g.Expect(testEnv.Create(context.TODO(), previous)).To(Succeed())
+
+		obj.Status.Outputs = &meta.NamespacedObjectKindReference{
+			APIVersion: "v1",
+			Kind:       v2.OutputsKindConfigMap,
+			Name:       previous.Name,
+			Namespace:  previous.Namespace,
+		}
+
+		r := NewReleaseOutputs(testEnv, newConfigFactory(g, obj), record.NewFakeRecorder(32), testFieldManager)
+		g.Expect(r.Reconcile(context.TODO(), &Request{Object: obj})).To(Succeed())
+		g.Expect(obj.Status.Outputs).To(BeNil())
+
+		err := testEnv.Get(context.TODO(), types.NamespacedName{Namespace: previous.Namespace, Name: previous.Name}, previous)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	t.Run("keeps previous outputs not owned by HelmRelease", func(t *testing.T) {
+		g := NewWithT(t)
+
+		previous := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "unowned-outputs",
+				Namespace: namespace.Name,
+			},
+		}
+		g.Expect(testEnv.Create(context.TODO(), previous)).To(Succeed())
+
+		obj := newObj()
+		obj.Spec.Outputs = nil
+		obj.Status.Outputs = &meta.NamespacedObjectKindReference{
+			APIVersion: "v1",
+			Kind:       v2.OutputsKindConfigMap,
+			Name:       previous.Name,
+			Namespace:  previous.Namespace,
+		}
+
+		r := NewReleaseOutputs(testEnv, newConfigFactory(g, obj), record.NewFakeRecorder(32), testFieldManager)
+		g.Expect(r.Reconcile(context.TODO(), &Request{Object: obj})).To(Succeed())
+		g.Expect(obj.Status.Outputs).To(BeNil())
+
+		g.Expect(testEnv.Get(context.TODO(), types.NamespacedName{Namespace: previous.Namespace, Name: previous.Name}, previous)).To(Succeed())
+	})
+
+	t.Run("skips release which is not deployed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := newObj()
+		obj.Status.History[0].Status = helmrelease.StatusFailed.String()
+
+		r := NewReleaseOutputs(testEnv, newConfigFactory(g, obj), record.NewFakeRecorder(32), testFieldManager)
+		g.Expect(r.Reconcile(context.TODO(), &Request{Object: obj})).To(Succeed())
+		g.Expect(obj.Status.Outputs).To(BeNil())
+	})
+}
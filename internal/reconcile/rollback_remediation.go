@@ -75,9 +75,10 @@ func NewRollbackRemediation(configFactory *action.ConfigFactory, eventRecorder r
 
 func (r *RollbackRemediation) Reconcile(ctx context.Context, req *Request) error {
 	var (
-		cur    = req.Object.Status.History.Latest().DeepCopy()
-		logBuf = action.NewLogBuffer(action.NewDebugLog(ctrl.LoggerFrom(ctx).V(logger.DebugLevel)), 10)
-		cfg    = r.configFactory.Build(logBuf.Log, observeRollback(req.Object))
+		cur         = req.Object.Status.History.Latest().DeepCopy()
+		logBuf      = action.NewLogBuffer(action.NewDebugLog(ctrl.LoggerFrom(ctx).V(logger.DebugLevel)), 10)
+		obsReleases = make(observedReleases)
+		cfg         = r.configFactory.Build(logBuf.Log, observeRollback(req.Object), observeRelease(obsReleases))
 	)
 
 	defer summarize(req)
@@ -107,6 +108,11 @@ func (r *RollbackRemediation) Reconcile(ctx context.Context, req *Request) error
 		return nil
 	}
 
+	// Record the inventory of the objects in the release manifest.
+	if err := obsReleases.recordInventory(req.Object, r.configFactory.Getter); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to record inventory of release objects")
+	}
+
 	r.success(req, prev)
 	return nil
 }
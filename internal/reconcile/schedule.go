@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/schedule"
+)
+
+// WaitingForWindowError is returned when an action must wait for a
+// maintenance window in which it is allowed to run.
+type WaitingForWindowError struct {
+	// Action is the name of the action which waits for the window.
+	Action string
+	// Next is the time at which the action is allowed to run, or the zero
+	// time if it could not be determined.
+	Next time.Time
+}
+
+func (e *WaitingForWindowError) Error() string {
+	if e.Next.IsZero() {
+		return fmt.Sprintf("waiting for maintenance window to run '%s' action: no upcoming window found", e.Action)
+	}
+	return fmt.Sprintf("waiting for maintenance window to run '%s' action: next window starts at %s",
+		e.Action, e.Next.UTC().Format(time.RFC3339))
+}
+
+// WaitForWindow returns a WaitingForWindowError if the given action is not
+// allowed to run at the given time according to the schedule, and marks the
+// object with Ready=False. Helm installs and uninstalls are only subject to
+// the schedule when it is configured to include them, but Helm installs are
+// always subject to the cluster-wide freeze windows.
+func WaitForWindow(obj *v2.HelmRelease, s *schedule.Schedule, action ActionReconciler, now time.Time) error {
+	s = scheduleFor(s, action)
+	if s.Allowed(now) {
+		return nil
+	}
+	err := &WaitingForWindowError{Action: action.Name(), Next: s.NextAllowed(now)}
+	conditions.MarkFalse(obj, meta.ReadyCondition, v2.WaitingForWindowReason, "%s", err.Error())
+	return err
+}
+
+// scheduleFor returns the part of the schedule the given action is subject
+// to, or nil if it is not subject to the schedule.
+func scheduleFor(s *schedule.Schedule, action ActionReconciler) *schedule.Schedule {
+	if s == nil {
+		return nil
+	}
+	switch action.(type) {
+	case *Upgrade, *RollbackRemediation, *CorrectClusterDrift:
+		return s
+	case *Install:
+		if s.Install {
+			return s
+		}
+		return s.Freeze()
+	case *Uninstall, *UninstallRemediation:
+		if s.Uninstall {
+			return s
+		}
+		return nil
+	default:
+		return nil
+	}
+}
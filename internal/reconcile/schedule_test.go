@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/schedule"
+)
+
+func TestWaitForWindow(t *testing.T) {
+	// Monday 4 March 2024, 12:00 UTC.
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	// Allow changes at night only.
+	nightly, err := schedule.New(&v2.Schedule{
+		Windows: []v2.ScheduleWindow{
+			{Start: "0 22 * * *", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withInstall := *nightly
+	withInstall.Install = true
+
+	// Freeze changes until the evening.
+	freeze, err := schedule.ParseWindowFlag("0 0 * * *;22h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withFreeze := *nightly
+	withFreeze.FreezeWindows = []schedule.Window{freeze}
+
+	tests := []struct {
+		name     string
+		schedule *schedule.Schedule
+		action   ActionReconciler
+		wantWait bool
+	}{
+		{
+			name:   "without schedule",
+			action: &Upgrade{},
+		},
+		{
+			name:     "upgrade outside window",
+			schedule: nightly,
+			action:   &Upgrade{},
+			wantWait: true,
+		},
+		{
+			name:     "rollback outside window",
+			schedule: nightly,
+			action:   &RollbackRemediation{},
+			wantWait: true,
+		},
+		{
+			name:     "drift correction outside window",
+			schedule: nightly,
+			action:   &CorrectClusterDrift{},
+			wantWait: true,
+		},
+		{
+			name:     "install outside window",
+			schedule: nightly,
+			action:   &Install{},
+		},
+		{
+			name:     "install outside window with install scheduled",
+			schedule: &withInstall,
+			action:   &Install{},
+			wantWait: true,
+		},
+		{
+			name:     "install inside cluster-wide freeze",
+			schedule: &withFreeze,
+			action:   &Install{},
+			wantWait: true,
+		},
+		{
+			name:     "uninstall inside cluster-wide freeze",
+			schedule: &withFreeze,
+			action:   &Uninstall{},
+		},
+		{
+			name:     "test outside window",
+			schedule: nightly,
+			action:   &Test{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &v2.HelmRelease{}
+			err := WaitForWindow(obj, tt.schedule, tt.action, now)
+			if !tt.wantWait {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(conditions.Has(obj, meta.ReadyCondition)).To(BeFalse())
+				return
+			}
+
+			var windowErr *WaitingForWindowError
+			g.Expect(errors.As(err, &windowErr)).To(BeTrue())
+			g.Expect(windowErr.Action).To(Equal(tt.action.Name()))
+			g.Expect(windowErr.Next).To(BeTemporally("==", time.Date(2024, 3, 4, 22, 0, 0, 0, time.UTC)))
+			g.Expect(conditions.IsFalse(obj, meta.ReadyCondition)).To(BeTrue())
+			g.Expect(conditions.GetReason(obj, meta.ReadyCondition)).To(Equal(v2.WaitingForWindowReason))
+			g.Expect(conditions.GetMessage(obj, meta.ReadyCondition)).To(ContainSubstring("2024-03-04T22:00:00Z"))
+		})
+	}
+}
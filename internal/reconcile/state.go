@@ -26,12 +26,15 @@ import (
 	"github.com/fluxcd/pkg/ssa/jsondiff"
 	"helm.sh/helm/v3/pkg/kube"
 	helmrelease "helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	apierrutil "k8s.io/apimachinery/pkg/util/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/fluxcd/helm-controller/internal/action"
 	"github.com/fluxcd/helm-controller/internal/digest"
 	interrors "github.com/fluxcd/helm-controller/internal/errors"
 	"github.com/fluxcd/helm-controller/internal/postrender"
+	intvalues "github.com/fluxcd/helm-controller/internal/values"
 )
 
 // ReleaseStatus represents the status of a Helm release as determined by
@@ -84,6 +87,10 @@ type ReleaseState struct {
 	// Diff contains any differences between the Helm storage manifest and the
 	// cluster state when Status equals ReleaseStatusDrifted.
 	Diff jsondiff.DiffSet
+	// Orphans contains the objects in the cluster which carry the origin
+	// labels of the object, but are not part of the Helm storage manifest,
+	// when Status equals ReleaseStatusDrifted.
+	Orphans []*unstructured.Unstructured
 }
 
 // DetermineReleaseState determines the state of the Helm release as compared
@@ -161,6 +168,11 @@ func DetermineReleaseState(ctx context.Context, cfg *action.ConfigFactory, req *
 			if postrenderersDigest != req.Object.Status.ObservedPostRenderersDigest {
 				return ReleaseState{Status: ReleaseStatusOutOfSync, Reason: "postrenderers digest has changed"}, nil
 			}
+
+			valuesMergeDigest := intvalues.Digest(digest.Canonical, req.Object.Spec.ValuesFrom).String()
+			if valuesMergeDigest != req.Object.Status.ObservedValuesMergeDigest {
+				return ReleaseState{Status: ReleaseStatusOutOfSync, Reason: "values merge strategies digest has changed"}, nil
+			}
 		}
 
 		// For the further determination of test results, we look at the
@@ -181,16 +193,17 @@ func DetermineReleaseState(ctx context.Context, cfg *action.ConfigFactory, req *
 
 		// Confirm the cluster state matches the desired config.
 		if diffOpts := req.Object.GetDriftDetection(); diffOpts.MustDetectChanges() {
-			diffSet, err := action.Diff(ctx, cfg.Build(nil), rls, kube.ManagedFieldsManager, req.Object.GetDriftDetection().Ignore...)
-			hasChanges := diffSet.HasChanges()
-			if err != nil {
+			diffSet, diffErr := action.Diff(ctx, cfg.Build(nil), rls, kube.ManagedFieldsManager, req.Object.GetDriftDetection().Ignore...)
+			orphans, orphansErr := action.Orphans(ctx, cfg.Build(nil), req.Object, rls)
+			hasChanges := diffSet.HasChanges() || len(orphans) > 0
+			if err := apierrutil.NewAggregate([]error{diffErr, orphansErr}); err != nil {
 				if !hasChanges {
 					return ReleaseState{Status: ReleaseStatusUnknown}, fmt.Errorf("unable to determine cluster state: %w", err)
 				}
 				ctrl.LoggerFrom(ctx).Error(err, "diff of release against cluster state completed with error")
 			}
 			if hasChanges {
-				return ReleaseState{Status: ReleaseStatusDrifted, Diff: diffSet}, nil
+				return ReleaseState{Status: ReleaseStatusDrifted, Diff: diffSet, Orphans: orphans}, nil
 			}
 		}
 
@@ -491,6 +491,42 @@ func Test_DetermineReleaseState(t *testing.T) {
 				Status: ReleaseStatusOutOfSync,
 			},
 		},
+		{
+			name: "values merge strategies changed",
+			releases: []*helmrelease.Release{
+				testutil.BuildRelease(&helmrelease.MockReleaseOptions{
+					Name:      mockReleaseName,
+					Namespace: mockReleaseNamespace,
+					Version:   1,
+					Status:    helmrelease.StatusDeployed,
+					Chart:     testutil.BuildChart(),
+				}, testutil.ReleaseWithConfig(map[string]interface{}{"foo": "bar"})),
+			},
+			spec: func(spec *v2.HelmReleaseSpec) {
+				spec.ValuesFrom = []v2.ValuesReference{
+					{Kind: "ConfigMap", Name: "values", MergeStrategy: v2.ValuesMergeStrategyAppendLists},
+				}
+			},
+			status: func(releases []*helmrelease.Release) v2.HelmReleaseStatus {
+				return v2.HelmReleaseStatus{
+					History: v2.Snapshots{
+						release.ObservedToSnapshot(release.ObserveRelease(releases[0])),
+					},
+					Conditions: []metav1.Condition{
+						{
+							Type:               meta.ReadyCondition,
+							Status:             metav1.ConditionTrue,
+							ObservedGeneration: 1,
+						},
+					},
+				}
+			},
+			chart:  testutil.BuildChart(),
+			values: map[string]interface{}{"foo": "bar"},
+			want: ReleaseState{
+				Status: ReleaseStatusOutOfSync,
+			},
+		},
 		{
 			name: "postRenderers mismatch ignored for processed generation",
 			releases: []*helmrelease.Release{
@@ -585,6 +621,7 @@ func TestDetermineReleaseState_DriftDetection(t *testing.T) {
 		name          string
 		driftMode     v2.DriftDetectionMode
 		applyManifest bool
+		orphan        bool
 		want          func(namespace string) ReleaseState
 	}{
 		{
@@ -627,6 +664,15 @@ func TestDetermineReleaseState_DriftDetection(t *testing.T) {
 				return ReleaseState{Status: ReleaseStatusInSync}
 			},
 		},
+		{
+			name:          "with orphan and detection mode enabled without prune",
+			driftMode:     v2.DriftDetectionEnabled,
+			applyManifest: true,
+			orphan:        true,
+			want: func(_ string) ReleaseState {
+				return ReleaseState{Status: ReleaseStatusDrifted}
+			},
+		},
 		{
 			name:      "with drift and detection mode warn",
 			driftMode: v2.DriftDetectionWarn,
@@ -712,6 +758,17 @@ func TestDetermineReleaseState_DriftDetection(t *testing.T) {
 						"meta.helm.sh/release-namespace": rls.Namespace,
 					})
 					g.Expect(testEnv.Create(context.Background(), obj)).To(Succeed())
+
+					if tt.orphan {
+						orphan := obj.DeepCopy()
+						orphan.SetName("orphan")
+						orphan.SetResourceVersion("")
+						orphan.SetLabels(map[string]string{
+							v2.GroupVersion.Group + "/name":      "",
+							v2.GroupVersion.Group + "/namespace": "",
+						})
+						g.Expect(testEnv.Create(context.Background(), orphan)).To(Succeed())
+					}
 				}
 			}
 
@@ -749,6 +806,12 @@ func TestDetermineReleaseState_DriftDetection(t *testing.T) {
 			})
 			g.Expect(err).ToNot(HaveOccurred())
 
+			if tt.orphan {
+				g.Expect(got.Orphans).To(HaveLen(1))
+				g.Expect(got.Orphans[0].GetName()).To(Equal("orphan"))
+				got.Orphans = nil
+			}
+
 			want := tt.want(releaseNamespace)
 			g.Expect(got).To(Equal(want))
 		})
@@ -101,6 +101,7 @@ func (r *Uninstall) Reconcile(ctx context.Context, req *Request) error {
 	if errors.Is(err, helmdriver.ErrReleaseNotFound) {
 		conditions.MarkFalse(req.Object, v2.ReleasedCondition, v2.UninstallSucceededReason,
 			"Release %s was not found, assuming it is uninstalled", cur.FullReleaseName())
+		req.Object.Status.Inventory = nil
 		return nil
 	}
 
@@ -113,6 +114,7 @@ func (r *Uninstall) Reconcile(ctx context.Context, req *Request) error {
 	if err != nil && req.Object.GetUninstall().KeepHistory && strings.Contains(err.Error(), "is already deleted") {
 		conditions.MarkFalse(req.Object, v2.ReleasedCondition, v2.UninstallSucceededReason,
 			"Release %s was already uninstalled", cur.FullReleaseName())
+		req.Object.Status.Inventory = nil
 		return nil
 	}
 
@@ -141,6 +143,9 @@ func (r *Uninstall) Reconcile(ctx context.Context, req *Request) error {
 		return err
 	}
 
+	// The objects of the release have been removed from the cluster.
+	req.Object.Status.Inventory = nil
+
 	// Mark success.
 	r.success(req)
 	return nil
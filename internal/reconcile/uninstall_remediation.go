@@ -116,6 +116,9 @@ func (r *UninstallRemediation) Reconcile(ctx context.Context, req *Request) erro
 		return nil
 	}
 
+	// The objects of the release have been removed from the cluster.
+	req.Object.Status.Inventory = nil
+
 	// Mark success.
 	r.success(req)
 	return nil
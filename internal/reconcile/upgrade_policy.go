@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+const (
+	// fmtUpgradeHeld is the message format for a Helm upgrade which is held
+	// by the upgrade policy.
+	fmtUpgradeHeld = "Helm upgrade for release %s/%s from chart version %s to %s is a %s version bump, " +
+		"set annotation '%s' to the value of '%s' to release it"
+	// fmtDowngradeRefused is the message format for a Helm upgrade which is
+	// refused by the upgrade policy.
+	fmtDowngradeRefused = "Helm upgrade for release %s/%s from chart version %s to %s is a downgrade, " +
+		"set annotation '%s' to the value of '%s' to force it"
+)
+
+// versionBumpRank orders the v2.VersionBump values by size.
+var versionBumpRank = map[v2.VersionBump]int{
+	v2.VersionBumpPatch: 0,
+	v2.VersionBumpMinor: 1,
+	v2.VersionBumpMajor: 2,
+}
+
+// upgradePolicyGate returns the UpgradeGate the pending Helm upgrade of the
+// given Request is held behind by the given policy, or nil if the upgrade
+// is allowed to be performed automatically. The chart version of the
+// pending upgrade is compared to the chart version of the latest release in
+// the history of the Request.Object.
+func upgradePolicyGate(req *Request, policy v2.UpgradePolicy) *UpgradeGate {
+	latest := req.Object.Status.History.Latest()
+	if latest == nil {
+		return nil
+	}
+
+	current, target := latest.ChartVersion, req.Chart.Metadata.Version
+	bump, downgrade := versionBump(current, target)
+	switch {
+	case downgrade:
+		return &UpgradeGate{
+			Condition: v2.PendingUpgradeCondition,
+			Reason:    v2.DowngradeRefusedReason,
+			Message: fmt.Sprintf(fmtDowngradeRefused, req.Object.GetReleaseNamespace(), req.Object.GetReleaseName(),
+				current, target, v2.ForceRequestAnnotation, meta.ReconcileRequestAnnotation),
+		}
+	case versionBumpRank[bump] > versionBumpRank[policy.GetAutomatic()]:
+		return &UpgradeGate{
+			Condition: v2.PendingUpgradeCondition,
+			Reason:    v2.UpgradeHeldReason,
+			Message: fmt.Sprintf(fmtUpgradeHeld, req.Object.GetReleaseNamespace(), req.Object.GetReleaseName(),
+				current, target, bump, v2.UpgradeRequestAnnotation, meta.ReconcileRequestAnnotation),
+		}
+	default:
+		return nil
+	}
+}
+
+// versionBump returns the semantic version bump from the current to the
+// target version, and whether the target version is lower than the current
+// version. Versions which are equal apart from their build metadata are
+// considered a patch bump. If either version is not a valid semantic
+// version, it is considered a major bump.
+func versionBump(current, target string) (v2.VersionBump, bool) {
+	cur, err := semver.NewVersion(current)
+	if err != nil {
+		return v2.VersionBumpMajor, false
+	}
+	tgt, err := semver.NewVersion(target)
+	if err != nil {
+		return v2.VersionBumpMajor, false
+	}
+
+	switch {
+	case tgt.LessThan(cur):
+		return "", true
+	case tgt.Major() != cur.Major():
+		return v2.VersionBumpMajor, false
+	case tgt.Minor() != cur.Minor():
+		return v2.VersionBumpMinor, false
+	default:
+		return v2.VersionBumpPatch, false
+	}
+}
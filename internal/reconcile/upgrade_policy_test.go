@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/testutil"
+)
+
+func Test_versionBump(t *testing.T) {
+	tests := []struct {
+		name          string
+		current       string
+		target        string
+		wantBump      v2.VersionBump
+		wantDowngrade bool
+	}{
+		{name: "equal", current: "1.2.3", target: "1.2.3", wantBump: v2.VersionBumpPatch},
+		{name: "build metadata", current: "1.2.3", target: "1.2.3+abc", wantBump: v2.VersionBumpPatch},
+		{name: "patch", current: "1.2.3", target: "1.2.4", wantBump: v2.VersionBumpPatch},
+		{name: "minor", current: "1.2.3", target: "1.3.0", wantBump: v2.VersionBumpMinor},
+		{name: "minor pre-release", current: "1.2.3", target: "1.3.0-rc.1", wantBump: v2.VersionBumpMinor},
+		{name: "major", current: "1.2.3", target: "2.0.0", wantBump: v2.VersionBumpMajor},
+		{name: "downgrade", current: "1.2.3", target: "1.2.2", wantDowngrade: true},
+		{name: "downgrade to pre-release", current: "1.2.3", target: "1.2.3-rc.1", wantDowngrade: true},
+		{name: "invalid", current: "1.2.3", target: "latest", wantBump: v2.VersionBumpMajor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			bump, downgrade := versionBump(tt.current, tt.target)
+			g.Expect(bump).To(Equal(tt.wantBump))
+			g.Expect(downgrade).To(Equal(tt.wantDowngrade))
+		})
+	}
+}
+
+func Test_upgradePolicyGate(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     v2.UpgradePolicy
+		history    v2.Snapshots
+		version    string
+		wantReason string
+	}{
+		{
+			name:    "without history",
+			version: "2.0.0",
+		},
+		{
+			name:    "patch bump",
+			history: v2.Snapshots{{ChartVersion: "1.0.0"}},
+			version: "1.0.1",
+		},
+		{
+			name:       "minor bump",
+			history:    v2.Snapshots{{ChartVersion: "1.0.0"}},
+			version:    "1.1.0",
+			wantReason: v2.UpgradeHeldReason,
+		},
+		{
+			name:    "minor bump with automatic minor",
+			policy:  v2.UpgradePolicy{Automatic: v2.VersionBumpMinor},
+			history: v2.Snapshots{{ChartVersion: "1.0.0"}},
+			version: "1.1.0",
+		},
+		{
+			name:       "major bump with automatic minor",
+			policy:     v2.UpgradePolicy{Automatic: v2.VersionBumpMinor},
+			history:    v2.Snapshots{{ChartVersion: "1.0.0"}},
+			version:    "2.0.0",
+			wantReason: v2.UpgradeHeldReason,
+		},
+		{
+			name:       "downgrade with automatic major",
+			policy:     v2.UpgradePolicy{Automatic: v2.VersionBumpMajor},
+			history:    v2.Snapshots{{ChartVersion: "1.0.0"}},
+			version:    "0.9.0",
+			wantReason: v2.DowngradeRefusedReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			req := &Request{
+				Object: &v2.HelmRelease{Status: v2.HelmReleaseStatus{History: tt.history}},
+				Chart:  testutil.BuildChart(testutil.ChartWithVersion(tt.version)),
+			}
+			gate := upgradePolicyGate(req, tt.policy)
+			if tt.wantReason == "" {
+				g.Expect(gate).To(BeNil())
+				return
+			}
+			g.Expect(gate).ToNot(BeNil())
+			g.Expect(gate.Condition).To(Equal(v2.PendingUpgradeCondition))
+			g.Expect(gate.Reason).To(Equal(tt.wantReason))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/fluxcd/cli-utils/pkg/object"
+	ssautil "github.com/fluxcd/pkg/ssa/utils"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+// ObservedToInventory returns a v2.ResourceInventory of the objects in the
+// manifest of the Observation, sorted by ID. Namespaced objects without a
+// namespace default to the namespace of the release, using the given mapper
+// to determine the scope of their kind. Objects of kinds unknown to the
+// mapper are recorded with the namespace of the manifest.
+func ObservedToInventory(rls Observation, mapper apimeta.RESTMapper) (*v2.ResourceInventory, error) {
+	objects, err := ssautil.ReadObjects(strings.NewReader(rls.Manifest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read objects from release manifest: %w", err)
+	}
+
+	var (
+		inventory = &v2.ResourceInventory{Entries: make([]v2.ResourceRef, 0, len(objects))}
+		seen      = make(map[string]struct{}, len(objects))
+	)
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		if obj.GetNamespace() == "" {
+			if mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err == nil &&
+				mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+				obj.SetNamespace(rls.Namespace)
+			}
+		}
+
+		id := object.UnstructuredToObjMetadata(obj).String()
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		inventory.Entries = append(inventory.Entries, v2.ResourceRef{
+			ID:      id,
+			Version: gvk.Version,
+		})
+	}
+	sort.Slice(inventory.Entries, func(i, j int) bool {
+		return inventory.Entries[i].ID < inventory.Entries[j].ID
+	})
+	return inventory, nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func TestObservedToInventory(t *testing.T) {
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, apimeta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, apimeta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, apimeta.RESTScopeRoot)
+
+	tests := []struct {
+		name     string
+		manifest string
+		want     []v2.ResourceRef
+		wantErr  bool
+	}{
+		{
+			name: "returns sorted object references",
+			manifest: `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: other
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: role
+`,
+			want: []v2.ResourceRef{
+				{ID: "_role_rbac.authorization.k8s.io_ClusterRole", Version: "v1"},
+				{ID: "other_config__ConfigMap", Version: "v1"},
+				{ID: "release_app_apps_Deployment", Version: "v1"},
+			},
+		},
+		{
+			name: "deduplicates objects",
+			manifest: `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: release
+`,
+			want: []v2.ResourceRef{
+				{ID: "release_config__ConfigMap", Version: "v1"},
+			},
+		},
+		{
+			name: "keeps namespace of unknown kinds",
+			manifest: `---
+apiVersion: example.com/v1alpha1
+kind: Widget
+metadata:
+  name: widget
+`,
+			want: []v2.ResourceRef{
+				{ID: "_widget_example.com_Widget", Version: "v1alpha1"},
+			},
+		},
+		{
+			name:     "returns empty inventory for empty manifest",
+			manifest: "",
+			want:     []v2.ResourceRef{},
+		},
+		{
+			name:     "returns error for invalid manifest",
+			manifest: "invalid",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := ObservedToInventory(Observation{
+				Name:      "release",
+				Namespace: "release",
+				Manifest:  tt.manifest,
+			}, mapper)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(got).To(BeNil())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got.Entries).To(Equal(tt.want))
+		})
+	}
+}
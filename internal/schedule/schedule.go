@@ -0,0 +1,241 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+const (
+	// FreezeWindowsKey is the ConfigMap key which holds the cluster-wide
+	// freeze windows.
+	FreezeWindowsKey = "windows"
+
+	// maxNextAllowedSteps is the maximum number of window boundaries
+	// Schedule.NextAllowed steps over before it gives up.
+	maxNextAllowedSteps = 1000
+)
+
+// Window is a recurring window of time.
+type Window struct {
+	// Start is the schedule which defines when the window starts.
+	Start cron.Schedule
+	// Duration is the duration of the window after each start.
+	Duration time.Duration
+	// Location is the location in which Start is evaluated.
+	Location *time.Location
+	// Deny is true if changes are denied inside the window.
+	Deny bool
+}
+
+// NewWindow returns a Window for the given v2.ScheduleWindow. The start of
+// the window is parsed as a standard cron expression with five fields, or
+// one of the predefined schedules like @daily. As the window recurs at fixed
+// times, @every is not supported.
+func NewWindow(w v2.ScheduleWindow) (Window, error) {
+	start, err := cron.ParseStandard(w.Start)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid cron expression '%s': %w", w.Start, err)
+	}
+	if _, ok := start.(cron.ConstantDelaySchedule); ok {
+		return Window{}, fmt.Errorf("invalid cron expression '%s': @every is not supported", w.Start)
+	}
+	if w.Duration.Duration <= 0 {
+		return Window{}, fmt.Errorf("invalid duration '%s': must be greater than zero", w.Duration.Duration)
+	}
+	loc := time.UTC
+	if w.TimeZone != "" {
+		if loc, err = time.LoadLocation(w.TimeZone); err != nil {
+			return Window{}, fmt.Errorf("invalid time zone '%s': %w", w.TimeZone, err)
+		}
+	}
+	return Window{
+		Start:    start,
+		Duration: w.Duration.Duration,
+		Location: loc,
+		Deny:     w.GetType() == v2.ScheduleWindowDeny,
+	}, nil
+}
+
+// ParseWindowFlag parses a deny window from the given flag value, in the
+// format `<cron expression>;<duration>[;<time zone>]`.
+func ParseWindowFlag(value string) (Window, error) {
+	parts := strings.Split(value, ";")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Window{}, fmt.Errorf("invalid window '%s': expected format '<cron expression>;<duration>[;<time zone>]'", value)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window '%s': %w", value, err)
+	}
+	w := v2.ScheduleWindow{
+		Type:     v2.ScheduleWindowDeny,
+		Start:    parts[0],
+		Duration: metav1.Duration{Duration: d},
+	}
+	if len(parts) == 3 {
+		w.TimeZone = strings.TrimSpace(parts[2])
+	}
+	return NewWindow(w)
+}
+
+// ParseWindows parses a YAML list of v2.ScheduleWindow objects into deny
+// windows, regardless of their type.
+func ParseWindows(data []byte) ([]Window, error) {
+	var windows []v2.ScheduleWindow
+	if err := yaml.Unmarshal(data, &windows); err != nil {
+		return nil, fmt.Errorf("failed to parse windows: %w", err)
+	}
+	result := make([]Window, 0, len(windows))
+	for i, w := range windows {
+		w.Type = v2.ScheduleWindowDeny
+		window, err := NewWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window at index %d: %w", i, err)
+		}
+		result = append(result, window)
+	}
+	return result, nil
+}
+
+// Active returns true if the given time is inside the window.
+func (w Window) Active(t time.Time) bool {
+	start := w.Start.Next(t.In(w.Location).Add(-w.Duration))
+	return !start.IsZero() && !start.After(t)
+}
+
+// end returns the end of the earliest occurrence of the window which
+// contains the given time, or the zero time if the window is not active.
+func (w Window) end(t time.Time) time.Time {
+	start := w.Start.Next(t.In(w.Location).Add(-w.Duration))
+	if start.IsZero() || start.After(t) {
+		return time.Time{}
+	}
+	return start.Add(w.Duration)
+}
+
+// Schedule is a set of windows which determine when changes are allowed.
+type Schedule struct {
+	// Windows holds the allow and deny windows.
+	Windows []Window
+	// FreezeWindows holds the cluster-wide deny windows.
+	FreezeWindows []Window
+	// Install is true if the windows apply to Helm installs.
+	Install bool
+	// Uninstall is true if the windows apply to Helm uninstalls.
+	Uninstall bool
+}
+
+// New returns a Schedule for the given v2.Schedule, extended with the given
+// cluster-wide deny windows. It returns nil if there are no windows.
+func New(spec *v2.Schedule, freeze []Window) (*Schedule, error) {
+	s := &Schedule{}
+	if spec != nil {
+		s.Install, s.Uninstall = spec.Install, spec.Uninstall
+		for i, w := range spec.Windows {
+			window, err := NewWindow(w)
+			if err != nil {
+				return nil, fmt.Errorf("invalid window at index %d: %w", i, err)
+			}
+			s.Windows = append(s.Windows, window)
+		}
+	}
+	s.FreezeWindows = freeze
+	if len(s.Windows) == 0 && len(s.FreezeWindows) == 0 {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// Freeze returns a Schedule with only the cluster-wide deny windows of the
+// Schedule, or nil if there are none.
+func (s *Schedule) Freeze() *Schedule {
+	if s == nil || len(s.FreezeWindows) == 0 {
+		return nil
+	}
+	return &Schedule{FreezeWindows: s.FreezeWindows}
+}
+
+// windows returns all windows of the Schedule.
+func (s *Schedule) windows() []Window {
+	return append(append([]Window{}, s.Windows...), s.FreezeWindows...)
+}
+
+// Allowed returns true if changes are allowed at the given time. This is
+// the case if the time is inside an allow window, or if there are no allow
+// windows, and it is not inside a deny window. A nil Schedule allows changes
+// at any time.
+func (s *Schedule) Allowed(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	var hasAllow, inAllow bool
+	for _, w := range s.windows() {
+		if w.Deny {
+			if w.Active(t) {
+				return false
+			}
+			continue
+		}
+		hasAllow = true
+		inAllow = inAllow || w.Active(t)
+	}
+	return !hasAllow || inAllow
+}
+
+// NextAllowed returns the first time at or after the given time at which
+// changes are allowed, or the zero time if it can not be determined.
+func (s *Schedule) NextAllowed(t time.Time) time.Time {
+	for i := 0; i < maxNextAllowedSteps; i++ {
+		if s.Allowed(t) {
+			return t
+		}
+
+		// Step to the earliest window boundary after t at which the result
+		// may change: the end of an active deny window, or the start of an
+		// allow window.
+		var next time.Time
+		for _, w := range s.windows() {
+			var boundary time.Time
+			switch {
+			case w.Deny:
+				boundary = w.end(t)
+			case !w.Active(t):
+				boundary = w.Start.Next(t.In(w.Location))
+			}
+			if boundary.IsZero() || !boundary.After(t) {
+				continue
+			}
+			if next.IsZero() || boundary.Before(next) {
+				next = boundary
+			}
+		}
+		if next.IsZero() {
+			return time.Time{}
+		}
+		t = next
+	}
+	return time.Time{}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func TestSchedule_Allowed(t *testing.T) {
+	// Friday 1 March 2024.
+	friday := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	s, err := New(&v2.Schedule{
+		Windows: []v2.ScheduleWindow{
+			// Working hours on weekdays.
+			{Start: "0 8 * * 1-5", Duration: metav1.Duration{Duration: 10 * time.Hour}},
+			// Peak hours.
+			{Type: v2.ScheduleWindowDeny, Start: "0 12 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No changes on Fridays.
+	freeze, err := ParseWindowFlag("0 0 * * fri;24h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		schedule  *Schedule
+		at        time.Time
+		want      bool
+		wantNext  time.Time
+		wantNever bool
+	}{
+		{
+			name:     "nil schedule",
+			at:       friday,
+			want:     true,
+			wantNext: friday,
+		},
+		{
+			name:     "before allow window",
+			schedule: s,
+			at:       friday.Add(7 * time.Hour),
+			wantNext: friday.Add(8 * time.Hour),
+		},
+		{
+			name:     "inside allow window",
+			schedule: s,
+			at:       friday.Add(9 * time.Hour),
+			want:     true,
+			wantNext: friday.Add(9 * time.Hour),
+		},
+		{
+			name:     "inside deny window inside allow window",
+			schedule: s,
+			at:       friday.Add(13 * time.Hour),
+			wantNext: friday.Add(14 * time.Hour),
+		},
+		{
+			name:     "end of allow window",
+			schedule: s,
+			at:       friday.Add(18 * time.Hour),
+			wantNext: friday.Add(3*24*time.Hour + 8*time.Hour),
+		},
+		{
+			name:     "cluster-wide freeze",
+			schedule: &Schedule{Windows: s.Windows, FreezeWindows: []Window{freeze}},
+			at:       friday.Add(9 * time.Hour),
+			wantNext: friday.Add(3*24*time.Hour + 8*time.Hour),
+		},
+		{
+			name:      "never allowed",
+			schedule:  &Schedule{Windows: []Window{mustWindow(t, "* * * * *", time.Hour, true)}},
+			at:        friday,
+			wantNever: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(tt.schedule.Allowed(tt.at)).To(Equal(tt.want))
+			next := tt.schedule.NextAllowed(tt.at)
+			if tt.wantNever {
+				g.Expect(next.IsZero()).To(BeTrue())
+				return
+			}
+			g.Expect(next).To(BeTemporally("==", tt.wantNext))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := New(nil, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(s).To(BeNil())
+
+	_, err = New(&v2.Schedule{Windows: []v2.ScheduleWindow{
+		{Start: "0 0 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Mars/Olympus_Mons"},
+	}}, nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid time zone"))
+}
+
+func TestNewWindow(t *testing.T) {
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		start      string
+		timeZone   string
+		wantErr    string
+		wantActive time.Time
+	}{
+		{
+			name:       "lists, ranges and steps",
+			start:      "0,30 9-17/2 1-15 */3 1-5",
+			wantActive: time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:       "names",
+			start:      "0 0 * jan-mar MON,fri",
+			wantActive: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "predefined schedule",
+			start:      "@daily",
+			wantActive: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "time zone",
+			start:      "0 9 * * *",
+			timeZone:   "Europe/Amsterdam",
+			wantActive: time.Date(2024, 3, 1, 9, 0, 0, 0, amsterdam),
+		},
+		{name: "too few fields", start: "0 0 * *", wantErr: "expected exactly 5 fields"},
+		{name: "out of range", start: "60 * * * *", wantErr: "above maximum"},
+		{name: "invalid name", start: "* * * foo *", wantErr: "failed to parse int from foo"},
+		{name: "every", start: "@every 1h", wantErr: "@every is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			w, err := NewWindow(v2.ScheduleWindow{
+				Start:    tt.start,
+				Duration: metav1.Duration{Duration: time.Minute},
+				TimeZone: tt.timeZone,
+			})
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(w.Active(tt.wantActive)).To(BeTrue())
+			g.Expect(w.Active(tt.wantActive.Add(-time.Minute))).To(BeFalse())
+		})
+	}
+}
+
+func TestSchedule_Freeze(t *testing.T) {
+	g := NewWithT(t)
+
+	freeze, err := ParseWindowFlag("0 0 * * fri;24h")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	s, err := New(nil, []Window{freeze})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(s.Windows).To(BeEmpty())
+	g.Expect(s.Freeze()).To(Equal(&Schedule{FreezeWindows: []Window{freeze}}))
+
+	s, err = New(&v2.Schedule{Install: true, Windows: []v2.ScheduleWindow{
+		{Start: "0 8 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+	}}, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(s.Freeze()).To(BeNil())
+}
+
+func TestParseWindows(t *testing.T) {
+	g := NewWithT(t)
+
+	windows, err := ParseWindows([]byte(`
+- start: "0 0 * * fri"
+  duration: 24h
+  timeZone: Europe/Amsterdam
+- type: allow
+  start: "0 9 * * *"
+  duration: 8h
+`))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(windows).To(HaveLen(2))
+	for _, w := range windows {
+		g.Expect(w.Deny).To(BeTrue())
+	}
+
+	_, err = ParseWindows([]byte(`- start: "0 0 * *"`))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func mustWindow(t *testing.T, expr string, d time.Duration, deny bool) Window {
+	t.Helper()
+	c, err := cron.ParseStandard(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Window{Start: c, Duration: d, Location: time.UTC, Deny: deny}
+}
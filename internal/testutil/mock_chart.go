@@ -82,6 +82,15 @@ spec:
   restartPolicy: Never
 `
 
+var manifestWithInvalidNameTmpl = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: Invalid_Name
+  namespace: %[1]s
+data:
+  foo: bar
+`
+
 // ChartOptions is a helper to build a Helm chart object.
 type ChartOptions struct {
 	*helmchart.Chart
@@ -166,3 +175,14 @@ func ChartWithFailingTestHook() ChartOption {
 		})
 	}
 }
+
+// ChartWithInvalidManifest appends a manifest to the chart which renders,
+// but is rejected by the Kubernetes API server.
+func ChartWithInvalidManifest() ChartOption {
+	return func(opts *ChartOptions) {
+		opts.Templates = append(opts.Templates, &helmchart.File{
+			Name: "templates/invalid-manifest",
+			Data: []byte(fmt.Sprintf(manifestWithInvalidNameTmpl, "{{ default .Release.Namespace }}")),
+		})
+	}
+}
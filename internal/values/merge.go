@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/fluxcd/pkg/chartutil"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+// Merge merges the overlay into the base values using the given strategy,
+// and returns the result. The base and overlay are not modified.
+func Merge(base, overlay map[string]interface{}, strategy string) (map[string]interface{}, error) {
+	switch {
+	case strategy == "" || strategy == v2.ValuesMergeStrategyDeepMerge:
+		return chartutil.MergeMaps(base, overlay), nil
+	case strategy == v2.ValuesMergeStrategyReplace:
+		out := copyMap(base)
+		for k, v := range overlay {
+			out[k] = v
+		}
+		return out, nil
+	case strategy == v2.ValuesMergeStrategyAppendLists:
+		return mergeMaps(base, overlay, appendLists), nil
+	case strings.HasPrefix(strategy, v2.ValuesMergeStrategyMergeListsByKeyPrefix):
+		key := strings.TrimPrefix(strategy, v2.ValuesMergeStrategyMergeListsByKeyPrefix)
+		if key == "" {
+			return nil, fmt.Errorf("merge strategy '%s' requires a key", strategy)
+		}
+		return mergeMaps(base, overlay, mergeListsByKey(key)), nil
+	case strategy == v2.ValuesMergeStrategyJSONMergePatch:
+		return mergePatch(base, overlay), nil
+	default:
+		return nil, fmt.Errorf("unsupported merge strategy '%s'", strategy)
+	}
+}
+
+// Digest returns the digest of the merge strategies of the given values
+// references, using the given algorithm. It returns an empty digest if no
+// merge strategy is declared on any of the references.
+func Digest(algo digest.Algorithm, refs []v2.ValuesReference) digest.Digest {
+	var declared bool
+	strategies := make([]string, len(refs))
+	for i, ref := range refs {
+		strategies[i] = ref.MergeStrategy
+		declared = declared || ref.MergeStrategy != ""
+	}
+	if !declared {
+		return ""
+	}
+
+	digester := algo.Digester()
+	enc := json.NewEncoder(digester.Hash())
+	if err := enc.Encode(strategies); err != nil {
+		return ""
+	}
+	return digester.Digest()
+}
+
+// listMergeFunc merges the overlay list into the base list.
+type listMergeFunc func(base, overlay []interface{}) []interface{}
+
+// mergeMaps merges the overlay into the base map recursively. Lists present
+// in both maps are merged with mergeLists, any other value of the overlay
+// replaces the value of the base.
+func mergeMaps(base, overlay map[string]interface{}, mergeLists listMergeFunc) map[string]interface{} {
+	out := copyMap(base)
+	for k, v := range overlay {
+		switch ov := v.(type) {
+		case map[string]interface{}:
+			if bv, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeMaps(bv, ov, mergeLists)
+				continue
+			}
+		case []interface{}:
+			if bv, ok := out[k].([]interface{}); ok {
+				out[k] = mergeLists(bv, ov)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// appendLists appends the items of the overlay to the base.
+func appendLists(base, overlay []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(base)+len(overlay))
+	out = append(out, base...)
+	return append(out, overlay...)
+}
+
+// mergeListsByKey returns a listMergeFunc which merges the items of lists
+// of maps with an equal value for the given key, and appends items of the
+// overlay without a match. When any item of either list is not a map
+// containing the key, the overlay replaces the base.
+func mergeListsByKey(key string) listMergeFunc {
+	var merge listMergeFunc
+	merge = func(base, overlay []interface{}) []interface{} {
+		if !allMapsWithKey(base, key) || !allMapsWithKey(overlay, key) {
+			return overlay
+		}
+
+		out := make([]interface{}, len(base), len(base)+len(overlay))
+		copy(out, base)
+		for _, item := range overlay {
+			om := item.(map[string]interface{})
+			matched := false
+			for i := range out {
+				bm := out[i].(map[string]interface{})
+				if fmt.Sprint(bm[key]) == fmt.Sprint(om[key]) {
+					out[i] = mergeMaps(bm, om, merge)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				out = append(out, om)
+			}
+		}
+		return out
+	}
+	return merge
+}
+
+// allMapsWithKey returns true if all items of the list are maps which
+// contain the given key.
+func allMapsWithKey(list []interface{}, key string) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok = m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// mergePatch applies the patch to the target according to RFC 7386.
+func mergePatch(target, patch map[string]interface{}) map[string]interface{} {
+	out := copyMap(target)
+	for k, v := range patch {
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		if pv, ok := v.(map[string]interface{}); ok {
+			tv, _ := out[k].(map[string]interface{})
+			out[k] = mergePatch(tv, pv)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// copyMap returns a shallow copy of the given map.
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
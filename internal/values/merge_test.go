@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+)
+
+func TestMerge(t *testing.T) {
+	const base = `
+image:
+  repository: app
+  tag: "1.0"
+extraEnv:
+- name: LOG_LEVEL
+  value: info
+- name: REGION
+  value: eu-west-1
+tolerations:
+- key: dedicated
+  operator: Exists
+`
+
+	tests := []struct {
+		name     string
+		strategy string
+		overlay  string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "deep merge replaces lists",
+			strategy: v2.ValuesMergeStrategyDeepMerge,
+			overlay: `
+image:
+  tag: "2.0"
+extraEnv:
+- name: LOG_LEVEL
+  value: debug
+`,
+			want: `
+image:
+  repository: app
+  tag: "2.0"
+extraEnv:
+- name: LOG_LEVEL
+  value: debug
+tolerations:
+- key: dedicated
+  operator: Exists
+`,
+		},
+		{
+			name:     "replace replaces top-level keys",
+			strategy: v2.ValuesMergeStrategyReplace,
+			overlay: `
+image:
+  tag: "2.0"
+`,
+			want: `
+image:
+  tag: "2.0"
+extraEnv:
+- name: LOG_LEVEL
+  value: info
+- name: REGION
+  value: eu-west-1
+tolerations:
+- key: dedicated
+  operator: Exists
+`,
+		},
+		{
+			name:     "append lists",
+			strategy: v2.ValuesMergeStrategyAppendLists,
+			overlay: `
+image:
+  tag: "2.0"
+tolerations:
+- key: gpu
+  operator: Exists
+`,
+			want: `
+image:
+  repository: app
+  tag: "2.0"
+extraEnv:
+- name: LOG_LEVEL
+  value: info
+- name: REGION
+  value: eu-west-1
+tolerations:
+- key: dedicated
+  operator: Exists
+- key: gpu
+  operator: Exists
+`,
+		},
+		{
+			name:     "merge lists by key",
+			strategy: v2.ValuesMergeStrategyMergeListsByKeyPrefix + "name",
+			overlay: `
+extraEnv:
+- name: LOG_LEVEL
+  value: debug
+- name: CLUSTER
+  value: prod
+tolerations:
+- key: gpu
+  operator: Exists
+`,
+			want: `
+image:
+  repository: app
+  tag: "1.0"
+extraEnv:
+- name: LOG_LEVEL
+  value: debug
+- name: REGION
+  value: eu-west-1
+- name: CLUSTER
+  value: prod
+tolerations:
+- key: gpu
+  operator: Exists
+`,
+		},
+		{
+			name:     "JSON merge patch",
+			strategy: v2.ValuesMergeStrategyJSONMergePatch,
+			overlay: `
+image:
+  tag: null
+  pullPolicy: Always
+tolerations: null
+podLabels:
+  team: platform
+  removed: null
+`,
+			want: `
+image:
+  repository: app
+  pullPolicy: Always
+extraEnv:
+- name: LOG_LEVEL
+  value: info
+- name: REGION
+  value: eu-west-1
+podLabels:
+  team: platform
+`,
+		},
+		{
+			name:     "merge lists by key without key",
+			strategy: v2.ValuesMergeStrategyMergeListsByKeyPrefix,
+			wantErr:  "requires a key",
+		},
+		{
+			name:     "unsupported strategy",
+			strategy: "unknown",
+			wantErr:  "unsupported merge strategy 'unknown'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			baseValues, err := helmchartutil.ReadValues([]byte(base))
+			g.Expect(err).ToNot(HaveOccurred())
+			overlayValues, err := helmchartutil.ReadValues([]byte(tt.overlay))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			got, err := Merge(baseValues, overlayValues, tt.strategy)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			want, err := helmchartutil.ReadValues([]byte(tt.want))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(map[string]interface{}(want)))
+
+			// The base values must not be modified.
+			g.Expect(baseValues).To(Equal(mustReadValues(g, base)))
+		})
+	}
+}
+
+func TestDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	refs := []v2.ValuesReference{
+		{Kind: "ConfigMap", Name: "base"},
+		{Kind: "Secret", Name: "overlay"},
+	}
+	g.Expect(Digest(digest.Canonical, refs)).To(BeEmpty())
+
+	refs[1].MergeStrategy = v2.ValuesMergeStrategyAppendLists
+	appendDigest := Digest(digest.Canonical, refs)
+	g.Expect(appendDigest).ToNot(BeEmpty())
+
+	refs[1].MergeStrategy = v2.ValuesMergeStrategyJSONMergePatch
+	g.Expect(Digest(digest.Canonical, refs)).ToNot(Equal(appendDigest))
+}
+
+func mustReadValues(g *WithT, s string) helmchartutil.Values {
+	v, err := helmchartutil.ReadValues([]byte(s))
+	g.Expect(err).ToNot(HaveOccurred())
+	return v
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/fluxcd/pkg/chartutil"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/loader"
+)
+
+const (
+	kindConfigMap     = "ConfigMap"
+	kindSecret        = "Secret"
+	kindGitRepository = "GitRepository"
+	kindBucket        = "Bucket"
+	kindOCIRepository = "OCIRepository"
+)
+
+// ErrArtifactNotAvailable signals the referenced source does not have an
+// artifact (yet).
+var ErrArtifactNotAvailable = errors.New("source artifact not available")
+
+// ArtifactFetcher returns the contents of the file at the given path in
+// the given source artifact. It returns an error wrapping
+// loader.ErrFileNotFound if the artifact or the file does not exist.
+type ArtifactFetcher func(ctx context.Context, artifact *sourcev1.Artifact, path string) ([]byte, error)
+
+// ChartValuesFromReferences attempts to construct new chart values by
+// resolving the provided references using the client, merging them in the
+// order given using the merge strategy of each reference. The files
+// referenced in source artifacts are retrieved using the fetcher. If
+// provided, the values map is merged in last overwriting values from
+// references, unless a reference has a targetPath specified, in which case
+// it will overwrite all. It returns the merged values, or a
+// chartutil.ErrValuesReference error.
+func ChartValuesFromReferences(ctx context.Context, log logr.Logger, c client.Client, fetch ArtifactFetcher,
+	namespace string, values map[string]interface{}, refs ...v2.ValuesReference) (helmchartutil.Values, error) {
+
+	result := helmchartutil.Values{}
+	resources := make(map[string]client.Object)
+
+	for _, ref := range refs {
+		namespacedName := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+
+		// The resource may not exist, but we want to act on a single version
+		// of the resource in case the values reference is marked as optional.
+		index := ref.Kind + namespacedName.String()
+		resource, ok := resources[index]
+		if !ok {
+			resources[index] = nil
+
+			var err error
+			if resource, err = newObjectForKind(ref.Kind); err != nil {
+				return nil, newErrValuesReference(namespacedName, ref, chartutil.ErrUnsupportedRefKind, nil)
+			}
+			if err = c.Get(ctx, namespacedName, resource); err != nil {
+				if apierrors.IsNotFound(err) {
+					err := newErrValuesReference(namespacedName, ref, chartutil.ErrResourceNotFound, err)
+					if err.Optional {
+						log.Info(err.Error())
+						continue
+					}
+					return nil, err
+				}
+				return nil, err
+			}
+			resources[index] = resource
+		}
+
+		if resource == nil {
+			if ref.Optional {
+				continue
+			}
+			return nil, newErrValuesReference(namespacedName, ref, chartutil.ErrResourceNotFound, nil)
+		}
+
+		var valuesData []byte
+		switch typedRes := resource.(type) {
+		case *corev1.Secret:
+			data, ok := typedRes.Data[ref.GetValuesKey()]
+			if !ok {
+				err := newErrValuesReference(namespacedName, ref, chartutil.ErrKeyNotFound, nil)
+				if ref.Optional {
+					log.Info(err.Error())
+					continue
+				}
+				return nil, err
+			}
+			valuesData = data
+		case *corev1.ConfigMap:
+			data, ok := typedRes.Data[ref.GetValuesKey()]
+			if !ok {
+				err := newErrValuesReference(namespacedName, ref, chartutil.ErrKeyNotFound, nil)
+				if ref.Optional {
+					log.Info(err.Error())
+					continue
+				}
+				return nil, err
+			}
+			valuesData = []byte(data)
+		case sourcev1.Source:
+			artifact := typedRes.GetArtifact()
+			if artifact == nil {
+				return nil, newErrValuesReference(namespacedName, ref, chartutil.ErrValuesDataRead, ErrArtifactNotAvailable)
+			}
+			data, err := fetch(ctx, artifact, ref.GetPath())
+			if err != nil {
+				if errors.Is(err, loader.ErrFileNotFound) {
+					err := newErrValuesReference(namespacedName, ref, chartutil.ErrKeyNotFound, err)
+					if ref.Optional {
+						log.Info(err.Error())
+						continue
+					}
+					return nil, err
+				}
+				return nil, newErrValuesReference(namespacedName, ref, chartutil.ErrValuesDataRead, err)
+			}
+			valuesData = data
+		default:
+			return nil, newErrValuesReference(namespacedName, ref, chartutil.ErrUnsupportedRefKind, nil)
+		}
+
+		if ref.TargetPath != "" {
+			result = chartutil.MergeMaps(result, values)
+
+			if err := chartutil.ReplacePathValue(result, ref.TargetPath, string(valuesData)); err != nil {
+				return nil, newErrValuesReference(namespacedName, ref, chartutil.ErrValueMerge, err)
+			}
+			continue
+		}
+
+		values, err := helmchartutil.ReadValues(valuesData)
+		if err != nil {
+			return nil, newErrValuesReference(namespacedName, ref, chartutil.ErrValuesDataRead, err)
+		}
+		if result, err = Merge(result, values, ref.GetMergeStrategy()); err != nil {
+			return nil, newErrValuesReference(namespacedName, ref, chartutil.ErrValueMerge, err)
+		}
+	}
+	return chartutil.MergeMaps(result, values), nil
+}
+
+// newObjectForKind returns a new empty object for the given values
+// reference kind.
+func newObjectForKind(kind string) (client.Object, error) {
+	switch kind {
+	case kindConfigMap:
+		return &corev1.ConfigMap{}, nil
+	case kindSecret:
+		return &corev1.Secret{}, nil
+	case kindGitRepository:
+		return &sourcev1.GitRepository{}, nil
+	case kindBucket:
+		return &sourcev1.Bucket{}, nil
+	case kindOCIRepository:
+		return &sourcev1beta2.OCIRepository{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported values reference kind '%s'", kind)
+	}
+}
+
+// newErrValuesReference returns a new chartutil.ErrValuesReference
+// constructed from the provided values.
+func newErrValuesReference(name types.NamespacedName, ref v2.ValuesReference, reason chartutil.ErrValuesRefReason, err error) *chartutil.ErrValuesReference {
+	key := ref.GetValuesKey()
+	if ref.IsSource() {
+		key = ref.GetPath()
+	}
+	return &chartutil.ErrValuesReference{
+		Reason:   reason,
+		Kind:     ref.Kind,
+		Name:     name,
+		Key:      key,
+		Optional: ref.Optional,
+		Err:      err,
+	}
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package values
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/chartutil"
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	"github.com/fluxcd/helm-controller/internal/loader"
+)
+
+func TestChartValuesFromReferences(t *testing.T) {
+	scheme := testScheme()
+
+	artifactFiles := map[string]string{
+		"http://source/git.tar.gz|values.yaml": `flat: git
+fromGit: true
+`,
+		"http://source/git.tar.gz|envs/prod/values.yaml": `flat: prod
+`,
+		"http://source/oci.tar.gz|single": `oci`,
+	}
+	fetch := func(_ context.Context, artifact *sourcev1.Artifact, path string) ([]byte, error) {
+		if artifact.URL == "http://source/error.tar.gz" {
+			return nil, errors.New("connection refused")
+		}
+		data, ok := artifactFiles[artifact.URL+"|"+path]
+		if !ok {
+			return nil, fmt.Errorf("failed to read file '%s' from artifact: %w", path, loader.ErrFileNotFound)
+		}
+		return []byte(data), nil
+	}
+
+	tests := []struct {
+		name       string
+		resources  []runtime.Object
+		references []v2.ValuesReference
+		values     string
+		want       helmchartutil.Values
+		wantErr    error
+	}{
+		{
+			name: "merges",
+			resources: []runtime.Object{
+				mockConfigMap("values", map[string]string{
+					"values.yaml": `flat: value
+nested:
+  configuration: value
+`,
+				}),
+				mockSecret("values", map[string][]byte{
+					"values.yaml": []byte(`flat:
+  nested: value
+nested: value
+`),
+				}),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindConfigMap, Name: "values"},
+				{Kind: kindSecret, Name: "values"},
+			},
+			values: `
+other: values
+`,
+			want: helmchartutil.Values{
+				"flat": map[string]interface{}{
+					"nested": "value",
+				},
+				"nested": "value",
+				"other":  "values",
+			},
+		},
+		{
+			name: "merges with strategy",
+			resources: []runtime.Object{
+				mockConfigMap("base", map[string]string{
+					"values.yaml": `tolerations:
+- key: dedicated
+`,
+				}),
+				mockSecret("overlay", map[string][]byte{
+					"values.yaml": []byte(`tolerations:
+- key: gpu
+`),
+				}),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindConfigMap, Name: "base"},
+				{Kind: kindSecret, Name: "overlay", MergeStrategy: v2.ValuesMergeStrategyAppendLists},
+			},
+			want: helmchartutil.Values{
+				"tolerations": []interface{}{
+					map[string]interface{}{"key": "dedicated"},
+					map[string]interface{}{"key": "gpu"},
+				},
+			},
+		},
+		{
+			name: "merges source artifact files in order",
+			resources: []runtime.Object{
+				mockConfigMap("values", map[string]string{
+					"values.yaml": `flat: value
+`,
+				}),
+				mockGitRepository("git", "http://source/git.tar.gz"),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindGitRepository, Name: "git"},
+				{Kind: kindConfigMap, Name: "values"},
+				{Kind: kindGitRepository, Name: "git", Path: "envs/prod/values.yaml"},
+			},
+			want: helmchartutil.Values{
+				"flat":    "prod",
+				"fromGit": true,
+			},
+		},
+		{
+			name: "source artifact file with target path",
+			resources: []runtime.Object{
+				mockOCIRepository("oci", "http://source/oci.tar.gz"),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindOCIRepository, Name: "oci", Path: "single", TargetPath: "from.oci"},
+			},
+			want: helmchartutil.Values{
+				"from": map[string]interface{}{
+					"oci": "oci",
+				},
+			},
+		},
+		{
+			name: "with target path",
+			resources: []runtime.Object{
+				mockSecret("values", map[string][]byte{"single": []byte("value")}),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindSecret, Name: "values", ValuesKey: "single", TargetPath: "merge.at.specific.path"},
+			},
+			want: helmchartutil.Values{
+				"merge": map[string]interface{}{
+					"at": map[string]interface{}{
+						"specific": map[string]interface{}{
+							"path": "value",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "values reference to non existing secret",
+			references: []v2.ValuesReference{
+				{Kind: kindSecret, Name: "missing"},
+			},
+			wantErr: chartutil.ErrResourceNotFound,
+		},
+		{
+			name: "optional values reference to non existing config map",
+			references: []v2.ValuesReference{
+				{Kind: kindConfigMap, Name: "missing", Optional: true},
+			},
+			want: helmchartutil.Values{},
+		},
+		{
+			name: "values reference to non existing source",
+			references: []v2.ValuesReference{
+				{Kind: kindBucket, Name: "missing"},
+			},
+			wantErr: chartutil.ErrResourceNotFound,
+		},
+		{
+			name: "optional values reference to non existing source",
+			references: []v2.ValuesReference{
+				{Kind: kindBucket, Name: "missing", Optional: true},
+			},
+			want: helmchartutil.Values{},
+		},
+		{
+			name: "missing config map key",
+			resources: []runtime.Object{
+				mockConfigMap("values", nil),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindConfigMap, Name: "values", ValuesKey: "nonexisting"},
+			},
+			wantErr: chartutil.ErrKeyNotFound,
+		},
+		{
+			name: "missing source artifact file",
+			resources: []runtime.Object{
+				mockGitRepository("git", "http://source/git.tar.gz"),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindGitRepository, Name: "git", Path: "nonexisting.yaml"},
+			},
+			wantErr: chartutil.ErrKeyNotFound,
+		},
+		{
+			name: "optional missing source artifact file",
+			resources: []runtime.Object{
+				mockGitRepository("git", "http://source/git.tar.gz"),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindGitRepository, Name: "git", Path: "nonexisting.yaml", Optional: true},
+			},
+			want: helmchartutil.Values{},
+		},
+		{
+			name: "source without artifact",
+			resources: []runtime.Object{
+				mockGitRepository("git", ""),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindGitRepository, Name: "git", Optional: true},
+			},
+			wantErr: ErrArtifactNotAvailable,
+		},
+		{
+			name: "source artifact fetch error",
+			resources: []runtime.Object{
+				mockGitRepository("git", "http://source/error.tar.gz"),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindGitRepository, Name: "git"},
+			},
+			wantErr: chartutil.ErrValuesDataRead,
+		},
+		{
+			name: "unsupported values reference kind",
+			references: []v2.ValuesReference{
+				{Kind: "Unsupported"},
+			},
+			wantErr: chartutil.ErrUnsupportedRefKind,
+		},
+		{
+			name: "invalid values",
+			resources: []runtime.Object{
+				mockConfigMap("values", map[string]string{
+					"values.yaml": `
+invalid`,
+				}),
+			},
+			references: []v2.ValuesReference{
+				{Kind: kindConfigMap, Name: "values"},
+			},
+			wantErr: chartutil.ErrValuesDataRead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(tt.resources...)
+			var values map[string]interface{}
+			if tt.values != "" {
+				m, err := helmchartutil.ReadValues([]byte(tt.values))
+				g.Expect(err).ToNot(HaveOccurred())
+				values = m
+			}
+			got, err := ChartValuesFromReferences(context.TODO(), logr.Discard(), c.Build(), fetch, "", values, tt.references...)
+			if tt.wantErr != nil {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(errors.Is(err, tt.wantErr)).To(BeTrue(), err.Error())
+				g.Expect(got).To(BeNil())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func mockSecret(name string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Data:       data,
+	}
+}
+
+func mockConfigMap(name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Data:       data,
+	}
+}
+
+func mockGitRepository(name, url string) *sourcev1.GitRepository {
+	obj := &sourcev1.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	if url != "" {
+		obj.Status.Artifact = &sourcev1.Artifact{URL: url, Revision: "main@sha1:abc"}
+	}
+	return obj
+}
+
+func mockOCIRepository(name, url string) *sourcev1beta2.OCIRepository {
+	return &sourcev1beta2.OCIRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: sourcev1beta2.OCIRepositoryStatus{
+			Artifact: &sourcev1.Artifact{URL: url, Revision: "latest@sha256:abc"},
+		},
+	}
+}
+
+func testScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = sourcev1.AddToScheme(scheme)
+	_ = sourcev1beta2.AddToScheme(scheme)
+	return scheme
+}
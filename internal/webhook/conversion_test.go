@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	v2beta2 "github.com/fluxcd/helm-controller/api/v2beta2"
+)
+
+func TestHelmRelease_conversion(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.TODO()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "conversion-"}}
+	g.Expect(testClient.Create(ctx, ns)).To(Succeed())
+	t.Cleanup(func() { _ = testClient.Delete(ctx, ns) })
+
+	beta := &v2beta2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: ns.Name},
+		Spec: v2beta2.HelmReleaseSpec{
+			Interval: metav1.Duration{Duration: time.Minute},
+			Chart: &v2beta2.HelmChartTemplate{
+				Spec: v2beta2.HelmChartTemplateSpec{
+					Chart:       "podinfo",
+					ValuesFile:  "values-prod.yaml",
+					ValuesFiles: []string{"values-eu.yaml"},
+					SourceRef: v2beta2.CrossNamespaceObjectReference{
+						Kind: sourcev1.HelmRepositoryKind,
+						Name: "podinfo",
+					},
+				},
+			},
+		},
+	}
+	g.Expect(testClient.Create(ctx, beta)).To(Succeed())
+
+	obj := &v2.HelmRelease{}
+	g.Expect(testClient.Get(ctx, client.ObjectKeyFromObject(beta), obj)).To(Succeed())
+	g.Expect(obj.Spec.Chart.Spec.ValuesFiles).To(Equal([]string{"values-prod.yaml", "values-eu.yaml"}))
+
+	got := &v2beta2.HelmRelease{}
+	g.Expect(testClient.Get(ctx, client.ObjectKeyFromObject(beta), got)).To(Succeed())
+	g.Expect(got.Spec.Chart.Spec.ValuesFile).To(Equal("values-prod.yaml"))
+	g.Expect(got.Spec.Chart.Spec.ValuesFiles).To(Equal([]string{"values-eu.yaml"}))
+	g.Expect(got.GetAnnotations()).To(BeEmpty())
+
+	obj.Spec.Chart.Spec.ValuesFiles = []string{"values.yaml"}
+	g.Expect(testClient.Update(ctx, obj)).To(Succeed())
+
+	g.Expect(testClient.Get(ctx, client.ObjectKeyFromObject(beta), got)).To(Succeed())
+	g.Expect(got.Spec.Chart.Spec.ValuesFile).To(BeEmpty())
+	g.Expect(got.Spec.Chart.Spec.ValuesFiles).To(Equal([]string{"values.yaml"}))
+}
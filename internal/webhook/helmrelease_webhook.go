@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	intacl "github.com/fluxcd/helm-controller/internal/acl"
+	"github.com/fluxcd/helm-controller/internal/action"
+	intcel "github.com/fluxcd/helm-controller/internal/cel"
+	"github.com/fluxcd/helm-controller/internal/schedule"
+)
+
+// +kubebuilder:webhook:path=/validate-helm-toolkit-fluxcd-io-v2-helmrelease,mutating=false,failurePolicy=fail,sideEffects=None,groups=helm.toolkit.fluxcd.io,resources=helmreleases,verbs=create;update,versions=v2,name=vhelmrelease.helm.toolkit.fluxcd.io,admissionReviewVersions=v1
+
+// HelmReleaseValidator validates v2.HelmRelease objects on admission, using
+// the checks the controller would otherwise only run during reconciliation.
+type HelmReleaseValidator struct{}
+
+var _ admission.CustomValidator = &HelmReleaseValidator{}
+
+// SetupWithManager registers the validating webhook with the webhook server
+// of the given manager. When the v2beta1 and v2beta2 HelmRelease APIs are
+// registered with the scheme of the manager, the CRD conversion webhook is
+// registered as well.
+func (v *HelmReleaseValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&v2.HelmRelease{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate validates the v2.HelmRelease on creation.
+func (v *HelmReleaseValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	hr, ok := obj.(*v2.HelmRelease)
+	if !ok {
+		return nil, fmt.Errorf("expected a HelmRelease, got %T", obj)
+	}
+	return nil, toError(hr, ValidateHelmRelease(hr))
+}
+
+// ValidateUpdate validates the v2.HelmRelease on update. Updates which do
+// not change the spec, or which are made while the object is being deleted,
+// are always allowed, so the controller can manage the finalizer of objects
+// which were admitted before the webhook was enabled.
+func (v *HelmReleaseValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldHR, ok := oldObj.(*v2.HelmRelease)
+	if !ok {
+		return nil, fmt.Errorf("expected a HelmRelease, got %T", oldObj)
+	}
+	hr, ok := newObj.(*v2.HelmRelease)
+	if !ok {
+		return nil, fmt.Errorf("expected a HelmRelease, got %T", newObj)
+	}
+	if !hr.DeletionTimestamp.IsZero() || apiequality.Semantic.DeepEqual(oldHR.Spec, hr.Spec) {
+		return nil, nil
+	}
+	return nil, toError(hr, ValidateHelmRelease(hr))
+}
+
+// ValidateDelete allows the deletion of any v2.HelmRelease.
+func (v *HelmReleaseValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateHelmRelease validates the spec of the given v2.HelmRelease, and
+// returns the errors found.
+func ValidateHelmRelease(obj *v2.HelmRelease) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	switch {
+	case obj.HasChartTemplate() && obj.HasChartRef():
+		errs = append(errs, field.Forbidden(specPath.Child("chartRef"), "chartRef may not be set when chart is set"))
+	case !obj.HasChartTemplate() && !obj.HasChartRef():
+		errs = append(errs, field.Required(specPath.Child("chart"), "one of chart or chartRef must be set"))
+	}
+
+	if obj.Spec.ReleaseName != "" {
+		if err := chartutil.ValidateReleaseName(obj.Spec.ReleaseName); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("releaseName"), obj.Spec.ReleaseName, err.Error()))
+		}
+	}
+
+	if install := obj.Spec.Install; install != nil {
+		if err := action.ValidateCRDsPolicy(install.CRDs); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("install", "crds"), install.CRDs, err.Error()))
+		}
+	}
+	if upgrade := obj.Spec.Upgrade; upgrade != nil {
+		if err := action.ValidateCRDsPolicy(upgrade.CRDs); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("upgrade", "crds"), upgrade.CRDs, err.Error()))
+		}
+	}
+
+	if drift := obj.Spec.DriftDetection; drift != nil {
+		for i, rule := range drift.Ignore {
+			for j, p := range rule.Paths {
+				if err := validateJSONPointer(p); err != nil {
+					errs = append(errs, field.Invalid(specPath.Child("driftDetection", "ignore").Index(i).Child("paths").Index(j), p, err.Error()))
+				}
+			}
+		}
+	}
+
+	if obj.Spec.Schedule != nil {
+		if _, err := schedule.New(obj.Spec.Schedule, nil); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("schedule"), obj.Spec.Schedule, err.Error()))
+		}
+	}
+
+	for i, d := range obj.Spec.DependsOn {
+		if d.ReadyExpr == "" {
+			continue
+		}
+		if _, err := intcel.NewExpression(d.ReadyExpr, "self", "dep"); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("dependsOn").Index(i).Child("readyExpr"), d.ReadyExpr, err.Error()))
+		}
+	}
+
+	for i, expr := range obj.Spec.HealthCheckExprs {
+		if err := action.ValidateHealthCheckExpr(expr); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("healthCheckExprs").Index(i), expr, err.Error()))
+		}
+	}
+
+	return append(errs, validateAccess(obj)...)
+}
+
+// validateAccess validates the cross-namespace references of the given
+// v2.HelmRelease against the access control of the controller.
+func validateAccess(obj *v2.HelmRelease) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if ref := obj.Spec.ChartRef; ref != nil {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		if err := intacl.AllowsAccessTo(obj, ref.Kind, types.NamespacedName{Namespace: namespace, Name: ref.Name}); err != nil {
+			errs = append(errs, field.Forbidden(specPath.Child("chartRef", "namespace"), err.Error()))
+		}
+	}
+
+	if obj.HasChartTemplate() {
+		namespace := obj.Spec.Chart.Spec.SourceRef.Namespace
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		ref := types.NamespacedName{Namespace: namespace, Name: obj.GetHelmChartName()}
+		if err := intacl.AllowsAccessTo(obj, sourcev1.HelmChartKind, ref); err != nil {
+			errs = append(errs, field.Forbidden(specPath.Child("chart", "spec", "sourceRef", "namespace"), err.Error()))
+		}
+	}
+
+	for i, d := range obj.Spec.DependsOn {
+		if d.Namespace == "" {
+			continue
+		}
+		ref := types.NamespacedName{Namespace: d.Namespace, Name: d.Name}
+		if err := intacl.AllowsAccessTo(obj, d.GetKind(), ref); err != nil {
+			errs = append(errs, field.Forbidden(specPath.Child("dependsOn").Index(i).Child("namespace"), err.Error()))
+		}
+	}
+
+	return errs
+}
+
+// validateJSONPointer returns an error if the given path is not a valid
+// JSON pointer as defined in RFC 6901.
+func validateJSONPointer(p string) error {
+	if p == "" {
+		return nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return fmt.Errorf("JSON pointer must be empty or start with '/'")
+	}
+	for i := 0; i < len(p); i++ {
+		if p[i] != '~' {
+			continue
+		}
+		if i+1 >= len(p) || (p[i+1] != '0' && p[i+1] != '1') {
+			return fmt.Errorf("JSON pointer contains an invalid escape sequence at position %d, '~' must be followed by '0' or '1'", i)
+		}
+	}
+	return nil
+}
+
+// toError returns an Invalid API error for the given v2.HelmRelease and
+// errors, or nil if there are no errors.
+func toError(obj *v2.HelmRelease, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(v2.GroupVersion.WithKind(v2.HelmReleaseKind).GroupKind(), obj.GetName(), errs)
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	intacl "github.com/fluxcd/helm-controller/internal/acl"
+)
+
+func TestValidateHelmRelease(t *testing.T) {
+	chartRef := &v2.CrossNamespaceSourceReference{
+		Kind: sourcev1beta2.OCIRepositoryKind,
+		Name: "podinfo",
+	}
+	chart := &v2.HelmChartTemplate{
+		Spec: v2.HelmChartTemplateSpec{
+			Chart: "podinfo",
+			SourceRef: v2.CrossNamespaceObjectReference{
+				Kind: sourcev1.HelmRepositoryKind,
+				Name: "podinfo",
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		allowCross bool
+		spec       v2.HelmReleaseSpec
+		wantFields []string
+	}{
+		{
+			name: "valid spec",
+			spec: v2.HelmReleaseSpec{
+				ChartRef:    chartRef,
+				ReleaseName: "podinfo",
+				Install:     &v2.Install{CRDs: v2.CreateReplace},
+				Upgrade:     &v2.Upgrade{CRDs: v2.Skip},
+				DriftDetection: &v2.DriftDetection{
+					Ignore: []v2.IgnoreRule{{Paths: []string{"/spec/replicas", "/metadata/annotations/a~1b"}}},
+				},
+			},
+		},
+		{
+			name: "chart and chartRef",
+			spec: v2.HelmReleaseSpec{
+				Chart:    chart,
+				ChartRef: chartRef,
+			},
+			wantFields: []string{"spec.chartRef"},
+		},
+		{
+			name:       "neither chart nor chartRef",
+			spec:       v2.HelmReleaseSpec{},
+			wantFields: []string{"spec.chart"},
+		},
+		{
+			name: "invalid release name",
+			spec: v2.HelmReleaseSpec{
+				ChartRef:    chartRef,
+				ReleaseName: "Invalid_Name",
+			},
+			wantFields: []string{"spec.releaseName"},
+		},
+		{
+			name: "unknown CRDs policy",
+			spec: v2.HelmReleaseSpec{
+				ChartRef: chartRef,
+				Install:  &v2.Install{CRDs: "Replace"},
+				Upgrade:  &v2.Upgrade{CRDs: "Delete"},
+			},
+			wantFields: []string{"spec.install.crds", "spec.upgrade.crds"},
+		},
+		{
+			name: "invalid ignore paths",
+			spec: v2.HelmReleaseSpec{
+				ChartRef: chartRef,
+				DriftDetection: &v2.DriftDetection{
+					Ignore: []v2.IgnoreRule{{Paths: []string{"spec/replicas", "/metadata/annotations/a~2b"}}},
+				},
+			},
+			wantFields: []string{"spec.driftDetection.ignore[0].paths[0]", "spec.driftDetection.ignore[0].paths[1]"},
+		},
+		{
+			name: "invalid schedule",
+			spec: v2.HelmReleaseSpec{
+				ChartRef: chartRef,
+				Schedule: &v2.Schedule{Windows: []v2.ScheduleWindow{
+					{Start: "0 22 * *", Duration: metav1.Duration{Duration: time.Hour}},
+				}},
+			},
+			wantFields: []string{"spec.schedule"},
+		},
+		{
+			name: "invalid ready expression",
+			spec: v2.HelmReleaseSpec{
+				ChartRef: chartRef,
+				DependsOn: []v2.DependencyReference{
+					{Name: "backend", ReadyExpr: "self.status.ready =="},
+				},
+			},
+			wantFields: []string{"spec.dependsOn[0].readyExpr"},
+		},
+		{
+			name: "invalid health check expression",
+			spec: v2.HelmReleaseSpec{
+				ChartRef: chartRef,
+				HealthCheckExprs: []v2.HealthCheckExpr{
+					{APIVersion: "kafka.strimzi.io/v1beta2", Kind: "Kafka", Current: "status.ready == true"},
+					{APIVersion: "example.com/v1", Kind: "Widget", Current: "status.phase =="},
+				},
+			},
+			wantFields: []string{"spec.healthCheckExprs[1]"},
+		},
+		{
+			name: "cross-namespace references",
+			spec: v2.HelmReleaseSpec{
+				Chart: &v2.HelmChartTemplate{
+					Spec: v2.HelmChartTemplateSpec{
+						Chart: "podinfo",
+						SourceRef: v2.CrossNamespaceObjectReference{
+							Kind:      sourcev1.HelmRepositoryKind,
+							Name:      "podinfo",
+							Namespace: "flux-system",
+						},
+					},
+				},
+				DependsOn: []v2.DependencyReference{
+					{Name: "backend", Namespace: "other"},
+					{APIVersion: "v1", Kind: "ConfigMap", Name: "ready", Namespace: "other"},
+				},
+			},
+			wantFields: []string{"spec.chart.spec.sourceRef.namespace", "spec.dependsOn[0].namespace", "spec.dependsOn[1].namespace"},
+		},
+		{
+			name:       "allowed cross-namespace references",
+			allowCross: true,
+			spec: v2.HelmReleaseSpec{
+				ChartRef: &v2.CrossNamespaceSourceReference{
+					Kind:      sourcev1beta2.OCIRepositoryKind,
+					Name:      "podinfo",
+					Namespace: "flux-system",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			allowCross := intacl.AllowCrossNamespaceRef
+			intacl.AllowCrossNamespaceRef = tt.allowCross
+			t.Cleanup(func() { intacl.AllowCrossNamespaceRef = allowCross })
+
+			obj := &v2.HelmRelease{
+				ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default"},
+				Spec:       tt.spec,
+			}
+
+			var got []string
+			for _, err := range ValidateHelmRelease(obj) {
+				got = append(got, err.Field)
+			}
+			g.Expect(got).To(ConsistOf(tt.wantFields))
+		})
+	}
+}
+
+func Test_validateJSONPointer(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, p := range []string{"", "/", "/spec/replicas", "/metadata/annotations/a~1b", "/data/a~0b"} {
+		g.Expect(validateJSONPointer(p)).To(Succeed(), p)
+	}
+	for _, p := range []string{"spec/replicas", "/data/a~", "/data/a~2b"} {
+		g.Expect(validateJSONPointer(p)).ToNot(Succeed(), p)
+	}
+}
+
+func TestHelmReleaseValidator_ValidateUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	invalid := &v2.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: "podinfo", Namespace: "default"},
+	}
+
+	v := &HelmReleaseValidator{}
+	_, err := v.ValidateUpdate(context.TODO(), invalid, invalid.DeepCopy())
+	g.Expect(err).ToNot(HaveOccurred())
+
+	deleting := invalid.DeepCopy()
+	deleting.Spec.ReleaseName = "podinfo"
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	_, err = v.ValidateUpdate(context.TODO(), invalid, deleting)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	changed := invalid.DeepCopy()
+	changed.Spec.ReleaseName = "podinfo"
+	_, err = v.ValidateUpdate(context.TODO(), invalid, changed)
+	g.Expect(apierrors.IsInvalid(err)).To(BeTrue())
+}
+
+func TestHelmReleaseValidator_admission(t *testing.T) {
+	g := NewWithT(t)
+
+	ctx := context.TODO()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "webhook-"}}
+	g.Expect(testClient.Create(ctx, ns)).To(Succeed())
+	t.Cleanup(func() { _ = testClient.Delete(ctx, ns) })
+
+	newHelmRelease := func(name string) *v2.HelmRelease {
+		return &v2.HelmRelease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns.Name},
+			Spec: v2.HelmReleaseSpec{
+				Interval: metav1.Duration{Duration: time.Minute},
+				ChartRef: &v2.CrossNamespaceSourceReference{
+					Kind: sourcev1beta2.OCIRepositoryKind,
+					Name: "podinfo",
+				},
+			},
+		}
+	}
+
+	t.Run("admits valid HelmRelease", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := newHelmRelease("valid")
+		g.Expect(testClient.Create(ctx, obj)).To(Succeed())
+
+		obj.Spec.DriftDetection = &v2.DriftDetection{
+			Ignore: []v2.IgnoreRule{{Paths: []string{"spec/replicas"}}},
+		}
+		err := testClient.Update(ctx, obj)
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("spec.driftDetection.ignore[0].paths[0]"))
+		g.Expect(err.Error()).To(ContainSubstring("JSON pointer must be empty or start with '/'"))
+	})
+
+	t.Run("rejects invalid HelmRelease", func(t *testing.T) {
+		g := NewWithT(t)
+
+		obj := newHelmRelease("invalid")
+		obj.Spec.ChartRef.Namespace = "flux-system"
+		obj.Spec.Schedule = &v2.Schedule{Windows: []v2.ScheduleWindow{
+			{Start: "0 22 * *", Duration: metav1.Duration{Duration: time.Hour}},
+		}}
+
+		err := testClient.Create(ctx, obj)
+		g.Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("spec.chartRef.namespace"))
+		g.Expect(err.Error()).To(ContainSubstring("cross-namespace references are not allowed"))
+		g.Expect(err.Error()).To(ContainSubstring("spec.schedule"))
+	})
+}
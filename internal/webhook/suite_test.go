@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	v2 "github.com/fluxcd/helm-controller/api/v2"
+	v2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	v2beta2 "github.com/fluxcd/helm-controller/api/v2beta2"
+)
+
+var (
+	testEnv *envtest.Environment
+	// testClient is a client for the test environment, which sends its
+	// requests through the validating and conversion webhooks.
+	testClient client.Client
+)
+
+func NewTestScheme() *runtime.Scheme {
+	s := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(s))
+	utilruntime.Must(v2.AddToScheme(s))
+	utilruntime.Must(v2beta2.AddToScheme(s))
+	utilruntime.Must(v2beta1.AddToScheme(s))
+	return s
+}
+
+func TestMain(m *testing.M) {
+	testEnv = &envtest.Environment{
+		// The scheme is used to configure the CRD conversion webhook for
+		// the convertible types.
+		Scheme: NewTestScheme(),
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "config", "crd", "bases"),
+		},
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			Paths: []string{
+				filepath.Join("..", "..", "config", "webhook", "manifests.yaml"),
+			},
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+
+	fmt.Println("Starting the test environment")
+	cfg, err := testEnv.Start()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to start the test environment: %v", err))
+	}
+
+	opts := testEnv.WebhookInstallOptions
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme: NewTestScheme(),
+		WebhookServer: ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Host:    opts.LocalServingHost,
+			Port:    opts.LocalServingPort,
+			CertDir: opts.LocalServingCertDir,
+		}),
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create the test manager: %v", err))
+	}
+	if err = (&HelmReleaseValidator{}).SetupWithManager(mgr); err != nil {
+		panic(fmt.Sprintf("Failed to set up the webhook: %v", err))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			panic(fmt.Sprintf("Failed to start the test manager: %v", err))
+		}
+	}()
+	if err = waitForWebhookServer(opts.LocalServingHost, opts.LocalServingPort); err != nil {
+		panic(fmt.Sprintf("Failed to wait for the webhook server: %v", err))
+	}
+
+	if testClient, err = client.New(cfg, client.Options{Scheme: NewTestScheme()}); err != nil {
+		panic(fmt.Sprintf("Failed to create the test client: %v", err))
+	}
+
+	code := m.Run()
+
+	fmt.Println("Stopping the test environment")
+	cancel()
+	if err := testEnv.Stop(); err != nil {
+		panic(fmt.Sprintf("Failed to stop the test environment: %v", err))
+	}
+
+	os.Exit(code)
+}
+
+// waitForWebhookServer waits for the webhook server at the given host and
+// port to accept TLS connections.
+func waitForWebhookServer(host string, port int) error {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: time.Second}
+
+	var err error
+	for i := 0; i < 30; i++ {
+		var conn *tls.Conn
+		if conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: true}); err == nil {
+			return conn.Close()
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
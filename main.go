@@ -19,12 +19,15 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	flag "github.com/spf13/pflag"
 	"helm.sh/helm/v3/pkg/kube"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -34,6 +37,7 @@ import (
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlcfg "sigs.k8s.io/controller-runtime/pkg/config"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/fluxcd/pkg/runtime/acl"
 	"github.com/fluxcd/pkg/runtime/client"
@@ -50,6 +54,8 @@ import (
 	sourcev1beta2 "github.com/fluxcd/source-controller/api/v1beta2"
 
 	v2 "github.com/fluxcd/helm-controller/api/v2"
+	v2beta1 "github.com/fluxcd/helm-controller/api/v2beta1"
+	v2beta2 "github.com/fluxcd/helm-controller/api/v2beta2"
 	intdigest "github.com/fluxcd/helm-controller/internal/digest"
 
 	// +kubebuilder:scaffold:imports
@@ -59,6 +65,8 @@ import (
 	"github.com/fluxcd/helm-controller/internal/features"
 	intkube "github.com/fluxcd/helm-controller/internal/kube"
 	"github.com/fluxcd/helm-controller/internal/oomwatch"
+	"github.com/fluxcd/helm-controller/internal/schedule"
+	"github.com/fluxcd/helm-controller/internal/webhook"
 )
 
 const controllerName = "helm-controller"
@@ -74,6 +82,8 @@ func init() {
 	utilruntime.Must(sourcev1.AddToScheme(scheme))
 	utilruntime.Must(sourcev1beta2.AddToScheme(scheme))
 	utilruntime.Must(v2.AddToScheme(scheme))
+	utilruntime.Must(v2beta2.AddToScheme(scheme))
+	utilruntime.Must(v2beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -100,6 +110,10 @@ func main() {
 		oomWatchMaxMemoryPath     string
 		oomWatchCurrentMemoryPath string
 		snapshotDigestAlgo        string
+		freezeWindows             []string
+		freezeConfigMap           string
+		webhookPort               int
+		webhookCertDir            string
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080",
@@ -128,6 +142,15 @@ func main() {
 		"The path to the cgroup current memory usage file. Requires feature gate 'OOMWatch' to be enabled. If not set, the path will be automatically detected.")
 	flag.StringVar(&snapshotDigestAlgo, "snapshot-digest-algo", intdigest.Canonical.String(),
 		"The algorithm to use to calculate the digest of Helm release storage snapshots.")
+	flag.StringArrayVar(&freezeWindows, "freeze-window", nil,
+		"A cluster-wide window in which no changes are made to Helm releases, in the format '<cron expression>;<duration>[;<time zone>]'. Can be specified multiple times.")
+	flag.StringVar(&freezeConfigMap, "freeze-config-map", "",
+		"The '<namespace>/<name>' of a ConfigMap holding a YAML list of cluster-wide windows in which no changes are made to Helm releases, in its 'windows' key. The controller must be allowed to list and watch ConfigMaps in its namespace.")
+
+	flag.IntVar(&webhookPort, "webhook-port", 0,
+		"The port the webhook server, serving the validating admission and CRD conversion webhooks, binds to. The webhook server is disabled when set to 0.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "",
+		"The directory holding the 'tls.crt' and 'tls.key' files of the webhook server. Defaults to the controller-runtime default when not set.")
 
 	clientOptions.BindFlags(flag.CommandLine)
 	logOptions.BindFlags(flag.CommandLine)
@@ -197,6 +220,27 @@ func main() {
 		intdigest.Canonical = algo
 	}
 
+	// Configure the cluster-wide freeze windows.
+	var freeze []schedule.Window
+	for _, v := range freezeWindows {
+		w, err := schedule.ParseWindowFlag(v)
+		if err != nil {
+			setupLog.Error(err, "unable to configure freeze window")
+			os.Exit(1)
+		}
+		freeze = append(freeze, w)
+	}
+	var freezeConfigMapRef *types.NamespacedName
+	if freezeConfigMap != "" {
+		namespace, name, ok := strings.Cut(freezeConfigMap, "/")
+		if !ok || namespace == "" || name == "" {
+			setupLog.Error(fmt.Errorf("expected format '<namespace>/<name>', got '%s'", freezeConfigMap),
+				"unable to configure freeze windows ConfigMap")
+			os.Exit(1)
+		}
+		freezeConfigMapRef = &types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
 	restConfig := client.GetConfigOrDie(clientOptions)
 
 	mgrConfig := ctrl.Options{
@@ -230,6 +274,13 @@ func main() {
 		},
 	}
 
+	if webhookPort > 0 {
+		mgrConfig.WebhookServer = ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Port:    webhookPort,
+			CertDir: webhookCertDir,
+		})
+	}
+
 	if watchNamespace != "" {
 		mgrConfig.Cache.DefaultNamespaces = map[string]ctrlcache.Config{
 			watchNamespace: ctrlcache.Config{},
@@ -244,6 +295,32 @@ func main() {
 
 	probes.SetupChecks(mgr, setupLog)
 
+	// Read the freeze windows ConfigMap from a cache dedicated to it, which
+	// is independent of the namespaces watched by the manager, and of the
+	// caching of ConfigMaps by the manager's client.
+	var freezeConfigMapReader ctrlclient.Reader
+	if freezeConfigMapRef != nil {
+		freezeCache, err := ctrlcache.New(mgr.GetConfig(), ctrlcache.Options{
+			Scheme: mgr.GetScheme(),
+			Mapper: mgr.GetRESTMapper(),
+			DefaultNamespaces: map[string]ctrlcache.Config{
+				freezeConfigMapRef.Namespace: {},
+			},
+			ByObject: map[ctrlclient.Object]ctrlcache.ByObject{
+				&corev1.ConfigMap{}: {Field: fields.OneTermEqualSelector("metadata.name", freezeConfigMapRef.Name)},
+			},
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to create freeze windows ConfigMap cache")
+			os.Exit(1)
+		}
+		if err = mgr.Add(freezeCache); err != nil {
+			setupLog.Error(err, "unable to add freeze windows ConfigMap cache")
+			os.Exit(1)
+		}
+		freezeConfigMapReader = freezeCache
+	}
+
 	metricsH := helper.NewMetrics(mgr, metrics.MustMakeRecorder(), v2.HelmReleaseFinalizer)
 	var eventRecorder *events.Recorder
 	if eventRecorder, err = events.NewRecorder(mgr, ctrl.Log, eventsAddr, controllerName); err != nil {
@@ -277,6 +354,10 @@ func main() {
 		ClientOpts:       clientOptions,
 		KubeConfigOpts:   kubeConfigOpts,
 		FieldManager:     controllerName,
+		FreezeWindows:    freeze,
+		FreezeConfigMap:  freezeConfigMapRef,
+
+		FreezeConfigMapReader: freezeConfigMapReader,
 	}).SetupWithManager(ctx, mgr, controller.HelmReleaseReconcilerOptions{
 		DependencyRequeueInterval: requeueDependency,
 		HTTPRetry:                 httpRetry,
@@ -285,6 +366,16 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", v2.HelmReleaseKind)
 		os.Exit(1)
 	}
+	if webhookPort > 0 {
+		if err = (&webhook.HelmReleaseValidator{}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", v2.HelmReleaseKind)
+			os.Exit(1)
+		}
+		if err = mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+			setupLog.Error(err, "unable to create webhook ready check")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")